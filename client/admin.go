@@ -0,0 +1,33 @@
+package client
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+)
+
+// execute an admin command, requires a user with admin rights on all namespaces
+func (client *Instance) Admin(request types.AdminRequest) (response *types.AdminResponse, err error) {
+	conn, err := client.GetConnection()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	// only transport errors are retried, commands like delete should not run twice after an error response
+	err = client.handleRetry(func() error {
+		response = &types.AdminResponse{}
+		return conn.call(types.EndpointAdmin, &request, response)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return response, response.Error.Error()
+	}
+	return response, nil
+}
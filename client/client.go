@@ -1,5 +1,14 @@
 package client
 
+import (
+	tsxdbRpc "github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"sync/atomic"
+	"time"
+)
+
 type Instance struct {
 	opts           *Opts
 	numConnections int64
@@ -7,9 +16,28 @@ type Instance struct {
 	closing        bool
 	seriesPool     *SeriesPool
 
+	errorLogSampler *tools.LogSampler // failures that can repeat on every call
+
+	numReauthentications uint64 // sessions expired by the server and transparently renewed
+
 	*EagerInitSeriesHelper
 }
 
+func (client *Instance) logger() logrus.FieldLogger {
+	if client.opts.Logger == nil {
+		return logrus.StandardLogger()
+	}
+	return client.opts.Logger
+}
+
+func (client *Instance) tracer() trace.Tracer {
+	return tsxdbRpc.Tracer(client.opts.TracerProvider)
+}
+
+func (client *Instance) NumReauthentications() uint64 {
+	return atomic.LoadUint64(&client.numReauthentications)
+}
+
 type EagerInitSeriesHelper struct {
 	preEagerInitFn func(series *Series)
 }
@@ -18,11 +46,15 @@ func (helper *EagerInitSeriesHelper) SetPreEagerInitFn(f func(series *Series)) {
 	helper.preEagerInitFn = f
 }
 
+// repeated failures are logged at most once per interval
+const errorLogSampleInterval = 10 * time.Second
+
 func New(opts *Opts) *Instance {
 	i := &Instance{
 		opts:                  opts,
 		seriesPool:            NewSeriesPool(opts),
 		EagerInitSeriesHelper: &EagerInitSeriesHelper{},
+		errorLogSampler:       tools.NewLogSampler(errorLogSampleInterval),
 	}
 	if err := i.initConnectionPool(); err != nil {
 		panic(err)
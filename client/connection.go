@@ -7,10 +7,8 @@ import (
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/tools"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
-	insecureRand "math/rand"
-	"net"
 	"net/rpc"
+	"reflect"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -32,7 +30,9 @@ func (client *Instance) GetConnection() (managedConnection *ManagedConnection, e
 	}()
 
 	// get connection, this may panic
+	getStart := time.Now()
 	conn := client.connectionPool.Get()
+	client.metrics().ObservePoolGet(time.Since(getStart), client.connectionPool.InUse(), client.connectionPool.MaxSize())
 	if conn == nil {
 		if client.closing {
 			return nil, nil
@@ -46,12 +46,16 @@ func (client *Instance) GetConnection() (managedConnection *ManagedConnection, e
 	// auth
 	if !managedConnection.authenticated {
 		// auth with retries
-		err = handleRetry(func() error {
+		err = client.handleRetry(func() error {
 			err := managedConnection.auth(client)
 			if err != nil {
 				// new connection
 				managedConnection.Discard()
 				client.connectionPool.Put(managedConnection)
+				if strings.Contains(err.Error(), types.RpcErrorAuthFailed.String()) || strings.Contains(err.Error(), types.RpcErrorAuthVersion.String()) {
+					// wrong credentials or incompatible server, non-retryable
+					panic(err)
+				}
 				managedConnection = client.connectionPool.Get().(*ManagedConnection)
 				return err
 			}
@@ -61,6 +65,7 @@ func (client *Instance) GetConnection() (managedConnection *ManagedConnection, e
 			// fatal auth error
 			return nil, err
 		}
+		managedConnection.authenticated = true
 	}
 
 	// track timing
@@ -73,7 +78,7 @@ func (client *Instance) GetConnection() (managedConnection *ManagedConnection, e
 		time.AfterFunc(returnTimeout, func() {
 			returned := atomic.LoadUint64(&managedConnection.poolReturn)
 			if returned < now {
-				log.Warnf("not returned connection after %s", returnTimeout)
+				client.logger().Warnf("not returned connection after %s", returnTimeout)
 			}
 		})
 	}
@@ -84,7 +89,7 @@ func (client *Instance) GetConnection() (managedConnection *ManagedConnection, e
 func (client *Instance) NewClient() (*ManagedConnection, error) {
 	// open connection
 	address := client.opts.ListenHost + fmt.Sprintf(":%d", client.opts.ListenPort)
-	conn, err := net.DialTimeout("tcp", address, client.opts.OptsConnection.ConnectTimeout)
+	conn, err := tsxdbRpc.Dial(address, client.opts.OptsConnection.ConnectTimeout, client.opts.OptsConnection.Tls)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +114,7 @@ type ManagedConnection struct {
 	authenticated bool
 	sessionId     int
 	sessionSecret []byte
+	nonce         uint64 // last nonce used in the session
 	poolGet       uint64
 	poolReturn    uint64
 	discard       bool // if set to true won't be returned back to the pool
@@ -118,7 +124,7 @@ type ManagedConnection struct {
 func (conn *ManagedConnection) DiscardPool() {
 	err := conn.Close()
 	if err != nil {
-		log.Errorf("failed to close connection %s", err)
+		conn.service.logger().WithError(err).Error("failed to close connection")
 	}
 }
 
@@ -138,7 +144,7 @@ func (conn *ManagedConnection) Close() error {
 	get := atomic.LoadUint64(&conn.poolGet)
 	took := nowMs() - get
 	if took > 30*1000 { // @todo configurable
-		log.Warnf("SLOW connection usage, taken at %d returned at %d took %d ms", get, now, took) // @todo via logger
+		conn.service.logger().Warnf("SLOW connection usage, taken at %d returned at %d took %d ms", get, now, took)
 	}
 
 	// track max usage per connection
@@ -176,14 +182,18 @@ func (conn *ManagedConnection) executeAuthRequest(request types.AuthRequest) (re
 	}()
 
 	// execute
+	start := time.Now()
 	if err := conn.client.Call(types.EndpointAuth.String()+"."+types.MethodName, request, &response); err != nil {
+		conn.observeRpc(types.EndpointAuth, start, err)
 		return nil, err
 	}
 
 	// pass back
 	if response.Error != nil {
+		conn.observeRpc(types.EndpointAuth, start, response.Error.Error())
 		return nil, response.Error.Error()
 	}
+	conn.observeRpc(types.EndpointAuth, start, nil)
 
 	success = true
 	// good
@@ -200,6 +210,10 @@ func (conn *ManagedConnection) auth(client *Instance) error {
 		if err != nil {
 			return err
 		}
+		clientNonce, err := base64.StdEncoding.DecodeString(request.Nonce)
+		if err != nil {
+			return err
+		}
 
 		// execute phase 1
 		resp, err := conn.executeAuthRequest(request)
@@ -212,13 +226,14 @@ func (conn *ManagedConnection) auth(client *Instance) error {
 			return errors.New("missing session id")
 		}
 		sessionId = resp.SessionId
-		if len(strings.TrimSpace(resp.SessionSecret)) < 1 {
-			return errors.New("missing session secret")
+		if len(strings.TrimSpace(resp.ServerNonce)) < 1 {
+			return errors.New("missing server nonce")
 		}
-		sessionSecret, err = base64.StdEncoding.DecodeString(resp.SessionSecret)
+		serverNonce, err := base64.StdEncoding.DecodeString(resp.ServerNonce)
 		if err != nil {
 			return err
 		}
+		sessionSecret = tools.SessionSecret([]byte(client.opts.AuthToken), clientNonce, serverNonce)
 		//log.Printf("resp stage 1 %+v", resp)
 	}
 
@@ -228,38 +243,97 @@ func (conn *ManagedConnection) auth(client *Instance) error {
 		if err != nil {
 			return err
 		}
-		request.SessionTicket.Id = sessionId
-		request.SessionTicket.Nonce = insecureRand.Int()
+		// store for next requests
+		conn.sessionId = sessionId
+		conn.sessionSecret = sessionSecret
+		atomic.StoreUint64(&conn.nonce, 0)
 
-		// signature of nonce
-		request.SessionTicket.Signature = tools.HmacInt(sessionSecret, request.SessionTicket.Nonce)
+		// signature of first nonce
+		request.SessionTicket = conn.getSessionTicket(types.EndpointAuth, request)
 
 		if _, err := conn.executeAuthRequest(request); err != nil {
 			return errors.Wrap(err, "failed auth call #2")
 		}
-		// store for next requests
-		conn.sessionId = sessionId
-		conn.sessionSecret = sessionSecret
 	}
 	//log.Println("auth complete")
 
 	return nil
 }
 
-func (conn *ManagedConnection) getSessionTicket() types.SessionTicket {
-	nonce := insecureRand.Int()
-	return types.SessionTicket{
-		Id:        conn.sessionId,
-		Nonce:     nonce,
-		Signature: tools.HmacInt(conn.sessionSecret, nonce),
+// sign and execute a call, if the server expired the session (e.g. idle pooled connection) authenticate again and repeat the call once
+func (conn *ManagedConnection) call(endpoint types.Endpoint, request types.SessionRequest, response types.SessionResponse) error {
+	for attempt := 0; ; attempt++ {
+		request.SetSessionTicket(conn.getSessionTicket(endpoint, request))
+		start := time.Now()
+		if err := conn.client.Call(endpoint.String()+"."+types.MethodName, request, response); err != nil {
+			conn.observeRpc(endpoint, start, err)
+			return err
+		}
+		responseError := response.ResponseError()
+		if responseError != nil {
+			conn.observeRpc(endpoint, start, responseError.Error())
+		} else {
+			conn.observeRpc(endpoint, start, nil)
+		}
+		if attempt > 0 || responseError == nil || *responseError != types.RpcErrorSessionExpired {
+			return nil
+		}
+		if err := conn.reauth(); err != nil {
+			return err
+		}
+		// gob does not transmit zero values, clear the expired error before decoding the repeated call
+		reflect.ValueOf(response).Elem().Set(reflect.Zero(reflect.TypeOf(response).Elem()))
 	}
 }
 
+func (conn *ManagedConnection) observeRpc(endpoint types.Endpoint, start time.Time, err error) {
+	conn.service.metrics().ObserveRpc(endpoint, time.Since(start), err)
+}
+
+// authenticate again on the same connection, e.g. after the server expired the session
+func (conn *ManagedConnection) reauth() error {
+	conn.authenticated = false
+	if err := conn.auth(conn.service); err != nil {
+		return err
+	}
+	conn.authenticated = true
+	atomic.AddUint64(&conn.service.numReauthentications, 1)
+	return nil
+}
+
+// end the session on the server when the client closes (best effort), without authenticating again: an expired session is logged out already
+// pooled connections that are recycled do not need this, the server expires the sessions of a connection once it is closed
+func (conn *ManagedConnection) logout() {
+	if !conn.authenticated {
+		return
+	}
+	conn.authenticated = false
+	request := &types.LogoutRequest{}
+	request.SetSessionTicket(conn.getSessionTicket(types.EndpointLogout, request))
+	response := &types.LogoutResponse{}
+	start := time.Now()
+	err := conn.client.Call(types.EndpointLogout.String()+"."+types.MethodName, request, response)
+	if err == nil && response.Error != nil && *response.Error != types.RpcErrorSessionExpired {
+		err = response.Error.Error()
+	}
+	conn.observeRpc(types.EndpointLogout, start, err)
+	if err != nil {
+		conn.service.logger().WithError(err).Debug("logout failed")
+	}
+}
+
+// sign a request with the next nonce, call again for each (re)transmission of the request
+func (conn *ManagedConnection) getSessionTicket(endpoint types.Endpoint, request types.SignedRequest) types.SessionTicket {
+	nonce := atomic.AddUint64(&conn.nonce, 1)
+	return tools.SignSessionTicket(conn.sessionSecret, conn.sessionId, nonce, endpoint, request)
+}
+
 func (client *Instance) Close() {
 	client.closing = true
 	for {
 		conn, _ := client.GetConnection()
 		if conn != nil {
+			conn.logout()
 			_ = conn.client.Close()
 		} else {
 			break
@@ -1,8 +1,6 @@
 package client
 
 import (
-	"github.com/pkg/errors"
-	"log"
 	"sync/atomic"
 )
 
@@ -21,14 +19,16 @@ func (client *Instance) NewConnectionPool() *ConnectionPool {
 			}
 			c, err := client.NewClient()
 			if err != nil {
-				log.Printf("init connection err %s", errors.Wrap(err, "failed to init new connection "))
+				if logger := client.errorLogSampler.Sample(client.logger(), "connect"); logger != nil {
+					logger.WithError(err).Error("failed to init new connection")
+				}
 				return nil
 			}
 			if client.opts.Debug && client.connectionPool != nil {
 				numCreated := atomic.LoadUint64(&client.connectionPool.numCreated)
 				numDiscarded := atomic.LoadUint64(&client.connectionPool.numDiscarded)
 				numConnections := numCreated - numDiscarded
-				log.Printf("numConnections %d numCreated %d numDiscarded %d", numConnections, numCreated, numDiscarded)
+				client.logger().Debugf("numConnections %d numCreated %d numDiscarded %d", numConnections, numCreated, numDiscarded)
 			}
 			return c
 		},
@@ -121,6 +121,15 @@ func (p *GenericPool) Put(v interface{}) {
 	}
 }
 
+// created and not discarded, minus the ones waiting in the pool
+func (p *GenericPool) InUse() int {
+	return int(atomic.LoadUint64(&p.numCreated)-atomic.LoadUint64(&p.numDiscarded)) - len(p.pool)
+}
+
+func (p *GenericPool) MaxSize() int {
+	return p.opts.MaxSize
+}
+
 func (p *GenericPool) Size() int {
 	return len(p.pool)
 }
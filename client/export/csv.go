@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{"series", "namespace", "timestamp", "value"}
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (w *csvWriter) Write(row Row) error {
+	w.record[0] = row.Series
+	w.record[1] = strconv.Itoa(row.Namespace)
+	w.record[2] = strconv.FormatUint(row.Timestamp, 10)
+	w.record[3] = strconv.FormatFloat(row.Value, 'g', -1, 64)
+	return w.writer.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func newCsvWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvWriter{
+		writer: writer,
+		record: make([]string, len(csvHeader)),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"io"
+)
+
+type Format string
+
+const FormatCsv Format = "csv"
+const FormatNdjson Format = "ndjson" // one json object per line
+const FormatParquet Format = "parquet"
+
+var Formats = []Format{FormatCsv, FormatNdjson, FormatParquet}
+
+type Opts struct {
+	Format   Format
+	From     uint64 // milliseconds, inclusive
+	To       uint64 // milliseconds, inclusive
+	PageSize int    // points per read, bounds the points held in memory
+}
+
+// a single point of a series
+type Row struct {
+	Namespace int     `json:"namespace"`
+	Series    string  `json:"series"`
+	Timestamp uint64  `json:"timestamp"` // milliseconds
+	Value     float64 `json:"value"`
+}
+
+type Result struct {
+	Series int
+	Points int
+}
+
+type Exporter struct {
+	client *client.Instance
+	opts   Opts
+}
+
+// rows of one format, written in order
+type rowWriter interface {
+	Write(row Row) error
+	Close() error // flush, does not close the underlying writer
+}
+
+func newRowWriter(format Format, w io.Writer) (rowWriter, error) {
+	switch format {
+	case FormatCsv:
+		return newCsvWriter(w)
+	case FormatNdjson:
+		return newNdjsonWriter(w), nil
+	case FormatParquet:
+		return newParquetWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown export format %s", format)
+	}
+}
+
+// stream the points of all series within the time range, series by series and ordered by time
+func (exporter *Exporter) Export(w io.Writer, series []*client.Series) (result Result, err error) {
+	writer, err := newRowWriter(exporter.opts.Format, w)
+	if err != nil {
+		return result, err
+	}
+	for _, s := range series {
+		points, err := exporter.exportSeries(writer, s)
+		if err != nil {
+			return result, err
+		}
+		result.Series++
+		result.Points += points
+	}
+	return result, writer.Close()
+}
+
+func (exporter *Exporter) exportSeries(writer rowWriter, series *client.Series) (points int, err error) {
+	it := series.QueryBuilder().From(exporter.opts.From).To(exporter.opts.To).Pages(exporter.opts.PageSize)
+	for it.Next() {
+		if valueType := it.ValueType(); !valueType.Numeric() {
+			return points, fmt.Errorf("series %s: %s values can not be exported", series.Name(), valueType)
+		}
+		ts, value := it.Value()
+		if err := writer.Write(Row{
+			Namespace: series.Namespace(),
+			Series:    series.Name(),
+			Timestamp: ts,
+			Value:     value,
+		}); err != nil {
+			return points, err
+		}
+		points++
+	}
+	if err := it.Err(); err != nil {
+		return points, fmt.Errorf("series %s: %s", series.Name(), err)
+	}
+	return points, nil
+}
+
+func New(c *client.Instance, opts Opts) (*Exporter, error) {
+	if !isKnownFormat(opts.Format) {
+		return nil, fmt.Errorf("unknown export format %s", opts.Format)
+	}
+	if opts.From == 0 || opts.To == 0 || opts.From > opts.To {
+		return nil, fmt.Errorf("invalid time range %d - %d", opts.From, opts.To)
+	}
+	if opts.PageSize < 1 {
+		opts.PageSize = client.DefaultPageSize
+	}
+	return &Exporter{
+		client: c,
+		opts:   opts,
+	}, nil
+}
+
+func isKnownFormat(format Format) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type ndjsonWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(row Row) error {
+	// the encoder terminates every value with a newline
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.buffer.Flush()
+}
+
+func newNdjsonWriter(w io.Writer) *ndjsonWriter {
+	buffer := bufio.NewWriter(w)
+	return &ndjsonWriter{
+		buffer:  buffer,
+		encoder: json.NewEncoder(buffer),
+	}
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	sourcewriter "github.com/xitongsys/parquet-go-source/writer"
+	"github.com/xitongsys/parquet-go/writer"
+	"io"
+)
+
+const parquetRowGroupSize = 16 * 1024 * 1024 // bytes buffered before a row group is written
+
+// columns of the parquet file
+type ParquetRow struct {
+	Series    string  `parquet:"name=series, type=UTF8, encoding=PLAIN_DICTIONARY"`
+	Namespace int32   `parquet:"name=namespace, type=INT32"`
+	Timestamp int64   `parquet:"name=timestamp, type=TIMESTAMP_MILLIS"`
+	Value     float64 `parquet:"name=value, type=DOUBLE"`
+}
+
+type parquetWriter struct {
+	writer *writer.ParquetWriter
+}
+
+func (w *parquetWriter) Write(row Row) error {
+	return w.writer.Write(ParquetRow{
+		Series:    row.Series,
+		Namespace: int32(row.Namespace),
+		Timestamp: int64(row.Timestamp),
+		Value:     row.Value,
+	})
+}
+
+func (w *parquetWriter) Close() error {
+	// writes the remaining row group and the footer
+	return w.writer.WriteStop()
+}
+
+func newParquetWriter(w io.Writer) (*parquetWriter, error) {
+	pw, err := writer.NewParquetWriter(sourcewriter.NewWriterFile(w), new(ParquetRow), 1)
+	if err != nil {
+		return nil, err
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	return &parquetWriter{
+		writer: pw,
+	}, nil
+}
@@ -15,5 +15,10 @@ require (
 	github.com/RobinUS2/tsxdb/tools v0.0.0-20200901125404-22137cdbe6ba
 	github.com/karlseguin/ccache/v2 v2.0.6
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.1
 	github.com/sirupsen/logrus v1.9.0
+	github.com/xitongsys/parquet-go v1.5.1
+	github.com/xitongsys/parquet-go-source v0.0.0-20190524061010-2b72cbee77d5
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 )
@@ -0,0 +1,219 @@
+package importer
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/client/export"
+	"hash/fnv"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultBatchSize = 1000
+const flushInterval = 10 * time.Second // batches are flushed at every checkpoint anyway
+
+type Opts struct {
+	Format      export.Format // csv or ndjson
+	Namespace   int           // of rows without a namespace
+	BatchSize   int           // points per write and series per metadata request
+	Parallelism int           // concurrent writers, points of one series are always written by the same writer
+	Offset      uint64        // rows to skip, e.g. to resume a failed import
+	DryRun      bool          // only validate the rows, nothing is written
+}
+
+type Result struct {
+	Rows   uint64 // read, including the skipped rows
+	Points int    // written (or validated during a dry run)
+	Series int    // distinct series of the points
+	// all rows before this offset are written, resume a failed import from here
+	Offset uint64
+}
+
+type Importer struct {
+	client *client.Instance
+	opts   Opts
+
+	namespaces map[string]int // of every series, series are pooled by name only
+	writers    []*client.AutoBatchWriter
+}
+
+type importRow struct {
+	Row
+	namespace int
+	offset    uint64
+}
+
+// read and write all rows, written in checkpoints of batch size * parallelism rows
+func (importer *Importer) Import(r io.Reader) (result Result, err error) {
+	reader, err := newRowReader(importer.opts.Format, r)
+	if err != nil {
+		return result, err
+	}
+	result.Offset = importer.opts.Offset
+	defer func() {
+		result.Series = len(importer.namespaces)
+	}()
+
+	if !importer.opts.DryRun {
+		importer.writers = make([]*client.AutoBatchWriter, importer.opts.Parallelism)
+		for i := range importer.writers {
+			writer := importer.client.NewAutoBatchWriter(uint64(importer.opts.BatchSize), flushInterval, client.NewAutoBatchOptAsyncFlush(false))
+			// flushes of the ticker report here instead of panicking
+			writer.Errors(1)
+			importer.writers[i] = writer
+		}
+		defer func() {
+			for _, writer := range importer.writers {
+				_ = writer.Close()
+			}
+		}()
+	}
+
+	chunkSize := importer.opts.BatchSize * importer.opts.Parallelism
+	chunk := make([]importRow, 0, chunkSize)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		offset := result.Rows
+		result.Rows++
+		if err != nil {
+			return result, fmt.Errorf("row %d: %s", offset, err)
+		}
+		if offset < importer.opts.Offset {
+			continue
+		}
+		namespace, err := importer.validate(row)
+		if err != nil {
+			return result, fmt.Errorf("row %d: %s", offset, err)
+		}
+		chunk = append(chunk, importRow{Row: row, namespace: namespace, offset: offset})
+		if len(chunk) < chunkSize {
+			continue
+		}
+		if err := importer.write(chunk); err != nil {
+			return result, err
+		}
+		result.Points += len(chunk)
+		result.Offset = result.Rows
+		chunk = chunk[:0]
+	}
+	if len(chunk) > 0 {
+		if err := importer.write(chunk); err != nil {
+			return result, err
+		}
+		result.Points += len(chunk)
+	}
+	result.Offset = result.Rows
+	return result, nil
+}
+
+func (importer *Importer) validate(row Row) (namespace int, err error) {
+	if len(row.Series) < 1 {
+		return 0, fmt.Errorf("missing series")
+	}
+	if strings.Contains(row.Series, " ") {
+		return 0, fmt.Errorf("series %s contains whitespace", row.Series)
+	}
+	if row.Timestamp < 1 {
+		return 0, fmt.Errorf("missing timestamp")
+	}
+	namespace = importer.opts.Namespace
+	if row.Namespace != nil {
+		namespace = *row.Namespace
+	}
+	if existing, found := importer.namespaces[row.Series]; found && existing != namespace {
+		return 0, fmt.Errorf("series %s in namespaces %d and %d", row.Series, existing, namespace)
+	}
+	importer.namespaces[row.Series] = namespace
+	return namespace, nil
+}
+
+// write a checkpoint, returns once all points are flushed
+func (importer *Importer) write(rows []importRow) error {
+	if importer.opts.DryRun {
+		return nil
+	}
+
+	// metadata of new series in batches
+	series := make([]*client.Series, len(rows))
+	var create []*client.Series
+	for i, row := range rows {
+		series[i] = importer.client.Series(row.Series, client.NewSeriesNamespace(row.namespace), client.NewSeriesTags(row.Tags...))
+		if series[i].Id() < 1 {
+			create = append(create, series[i])
+		}
+	}
+	for len(create) > 0 {
+		n := len(create)
+		if n > importer.opts.BatchSize {
+			n = importer.opts.BatchSize
+		}
+		if err := importer.client.CreateSeries(create[:n]); err != nil {
+			return fmt.Errorf("rows %d - %d: %s", rows[0].offset, rows[len(rows)-1].offset, err)
+		}
+		create = create[n:]
+	}
+
+	// points, partitioned by series
+	var wg sync.WaitGroup
+	errs := make([]error, len(importer.writers))
+	partitions := make([][]int, len(importer.writers))
+	for i, row := range rows {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(row.Series))
+		partition := int(h.Sum32() % uint32(len(importer.writers)))
+		partitions[partition] = append(partitions[partition], i)
+	}
+	for i, writer := range importer.writers {
+		wg.Add(1)
+		go func(i int, writer *client.AutoBatchWriter) {
+			defer wg.Done()
+			for _, j := range partitions[i] {
+				if err := writer.AddToBatch(series[j], rows[j].Timestamp, rows[j].Value); err != nil {
+					errs[i] = err
+					return
+				}
+			}
+			errs[i] = writer.Flush()
+		}(i, writer)
+	}
+	wg.Wait()
+	for i, writer := range importer.writers {
+		select {
+		case err := <-writer.Errors():
+			errs[i] = err
+		default:
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("rows %d - %d: %s", rows[0].offset, rows[len(rows)-1].offset, err)
+		}
+	}
+	return nil
+}
+
+// the client should not eagerly init series, metadata is created in batches instead
+func New(c *client.Instance, opts Opts) (*Importer, error) {
+	if opts.Format != export.FormatCsv && opts.Format != export.FormatNdjson {
+		return nil, fmt.Errorf("unsupported import format %s", opts.Format)
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Parallelism < 1 {
+		opts.Parallelism = 1
+	}
+	if c == nil && !opts.DryRun {
+		return nil, fmt.Errorf("missing client")
+	}
+	return &Importer{
+		client:     c,
+		opts:       opts,
+		namespaces: make(map[string]int),
+	}, nil
+}
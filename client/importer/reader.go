@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client/export"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TagSeparator = "|" // between the tags of a csv row
+
+// a single point of a series, namespace and tags are optional
+type Row struct {
+	Namespace *int     `json:"namespace"`
+	Series    string   `json:"series"`
+	Tags      []string `json:"tags"`
+	Timestamp uint64   `json:"timestamp"` // milliseconds
+	Value     float64  `json:"value"`
+}
+
+// rows of one format, io.EOF after the last row
+type rowReader interface {
+	Read() (Row, error)
+}
+
+func newRowReader(format export.Format, r io.Reader) (rowReader, error) {
+	switch format {
+	case export.FormatCsv:
+		return newCsvReader(r)
+	case export.FormatNdjson:
+		return newNdjsonReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %s", format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+var csvRequiredColumns = []string{"series", "timestamp", "value"}
+
+func (r *csvReader) Read() (row Row, err error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return row, err
+	}
+	row.Series = record[r.columns["series"]]
+	if row.Timestamp, err = parseTimestamp(record[r.columns["timestamp"]]); err != nil {
+		return row, err
+	}
+	if row.Value, err = strconv.ParseFloat(record[r.columns["value"]], 64); err != nil {
+		return row, fmt.Errorf("invalid value %s", record[r.columns["value"]])
+	}
+	if i, found := r.columns["namespace"]; found && len(record[i]) > 0 {
+		namespace, err := strconv.Atoi(record[i])
+		if err != nil {
+			return row, fmt.Errorf("invalid namespace %s", record[i])
+		}
+		row.Namespace = &namespace
+	}
+	if i, found := r.columns["tags"]; found && len(record[i]) > 0 {
+		row.Tags = strings.Split(record[i], TagSeparator)
+	}
+	return row, nil
+}
+
+func newCsvReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %s", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range csvRequiredColumns {
+		if _, found := columns[column]; !found {
+			return nil, fmt.Errorf("missing csv column %s", column)
+		}
+	}
+	return &csvReader{
+		reader:  reader,
+		columns: columns,
+	}, nil
+}
+
+// RFC3339 or unix milliseconds
+func parseTimestamp(value string) (uint64, error) {
+	if ms, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %s", value)
+	}
+	return uint64(t.UnixNano() / int64(time.Millisecond)), nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *ndjsonReader) Read() (row Row, err error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) < 1 {
+			continue
+		}
+		err = json.Unmarshal(line, &row)
+		return row, err
+	}
+	if err := r.scanner.Err(); err != nil {
+		return row, err
+	}
+	return row, io.EOF
+}
+
+func newNdjsonReader(r io.Reader) *ndjsonReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &ndjsonReader{
+		scanner: scanner,
+	}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+)
+
+// names of the labels of the series in the namespace, sorted
+func (client *Instance) LabelNames(namespace int) ([]string, error) {
+	return client.labels(namespace, "")
+}
+
+// values of a label of the series in the namespace, sorted
+func (client *Instance) LabelValues(namespace int, name string) ([]string, error) {
+	if len(name) < 1 {
+		return nil, errors.New("missing label name")
+	}
+	return client.labels(namespace, name)
+}
+
+func (client *Instance) labels(namespace int, name string) (values []string, err error) {
+	conn, err := client.GetConnection()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	var response *types.LabelsResponse
+	err = client.handleRetry(func() error {
+		request := &types.LabelsRequest{
+			Namespace: namespace,
+			Name:      name,
+		}
+		response = &types.LabelsResponse{}
+		if err := conn.call(types.EndpointLabels, request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if *response.Error == types.RpcErrorPermissionDenied {
+				// non-retryable
+				panic(response.Error)
+			}
+			return response.Error.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Values, nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"time"
+)
+
+// receives client instrumentation, set through Opts.Metrics, implementations must be safe for concurrent use
+type Metrics interface {
+	ObserveRpc(endpoint types.Endpoint, duration time.Duration, err error) // every rpc call, including auth and retries
+	ObserveRetry(attempt int, err error)                                   // a failed attempt that will be retried
+	ObserveBatchSize(items int)                                            // items in an executed batch
+	ObserveFlush(duration time.Duration, err error)                        // auto batch flushes
+	ObserveSeriesInit(state SeriesInitState)                               // outcome of every Series.Init
+	ObservePoolGet(wait time.Duration, inUse int, maxSize int)             // connection pool saturation on every connection get
+}
+
+type SeriesInitState string
+
+func (state SeriesInitState) String() string {
+	return string(state)
+}
+
+const SeriesInitCached SeriesInitState = "cached"     // already initialised, no rpc call
+const SeriesInitCreated SeriesInitState = "created"   // created on the server
+const SeriesInitExisting SeriesInitState = "existing" // existed on the server
+const SeriesInitUpdated SeriesInitState = "updated"   // existed on the server with other tags or ttl, updated to the ones of the series
+const SeriesInitFailed SeriesInitState = "failed"
+
+// default, discards everything
+type NoOpMetrics struct{}
+
+func (NoOpMetrics) ObserveRpc(types.Endpoint, time.Duration, error) {}
+func (NoOpMetrics) ObserveRetry(int, error)                         {}
+func (NoOpMetrics) ObserveBatchSize(int)                            {}
+func (NoOpMetrics) ObserveFlush(time.Duration, error)               {}
+func (NoOpMetrics) ObserveSeriesInit(SeriesInitState)               {}
+func (NoOpMetrics) ObservePoolGet(time.Duration, int, int)          {}
+
+func (client *Instance) metrics() Metrics {
+	if client.opts.Metrics == nil {
+		return NoOpMetrics{}
+	}
+	return client.opts.Metrics
+}
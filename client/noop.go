@@ -20,13 +20,12 @@ func (series *Series) NoOp() (err error) {
 	}()
 
 	// session data
-	return handleRetry(func() error {
-		request := types.NoOpRequest{}
-		request.SessionTicket = conn.getSessionTicket()
+	return series.client.handleRetry(func() error {
+		request := &types.NoOpRequest{}
 
 		// execute
-		var response *types.NoOpResponse
-		if err := conn.client.Call(types.EndpointNoOp.String()+"."+types.MethodName, request, &response); err != nil {
+		response := &types.NoOpResponse{}
+		if err := conn.call(types.EndpointNoOp, request, response); err != nil {
 			return err
 		}
 		if response.Error != nil {
@@ -2,12 +2,17 @@ package client
 
 import (
 	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Opts struct {
 	rpc.OptsConnection
 	SeriesCacheSize int64
-	EagerInitSeries bool // will load metadata on creation (async, instead of during flush, more equally spreading out load)
+	EagerInitSeries bool                 // will load metadata on creation (async, instead of during flush, more equally spreading out load)
+	Metrics         Metrics              // instrumentation callbacks, e.g. prommetrics.New
+	TracerProvider  trace.TracerProvider // optional, spans are propagated to the server
+	Logger          logrus.FieldLogger   // optional, defaults to the standard logger
 }
 
 func NewOpts() *Opts {
@@ -15,5 +20,6 @@ func NewOpts() *Opts {
 		OptsConnection:  rpc.NewOptsConnection(),
 		SeriesCacheSize: 100 * 1000, // by default keep 100K series metadata IDs in-memory
 		EagerInitSeries: true,
+		Metrics:         NoOpMetrics{},
 	}
 }
@@ -0,0 +1,113 @@
+package prommetrics
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// prometheus implementation of client.Metrics
+
+const namespace = "tsxdb_client"
+
+const statusOk = "ok"
+const statusError = "error"
+
+type Metrics struct {
+	rpcDuration    *prometheus.HistogramVec // endpoint, status
+	retries        prometheus.Counter
+	batchSize      prometheus.Histogram
+	flushDuration  *prometheus.HistogramVec // status
+	seriesInit     *prometheus.CounterVec   // state
+	poolWait       prometheus.Histogram
+	poolInUse      prometheus.Gauge
+	poolSaturation prometheus.Gauge
+}
+
+func status(err error) string {
+	if err != nil {
+		return statusError
+	}
+	return statusOk
+}
+
+func (m *Metrics) ObserveRpc(endpoint types.Endpoint, duration time.Duration, err error) {
+	m.rpcDuration.WithLabelValues(endpoint.String(), status(err)).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveRetry(_ int, _ error) {
+	m.retries.Inc()
+}
+
+func (m *Metrics) ObserveBatchSize(items int) {
+	m.batchSize.Observe(float64(items))
+}
+
+func (m *Metrics) ObserveFlush(duration time.Duration, err error) {
+	m.flushDuration.WithLabelValues(status(err)).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveSeriesInit(state client.SeriesInitState) {
+	m.seriesInit.WithLabelValues(state.String()).Inc()
+}
+
+func (m *Metrics) ObservePoolGet(wait time.Duration, inUse int, maxSize int) {
+	m.poolWait.Observe(wait.Seconds())
+	m.poolInUse.Set(float64(inUse))
+	if maxSize > 0 {
+		m.poolSaturation.Set(float64(inUse) / float64(maxSize))
+	}
+}
+
+// registers the collectors, e.g. with prometheus.DefaultRegisterer
+func New(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_duration_seconds",
+			Help:      "Latency of rpc calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_total",
+			Help:      "Failed attempts that were retried.",
+		}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_items",
+			Help:      "Items in executed write batches.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+		}),
+		flushDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "flush_duration_seconds",
+			Help:      "Duration of auto batch flushes.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+		seriesInit: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "series_init_total",
+			Help:      "Series initialisations by outcome.",
+		}, []string{"state"}),
+		poolWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pool_wait_seconds",
+			Help:      "Time waited for a pooled connection.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}),
+		poolInUse: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pool_connections_in_use",
+			Help:      "Connections taken from the pool.",
+		}),
+		poolSaturation: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pool_saturation_ratio",
+			Help:      "Connections in use relative to the maximum pool size.",
+		}),
+	}
+	registerer.MustRegister(m.rpcDuration, m.retries, m.batchSize, m.flushDuration, m.seriesInit, m.poolWait, m.poolInUse, m.poolSaturation)
+	return m
+}
@@ -12,6 +12,7 @@ type QueryBuilder struct {
 	series *Series
 	from   uint64
 	to     uint64
+	fields []string
 }
 
 func (series *Series) QueryBuilder() *QueryBuilder {
@@ -30,6 +31,12 @@ func (builder *QueryBuilder) To(to uint64) *QueryBuilder {
 	return builder
 }
 
+// selected fields of a fields series, the values of the results are in this order
+func (builder *QueryBuilder) Fields(fields ...string) *QueryBuilder {
+	builder.fields = fields
+	return builder
+}
+
 func (builder *QueryBuilder) IsValid() error {
 	if builder.from == 0 || builder.to == 0 {
 		return errors.New("missing time range")
@@ -48,6 +55,7 @@ func (builder *QueryBuilder) ToQuery() (*Query, error) {
 		Series: builder.series,
 		From:   builder.from,
 		To:     builder.to,
+		Fields: builder.fields,
 	}
 	return &query, nil
 }
@@ -73,4 +81,5 @@ type Query struct {
 	Series *Series
 	From   uint64
 	To     uint64
+	Fields []string // selected fields of a fields series, empty for all
 }
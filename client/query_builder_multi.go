@@ -1,9 +1,13 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	tsxdbRpc "github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"strings"
 )
 
@@ -33,6 +37,17 @@ func (multi *MultiQueryBuilder) AddQuery(queryBuilder *QueryBuilder) error {
 
 // will return queries in the order as they are added via AddQuery
 func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
+	return multi.ExecuteContext(context.Background())
+}
+
+// execute within the trace of ctx (if any)
+func (multi *MultiQueryBuilder) ExecuteContext(ctx context.Context) (res MultiQueryResult) {
+	// tracing
+	ctx, span := multi.client.tracer().Start(ctx, "MultiQueryBuilder.Execute", trace.WithAttributes(attribute.Int("tsxdb.queries", len(multi.queries))))
+	defer func() {
+		tsxdbRpc.EndSpan(span, res.Error)
+	}()
+
 	// get
 	conn, err := multi.client.GetConnection()
 	if err != nil {
@@ -48,8 +63,7 @@ func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
 
 	// batch request
 	request := types.ReadRequest{
-		Queries:       []types.ReadSeriesRequest{},
-		SessionTicket: conn.getSessionTicket(),
+		Queries: []types.ReadSeriesRequest{},
 	}
 
 	// init series
@@ -57,7 +71,7 @@ func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
 	for idx, query := range multi.queries {
 		// get series ID
 		var seriesId uint64
-		if seriesId, err = query.Series.Init(conn); err != nil {
+		if seriesId, err = query.Series.InitContext(ctx, conn); err != nil {
 			res.Error = err
 			return
 		}
@@ -65,8 +79,9 @@ func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
 
 		// query
 		queryRequest := types.ReadSeriesRequest{
-			From: query.From,
-			To:   query.To,
+			From:   query.From,
+			To:     query.To,
+			Fields: query.Fields,
 			SeriesIdentifier: types.SeriesIdentifier{
 				Id:        seriesId,
 				Namespace: query.Series.Namespace(),
@@ -76,14 +91,17 @@ func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
 	}
 
 	// execute with retries
+	tsxdbRpc.InjectTraceContext(ctx, &request.TraceContext)
 	var response *types.ReadResponse
-	err = handleRetry(func() error {
-		if err := conn.client.Call(types.EndpointReader.String()+"."+types.MethodName, request, &response); err != nil {
+	err = multi.client.handleRetry(func() error {
+		// signed with a fresh nonce for every attempt, the server rejects replays
+		response = &types.ReadResponse{}
+		if err := conn.call(types.EndpointReader, &request, response); err != nil {
 			return err
 		}
 		if response.Error != nil {
-			if strings.Contains(response.Error.String(), types.RpcErrorNoDataFound.String()) {
-				// not retryable if no data
+			if strings.Contains(response.Error.String(), types.RpcErrorNoDataFound.String()) || strings.HasPrefix(response.Error.String(), types.RpcErrorQuotaExceeded.String()) {
+				// not retryable if no data or too much data
 				panic(response.Error.String())
 			}
 			return response.Error.Error()
@@ -96,8 +114,8 @@ func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
 	}
 
 	// verify number of results equals what we requested
-	if len(request.Queries) != len(response.Results) {
-		res.Error = fmt.Errorf("expected %d results got %d", len(request.Queries), len(response.Results))
+	if numResults := len(response.Results) + len(response.TypedResults); len(request.Queries) != numResults {
+		res.Error = fmt.Errorf("expected %d results got %d", len(request.Queries), numResults)
 		return
 	}
 
@@ -109,9 +127,22 @@ func (multi *MultiQueryBuilder) Execute() (res MultiQueryResult) {
 			panic("missing series in map, should never happen, potential loss of metadata")
 		}
 		res.Results[idx] = QueryResult{
-			Series:  multi.queries[idx].Series,
-			Results: results,
-			Error:   nil,
+			Series:    multi.queries[idx].Series,
+			Results:   results,
+			ValueType: response.ValueTypes[seriesId],
+			Error:     nil,
+		}
+	}
+	for seriesId, results := range response.TypedResults {
+		idx, ok := querySeriesIdxMap[seriesId]
+		if !ok {
+			panic("missing series in map, should never happen, potential loss of metadata")
+		}
+		res.Results[idx] = QueryResult{
+			Series:       multi.queries[idx].Series,
+			TypedResults: results,
+			ValueType:    response.ValueTypes[seriesId],
+			Fields:       response.Fields[seriesId],
 		}
 	}
 
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	tsxdbRpc "github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const DefaultPageSize = 10000 // points
+
+// reads the points of the range lazily in pages sorted by time, only one page is held in memory
+// usage: for it.Next() { ts, v := it.Value() } and then check it.Err()
+// series that are not float use it.TypedValue() instead
+func (builder *QueryBuilder) Pages(pageSize int) *PageIterator {
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	return &PageIterator{
+		builder:  builder,
+		pageSize: pageSize,
+		current:  -1,
+	}
+}
+
+type PageIterator struct {
+	builder      *QueryBuilder
+	pageSize     int
+	page         types.ReadPage
+	valueType    types.ValueType
+	fields       []string
+	current      int
+	pages        int
+	done         bool
+	err          error
+	continuation uint64
+}
+
+func (iter *PageIterator) Next() bool {
+	iter.current++
+	for iter.current >= len(iter.page.Times) {
+		if iter.done || iter.err != nil {
+			return false
+		}
+		if iter.err = iter.fetch(); iter.err != nil {
+			return false
+		}
+		iter.current = 0
+	}
+	return true
+}
+
+// int and bool values are converted, other series that are not float return 0
+func (iter *PageIterator) Value() (uint64, float64) {
+	if iter.page.TypedValues != nil {
+		value, _ := iter.page.TypedValues[iter.current].Float64(iter.valueType)
+		return iter.page.Times[iter.current], value
+	}
+	return iter.page.Times[iter.current], iter.page.Values[iter.current]
+}
+
+func (iter *PageIterator) TypedValue() (uint64, types.Value) {
+	if iter.page.TypedValues == nil {
+		return iter.page.Times[iter.current], types.Value{}
+	}
+	return iter.page.Times[iter.current], iter.page.TypedValues[iter.current]
+}
+
+// names of the typed values of a fields series, known once the first page is read
+func (iter *PageIterator) Fields() []string {
+	return iter.fields
+}
+
+// value type of the series, known once the first page is read
+func (iter *PageIterator) ValueType() types.ValueType {
+	return iter.valueType
+}
+
+// error of the last page read, check after Next returned false
+func (iter *PageIterator) Err() error {
+	return iter.err
+}
+
+// number of pages read so far
+func (iter *PageIterator) Pages() int {
+	return iter.pages
+}
+
+func (iter *PageIterator) fetch() error {
+	if err := iter.builder.IsValid(); err != nil {
+		return err
+	}
+	page, valueType, err := iter.builder.series.client.readPage(context.Background(), types.ReadSeriesRequest{
+		From:         iter.builder.from,
+		To:           iter.builder.to,
+		Fields:       iter.builder.fields,
+		Limit:        iter.pageSize,
+		Continuation: iter.continuation,
+	}, iter.builder.series)
+	if err != nil {
+		return err
+	}
+	iter.page = page
+	iter.valueType = valueType
+	iter.fields = page.Fields
+	iter.pages++
+	iter.continuation = page.Continuation
+	iter.done = page.Continuation == 0
+	return nil
+}
+
+func (client *Instance) readPage(ctx context.Context, query types.ReadSeriesRequest, series *Series) (page types.ReadPage, valueType types.ValueType, err error) {
+	// tracing
+	ctx, span := client.tracer().Start(ctx, "PageIterator.Next", trace.WithAttributes(attribute.String("tsxdb.series.name", series.Name()), attribute.Int("tsxdb.limit", query.Limit)))
+	defer func() {
+		tsxdbRpc.EndSpan(span, err)
+	}()
+
+	// get
+	conn, err := client.GetConnection()
+	if err != nil {
+		return page, valueType, errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	// series id
+	if query.Id, err = series.InitContext(ctx, conn); err != nil {
+		return page, valueType, err
+	}
+	query.Namespace = series.Namespace()
+	request := types.ReadRequest{
+		Queries: []types.ReadSeriesRequest{query},
+	}
+	tsxdbRpc.InjectTraceContext(ctx, &request.TraceContext)
+
+	// execute with retries
+	var response *types.ReadResponse
+	err = client.handleRetry(func() error {
+		// signed with a fresh nonce for every attempt, the server rejects replays
+		response = &types.ReadResponse{}
+		if err := conn.call(types.EndpointReader, &request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if *response.Error == types.RpcErrorPermissionDenied || strings.HasPrefix(response.Error.String(), types.RpcErrorQuotaExceeded.String()) {
+				// non-retryable
+				panic(response.Error.String())
+			}
+			return response.Error.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return page, valueType, err
+	}
+	page, found := response.Pages[query.Id]
+	valueType = response.ValueTypes[query.Id]
+	if !found {
+		return page, valueType, fmt.Errorf("missing page of series %s", series.Name())
+	}
+	numValues := len(page.Values)
+	if page.TypedValues != nil {
+		numValues = len(page.TypedValues)
+	}
+	if len(page.Times) != numValues {
+		return page, valueType, fmt.Errorf("mismatch between %d times and %d values", len(page.Times), numValues)
+	}
+	return page, valueType, nil
+}
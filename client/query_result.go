@@ -1,17 +1,41 @@
 package client
 
-import "sort"
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"sort"
+)
 
 type QueryResult struct {
-	Series  *Series
-	Error   error
-	Results map[uint64]float64 // in random order due to Go map implementation, if you need sorted results call QueryResult.Iterator()
+	Series       *Series
+	Error        error
+	Results      map[uint64]float64     // in random order due to Go map implementation, if you need sorted results call QueryResult.Iterator()
+	TypedResults map[uint64]types.Value // instead of results for series that are not float
+	ValueType    types.ValueType
+	Fields       []string // names of the values in the typed results of a fields series
+}
+
+// values of one field of a fields series
+func (res QueryResult) Field(name string) map[uint64]float64 {
+	idx := -1
+	for i, field := range res.Fields {
+		if field == name {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+	values := make(map[uint64]float64, len(res.TypedResults))
+	for ts, value := range res.TypedResults {
+		values[ts] = value.Fields[idx]
+	}
+	return values
 }
 
 func (res QueryResult) Iterator() *QueryResultIterator {
 	iter := &QueryResultIterator{
 		results: &res,
-		size:    len(res.Results),
+		size:    len(res.Results) + len(res.TypedResults),
 	}
 	iter.Reset()
 
@@ -22,6 +46,10 @@ func (res QueryResult) Iterator() *QueryResultIterator {
 		sortedKeys[idx] = k
 		idx++
 	}
+	for k := range iter.results.TypedResults {
+		sortedKeys[idx] = k
+		idx++
+	}
 	sort.Slice(sortedKeys, func(i, j int) bool { return sortedKeys[i] < sortedKeys[j] })
 	iter.dataKeys = sortedKeys
 
@@ -49,3 +77,9 @@ func (iter *QueryResultIterator) Value() (uint64, float64) {
 	value := iter.results.Results[timestamp]
 	return timestamp, value
 }
+
+// value of a series that is not float
+func (iter *QueryResultIterator) TypedValue() (uint64, types.Value) {
+	timestamp := iter.dataKeys[iter.current]
+	return timestamp, iter.results.TypedResults[timestamp]
+}
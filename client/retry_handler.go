@@ -2,21 +2,27 @@ package client
 
 import (
 	"fmt"
-	"log"
+	"github.com/RobinUS2/tsxdb/rpc/types"
 	"math/rand"
+	"strings"
 	"time"
 )
 
 const DefaultRpcBaseSleep = 100 * time.Millisecond
+const DefaultRateLimitedBaseSleep = 500 * time.Millisecond // server token buckets need time to refill
 
 func jitterSleep(baseSleep time.Duration, attempt int) {
 	if attempt == 0 {
 		return
 	}
-	time.Sleep((baseSleep * time.Duration(attempt*attempt) * time.Millisecond) + time.Duration(rand.Intn(50)))
+	time.Sleep((baseSleep * time.Duration(attempt*attempt)) + time.Duration(rand.Intn(50))*time.Millisecond)
 }
 
-func handleRetry(fn func() error) (err error) {
+func isRateLimited(err error) bool {
+	return err != nil && strings.Contains(err.Error(), types.RpcErrorRateLimited.String())
+}
+
+func (client *Instance) handleRetry(fn func() error) (err error) {
 	defer func() {
 		// recover from panics, way to signal stop retrying
 		// @todo proper way to signal non-retryable errors from handleRetry
@@ -27,10 +33,20 @@ func handleRetry(fn func() error) (err error) {
 
 	const maxAttempts = 5
 	for i := 0; i < maxAttempts; i++ {
-		jitterSleep(DefaultRpcBaseSleep, i)
+		if isRateLimited(err) {
+			// back off instead of hammering the server
+			jitterSleep(DefaultRateLimitedBaseSleep, i)
+		} else {
+			jitterSleep(DefaultRpcBaseSleep, i)
+		}
 		err = fn()
 		if err != nil {
-			log.Printf("failed attempt: %s", err)
+			if logger := client.errorLogSampler.Sample(client.logger(), "retry"); logger != nil {
+				logger.WithError(err).WithField("attempt", i+1).Warn("failed attempt")
+			}
+			if i < maxAttempts-1 {
+				client.metrics().ObserveRetry(i+1, err)
+			}
 			continue
 		}
 		break
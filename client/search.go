@@ -0,0 +1,85 @@
+package client
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// existing series of the namespace by exact name and/or tag, the returned series are already initialised
+func (client *Instance) SearchSeries(namespace int, name string, tag string) (results []*Series, err error) {
+	results, _, err = client.search(&types.SearchRequest{
+		Namespace: namespace,
+		Name:      name,
+		Tag:       tag,
+	})
+	return
+}
+
+// existing series of the namespace matching all label matchers, e.g. {Name: "host", Type: types.MatchRegexp, Value: "web-.*"}
+func (client *Instance) SearchSeriesByLabels(namespace int, matchers ...types.LabelMatcher) (results []*Series, err error) {
+	results, _, err = client.search(&types.SearchRequest{
+		Namespace: namespace,
+		Matchers:  matchers,
+	})
+	return
+}
+
+// a page of existing series of the namespace with a name matching the pattern (e.g. types.NameMatchGlob and "cpu.*.idle"), in name order
+// after is the next of the previous page (empty for the first), next is empty on the last page, limit 0 returns all series at once
+func (client *Instance) SearchSeriesByName(namespace int, match types.NameMatchType, pattern string, after string, limit int) (results []*Series, next string, err error) {
+	return client.search(&types.SearchRequest{
+		Namespace: namespace,
+		Name:      pattern,
+		NameMatch: match,
+		After:     after,
+		Limit:     limit,
+	})
+}
+
+func (client *Instance) search(request *types.SearchRequest) (results []*Series, next string, err error) {
+	conn, err := client.GetConnection()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	var response *types.SearchResponse
+	err = client.handleRetry(func() error {
+		response = &types.SearchResponse{}
+		if err := conn.call(types.EndpointSearch, request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if *response.Error == types.RpcErrorPermissionDenied || strings.HasPrefix(response.Error.String(), types.RpcErrorInvalidLabelMatcher.String()) || strings.HasPrefix(response.Error.String(), types.RpcErrorInvalidNameMatcher.String()) {
+				// non-retryable
+				panic(response.Error)
+			}
+			return response.Error.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	results = make([]*Series, 0, len(response.Series))
+	for _, result := range response.Series {
+		// not taken from the pool, that is keyed by name only
+		results = append(results, &Series{
+			client:    client,
+			name:      result.Name,
+			namespace: request.Namespace,
+			tags:      result.Tags,
+			labels:    result.Labels,
+			id:        result.Id,
+			initState: SuccessState,
+		})
+	}
+	return results, response.Next, nil
+}
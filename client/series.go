@@ -1,7 +1,8 @@
 package client
 
 import (
-	"log"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +15,11 @@ type Series struct {
 	id        uint64
 	ttl       uint // in seconds, ~ 50.000 days
 	name      string
+	valueType types.ValueType
+	enum      []string
+	fields    []string
+	labels    map[string]string
+	changed   bool // metadata of the existing series was changed by the last init or update
 	metaMux   sync.RWMutex
 
 	initState    InitState
@@ -61,6 +67,48 @@ func (series *Series) Tags() []string {
 	return v
 }
 
+func (series *Series) ValueType() types.ValueType {
+	series.metaMux.RLock()
+	v := series.valueType
+	series.metaMux.RUnlock()
+	return v
+}
+
+func (series *Series) Enum() []string {
+	series.metaMux.RLock()
+	v := series.enum
+	series.metaMux.RUnlock()
+	return v
+}
+
+func (series *Series) Fields() []string {
+	series.metaMux.RLock()
+	v := series.fields
+	series.metaMux.RUnlock()
+	return v
+}
+
+func (series *Series) Labels() map[string]string {
+	series.metaMux.RLock()
+	v := series.labels
+	series.metaMux.RUnlock()
+	return v
+}
+
+// true if the last init or update changed the tags, ttl or name of the existing series on the server
+func (series *Series) MetadataChanged() bool {
+	series.metaMux.RLock()
+	v := series.changed
+	series.metaMux.RUnlock()
+	return v
+}
+
+func (series *Series) setMetadataChanged(changed bool) {
+	series.metaMux.Lock()
+	series.changed = changed
+	series.metaMux.Unlock()
+}
+
 func (series *Series) InitState() InitState {
 	series.initStateMux.RLock()
 	state := series.initState
@@ -107,7 +155,7 @@ func (client *Instance) EagerInitSeries(series *Series) {
 		go func() {
 			defer func() {
 				if r := recover(); r != nil {
-					log.Printf("error initing series %v", r)
+					client.logger().WithField(tools.LogFieldSeries, series.Name()).Errorf("error initing series %v", r)
 					series.SetInitState(PanicState)
 				}
 			}()
@@ -116,7 +164,9 @@ func (client *Instance) EagerInitSeries(series *Series) {
 			}
 			_, err := series.Create()
 			if err != nil {
-				log.Printf("error eager init for %s %s", series.Name(), err)
+				if logger := client.errorLogSampler.Sample(client.logger(), "eager init"); logger != nil {
+					logger.WithField(tools.LogFieldSeries, series.Name()).WithError(err).Error("error eager init")
+				}
 				series.SetInitState(ErrorState)
 			} else {
 				series.SetInitState(SuccessState)
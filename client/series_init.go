@@ -1,10 +1,14 @@
 package client
 
 import (
+	"context"
+	tsxdbRpc "github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/tools"
 	"github.com/pkg/errors"
-	"log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
 	"sync/atomic"
 )
 
@@ -34,8 +38,19 @@ func (series *Series) ResetInit() {
 }
 
 func (series *Series) Init(conn *ManagedConnection) (id uint64, err error) {
+	return series.InitContext(context.Background(), conn)
+}
+
+// init within the trace of ctx (if any), only calls to the server are traced
+func (series *Series) InitContext(ctx context.Context, conn *ManagedConnection) (id uint64, err error) {
 	// Note: this function does not close the connection, need to do in function that uses it
 
+	// instrumentation
+	state := SeriesInitFailed
+	defer func() {
+		series.client.metrics().ObserveSeriesInit(state)
+	}()
+
 	if series.Name() == "" {
 		return 0, errors.New("series name must be provided")
 	}
@@ -44,6 +59,7 @@ func (series *Series) Init(conn *ManagedConnection) (id uint64, err error) {
 	id = series.Id()
 	if id > 0 {
 		// already initialised
+		state = SeriesInitCached
 		return id, nil
 	}
 
@@ -52,6 +68,12 @@ func (series *Series) Init(conn *ManagedConnection) (id uint64, err error) {
 		return 0, errors.New("missing connection")
 	}
 
+	// tracing
+	ctx, span := series.client.tracer().Start(ctx, "Series.Init", trace.WithAttributes(attribute.String("tsxdb.series.name", series.Name()), attribute.Int("tsxdb.namespace", series.Namespace())))
+	defer func() {
+		tsxdbRpc.EndSpan(span, err)
+	}()
+
 	// max 1 init at a time for 1 series
 	series.initMux.Lock()
 	defer series.initMux.Unlock()
@@ -60,12 +82,13 @@ func (series *Series) Init(conn *ManagedConnection) (id uint64, err error) {
 	id = series.Id()
 	if id > 0 {
 		// already initialised
+		state = SeriesInitCached
 		return id, nil
 	}
 
 	// request with retries
 	var response *types.SeriesMetadataResponse
-	err = handleRetry(func() error {
+	err = series.client.handleRetry(func() error {
 		request := types.SeriesMetadataRequest{
 			SeriesCreateMetadata: types.SeriesCreateMetadata{
 				SeriesMetadata: types.SeriesMetadata{
@@ -73,27 +96,33 @@ func (series *Series) Init(conn *ManagedConnection) (id uint64, err error) {
 					Tags:      series.Tags(),
 					Name:      series.Name(),
 					Ttl:       series.TTL(),
+					ValueType: series.ValueType(),
+					Enum:      series.Enum(),
+					Fields:    series.Fields(),
+					Labels:    series.Labels(),
 				},
 				SeriesCreateIdentifier: types.SeriesCreateIdentifier(tools.RandomInsecureIdentifier()),
 			},
-			SessionTicket: conn.getSessionTicket(),
 		}
 
+		tsxdbRpc.InjectTraceContext(ctx, &request.TraceContext)
+
 		// execute
-		if err = conn.client.Call(types.EndpointSeriesMetadata.String()+"."+types.MethodName, request, &response); err != nil {
+		response = &types.SeriesMetadataResponse{}
+		if err = conn.call(types.EndpointSeriesMetadata, &request, response); err != nil {
 			return err
 		}
-		if response.Id < 1 {
-			// validate we really have an ID
-			return types.RpcErrorSeriesInitNoId.Error()
-		}
 		if response.Error != nil {
-			if *response.Error == types.RpcErrorSeriesNameEmpty || *response.Error == types.RpcErrorSeriesNameWhitespace {
+			if *response.Error == types.RpcErrorSeriesNameEmpty || *response.Error == types.RpcErrorSeriesNameWhitespace || *response.Error == types.RpcErrorPermissionDenied || *response.Error == types.RpcErrorInvalidValueType || strings.HasPrefix(response.Error.String(), types.RpcErrorQuotaExceeded.String()) || strings.HasPrefix(response.Error.String(), types.RpcErrorValueTypeMismatch.String()) || strings.HasPrefix(response.Error.String(), types.RpcErrorInvalidLabel.String()) || strings.HasPrefix(response.Error.String(), types.RpcErrorCardinalityExceeded.String()) {
 				// non-retryable
 				panic(response.Error)
 			}
 			return response.Error.Error()
 		}
+		if response.Id < 1 {
+			// validate we really have an ID
+			return types.RpcErrorSeriesInitNoId.Error()
+		}
 		return nil
 	})
 
@@ -102,11 +131,19 @@ func (series *Series) Init(conn *ManagedConnection) (id uint64, err error) {
 	}
 
 	if response.Id == 0 {
-		log.Printf("logging response from series init: %+v", response)
+		series.client.logger().WithField(tools.LogFieldSeries, series.Name()).Warnf("logging response from series init: %+v", response)
 	}
 
 	// store id
 	atomic.StoreUint64(&series.id, response.Id)
+	series.setMetadataChanged(response.Changed)
+	if response.New {
+		state = SeriesInitCreated
+	} else if response.Changed {
+		state = SeriesInitUpdated
+	} else {
+		state = SeriesInitExisting
+	}
 
 	return response.Id, nil
 }
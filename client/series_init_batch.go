@@ -0,0 +1,114 @@
+package client
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/pkg/errors"
+	"sync/atomic"
+)
+
+// init the metadata of many series in one round trip per types.MaxSeriesMetadataBatchSize series, instead of one per series
+// series that are already initialised are skipped, the first series that failed is returned as error
+func (client *Instance) CreateSeries(series []*Series) error {
+	pending := make([]*Series, 0, len(series))
+	seen := make(map[*Series]bool, len(series))
+	for _, s := range series {
+		if s.Id() > 0 || seen[s] {
+			continue
+		}
+		if s.Name() == "" {
+			return errors.New("series name must be provided")
+		}
+		seen[s] = true
+		pending = append(pending, s)
+	}
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > types.MaxSeriesMetadataBatchSize {
+			n = types.MaxSeriesMetadataBatchSize
+		}
+		if err := client.createSeriesBatch(pending[:n]); err != nil {
+			return err
+		}
+		pending = pending[n:]
+	}
+	return nil
+}
+
+func (client *Instance) createSeriesBatch(series []*Series) error {
+	conn, err := client.GetConnection()
+	if err != nil {
+		return errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		// errors of single series do not affect the connection
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	// request with retries
+	var response *types.SeriesMetadataBatchResponse
+	err = client.handleRetry(func() error {
+		request := types.SeriesMetadataBatchRequest{
+			Series: make([]types.SeriesCreateMetadata, 0, len(series)),
+		}
+		for _, s := range series {
+			request.Series = append(request.Series, types.SeriesCreateMetadata{
+				SeriesMetadata: types.SeriesMetadata{
+					Namespace: s.Namespace(),
+					Tags:      s.Tags(),
+					Name:      s.Name(),
+					Ttl:       s.TTL(),
+					ValueType: s.ValueType(),
+					Enum:      s.Enum(),
+					Fields:    s.Fields(),
+					Labels:    s.Labels(),
+				},
+				SeriesCreateIdentifier: types.SeriesCreateIdentifier(tools.RandomInsecureIdentifier()),
+			})
+		}
+
+		// execute
+		response = &types.SeriesMetadataBatchResponse{}
+		if err := conn.call(types.EndpointSeriesMetadataBatch, &request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if *response.Error == types.RpcErrorPermissionDenied {
+				// non-retryable
+				panic(response.Error)
+			}
+			return response.Error.Error()
+		}
+		if len(response.Series) != len(series) {
+			return fmt.Errorf("expected %d series was %d", len(series), len(response.Series))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// store ids, errors of single series are not retried (e.g. invalid names or quota)
+	var seriesErr error
+	for i, s := range series {
+		result := response.Series[i]
+		if result.Error == nil && result.Id < 1 {
+			result.Error = &types.RpcErrorSeriesInitNoId
+		}
+		if result.Error != nil {
+			s.SetInitState(ErrorState)
+			if seriesErr == nil {
+				seriesErr = fmt.Errorf("series %s: %s", s.Name(), result.Error)
+			}
+			continue
+		}
+		atomic.StoreUint64(&s.id, result.Id)
+		s.setMetadataChanged(result.Changed)
+		s.SetInitState(SuccessState)
+	}
+	return seriesErr
+}
@@ -0,0 +1,20 @@
+package client
+
+type SeriesLabels struct {
+	labels map[string]string
+}
+
+func (opt SeriesLabels) Apply(series *Series) error {
+	if series.labels == nil {
+		series.labels = make(map[string]string, len(opt.labels))
+	}
+	for name, value := range opt.labels {
+		series.labels[name] = value
+	}
+	return nil
+}
+
+// key=value labels, e.g. {"host": "abc"}, selected with label matchers in Instance.SearchSeriesByLabels
+func NewSeriesLabels(labels map[string]string) *SeriesLabels {
+	return &SeriesLabels{labels: labels}
+}
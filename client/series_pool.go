@@ -49,6 +49,14 @@ func (pool *SeriesPool) Set(name string, value *Series) {
 	pool.lru.Set(poolPrefix+name, value, DefaultPoolTtl)
 }
 
+// renamed series are reused under their new name
+func (pool *SeriesPool) rename(oldName string, newName string, value *Series) {
+	if pool.Get(oldName) == value {
+		pool.lru.Delete(poolPrefix + oldName)
+	}
+	pool.Set(newName, value)
+}
+
 func NewSeriesPool(clientOpts *Opts) *SeriesPool {
 	return &SeriesPool{
 		lru: ccache.New(ccache.Configure().MaxSize(clientOpts.SeriesCacheSize)),
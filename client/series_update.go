@@ -0,0 +1,85 @@
+package client
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// changes of the metadata of an existing series
+type SeriesUpdate struct {
+	AddTags      []string
+	RemoveTags   []string
+	SetTtl       bool              // change the ttl to Ttl, 0 removes the ttl
+	Ttl          uint              // in seconds, the series expires this long after the update
+	Rename       string            // new name, empty to keep the name
+	SetLabels    map[string]string // added or changed labels
+	RemoveLabels []string
+}
+
+// updates the metadata on the server (the series is created first if needed), changed is false if it already had this metadata
+func (series *Series) Update(update SeriesUpdate) (changed bool, err error) {
+	id, err := series.Create()
+	if err != nil {
+		return false, err
+	}
+	conn, err := series.client.GetConnection()
+	if err != nil {
+		return false, errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	var response *types.SeriesUpdateResponse
+	err = series.client.handleRetry(func() error {
+		request := &types.SeriesUpdateRequest{
+			Namespace:    series.Namespace(),
+			Id:           id,
+			AddTags:      update.AddTags,
+			RemoveTags:   update.RemoveTags,
+			SetTtl:       update.SetTtl,
+			Ttl:          update.Ttl,
+			Rename:       update.Rename,
+			SetLabels:    update.SetLabels,
+			RemoveLabels: update.RemoveLabels,
+		}
+		response = &types.SeriesUpdateResponse{}
+		if err := conn.call(types.EndpointSeriesUpdate, request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			switch *response.Error {
+			case types.RpcErrorPermissionDenied, types.RpcErrorSeriesNameExists, types.RpcErrorSeriesNameWhitespace, types.RpcErrorSeriesNotFound:
+				// non-retryable
+				panic(response.Error)
+			}
+			if strings.HasPrefix(response.Error.String(), types.RpcErrorInvalidLabel.String()) || strings.HasPrefix(response.Error.String(), types.RpcErrorCardinalityExceeded.String()) {
+				// non-retryable
+				panic(response.Error)
+			}
+			return response.Error.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// local metadata
+	series.metaMux.Lock()
+	oldName := series.name
+	series.name = response.Name
+	series.tags = response.Tags
+	series.labels = response.Labels
+	series.ttl = response.Ttl
+	series.changed = response.Changed
+	series.metaMux.Unlock()
+	if oldName != response.Name {
+		series.client.seriesPool.rename(oldName, response.Name, series)
+	}
+	return response.Changed, nil
+}
@@ -0,0 +1,32 @@
+package client
+
+import "github.com/RobinUS2/tsxdb/rpc/types"
+
+type SeriesValueType struct {
+	valueType types.ValueType
+	enum      []string
+	fields    []string
+}
+
+func (opt SeriesValueType) Apply(series *Series) error {
+	series.valueType = opt.valueType
+	series.enum = opt.enum
+	series.fields = opt.fields
+	return nil
+}
+
+// type of the values of the series, written with Series.WriteValue instead of Series.Write
+// e.g. types.ValueTypeInt for counters that do not fit in a float64
+func NewSeriesValueType(valueType types.ValueType) *SeriesValueType {
+	return &SeriesValueType{valueType: valueType}
+}
+
+// string series that only allows these values
+func NewSeriesEnum(values ...string) *SeriesValueType {
+	return &SeriesValueType{valueType: types.ValueTypeString, enum: values}
+}
+
+// series with multiple named float values per timestamp, written with Series.WriteFields
+func NewSeriesFields(fields ...string) *SeriesValueType {
+	return &SeriesValueType{valueType: types.ValueTypeFields, fields: fields}
+}
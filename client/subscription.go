@@ -0,0 +1,197 @@
+package client
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+	"sync"
+	"sync/atomic"
+)
+
+const subscriptionPollWait = 5000    // milliseconds the server waits for new points
+const subscriptionChannelSize = 1000 // points, once full the server buffers until its buffer is full as well
+
+// series to subscribe to, any combination of ids, exact name and tag
+type SubscriptionFilter struct {
+	Ids  []uint64
+	Name string // series created later with this name are included
+	Tag  string // series created later with this tag are included
+}
+
+// points written after subscribing, delivered in order per series
+type Subscription struct {
+	client  *Instance
+	id      uint64
+	points  chan types.SubscriptionPoint
+	done    chan struct{}
+	doneMux sync.Mutex
+	closed  bool
+	dropped uint64
+	err     error
+	errMux  sync.RWMutex
+}
+
+// closed once the subscription ended, check Err() afterwards
+func (sub *Subscription) Points() <-chan types.SubscriptionPoint {
+	return sub.points
+}
+
+// points the server dropped since this client did not keep up
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// reason the subscription ended, nil if closed by the client
+func (sub *Subscription) Err() error {
+	sub.errMux.RLock()
+	err := sub.err
+	sub.errMux.RUnlock()
+	return err
+}
+
+func (sub *Subscription) isDone() bool {
+	select {
+	case <-sub.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sub *Subscription) run() {
+	defer close(sub.points)
+	for {
+		response, err := sub.poll()
+		if sub.isDone() {
+			return
+		}
+		if err != nil {
+			sub.errMux.Lock()
+			sub.err = err
+			sub.errMux.Unlock()
+			return
+		}
+		atomic.AddUint64(&sub.dropped, response.Dropped)
+		for _, point := range response.Points {
+			select {
+			case sub.points <- point:
+			case <-sub.done:
+				return
+			}
+		}
+	}
+}
+
+func (sub *Subscription) poll() (response *types.SubscriptionPollResponse, err error) {
+	conn, err := sub.client.GetConnection()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+	err = sub.client.handleRetry(func() error {
+		request := &types.SubscriptionPollRequest{
+			Subscription: sub.id,
+			MaxWait:      subscriptionPollWait,
+		}
+		response = &types.SubscriptionPollResponse{}
+		if err := conn.call(types.EndpointSubscriptionPoll, request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if *response.Error == types.RpcErrorSubscriptionNotFound || *response.Error == types.RpcErrorSubscriptionSlowConsumer {
+				// non-retryable
+				panic(response.Error)
+			}
+			return response.Error.Error()
+		}
+		return nil
+	})
+	return response, err
+}
+
+// stop receiving points, the points channel is closed
+func (sub *Subscription) Close() error {
+	sub.doneMux.Lock()
+	if sub.closed {
+		sub.doneMux.Unlock()
+		return nil
+	}
+	sub.closed = true
+	close(sub.done)
+	sub.doneMux.Unlock()
+
+	conn, err := sub.client.GetConnection()
+	if err != nil {
+		return errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+	response := &types.UnsubscribeResponse{}
+	if err = conn.call(types.EndpointUnsubscribe, &types.UnsubscribeRequest{Subscription: sub.id}, response); err != nil {
+		return err
+	}
+	if response.Error != nil && *response.Error != types.RpcErrorSubscriptionNotFound {
+		// not found if the subscription already ended
+		return response.Error.Error()
+	}
+	return nil
+}
+
+// receive points written to the series of the namespace from now on
+func (client *Instance) Subscribe(namespace int, filter SubscriptionFilter) (sub *Subscription, err error) {
+	if len(filter.Ids) < 1 && len(filter.Name) < 1 && len(filter.Tag) < 1 {
+		return nil, errors.New("missing series ids, name or tag")
+	}
+	conn, err := client.GetConnection()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed get connection")
+	}
+	defer func() {
+		if err != nil && conn != nil {
+			conn.Discard()
+		}
+		panicOnErrorClose(conn.Close)
+	}()
+
+	var response *types.SubscribeResponse
+	err = client.handleRetry(func() error {
+		request := &types.SubscribeRequest{
+			Namespace: namespace,
+			Ids:       filter.Ids,
+			Name:      filter.Name,
+			Tag:       filter.Tag,
+		}
+		response = &types.SubscribeResponse{}
+		if err := conn.call(types.EndpointSubscribe, request, response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			if *response.Error != types.RpcErrorRateLimited {
+				// non-retryable, e.g. permissions or unknown series ids
+				panic(response.Error)
+			}
+			return response.Error.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sub = &Subscription{
+		client: client,
+		id:     response.Subscription,
+		points: make(chan types.SubscriptionPoint, subscriptionChannelSize),
+		done:   make(chan struct{}),
+	}
+	go sub.run()
+	return sub, nil
+}
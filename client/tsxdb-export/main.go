@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/client/export"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/tools"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+var configPathsStr string
+var listenHost string
+var listenPort int
+var user string
+var authToken string
+var namespace int
+var name string
+var tag string
+var fromStr string
+var toStr string
+var format string
+var outPath string
+var pageSize int
+
+func init() {
+	flag.StringVar(&configPathsStr, "config", "", "Connection configuration file (path(s)), optional")
+	flag.StringVar(&listenHost, "host", "", "Server host (overrides config)")
+	flag.IntVar(&listenPort, "port", 0, "Server port (overrides config)")
+	flag.StringVar(&user, "user", "", "User with read rights on the namespace, empty for the shared auth token")
+	flag.StringVar(&authToken, "token", os.Getenv("TSXDB_AUTH_TOKEN"), "Auth token (defaults to $TSXDB_AUTH_TOKEN)")
+	flag.IntVar(&namespace, "namespace", 0, "Namespace of the series")
+	flag.StringVar(&name, "name", "", "Series with exactly this name")
+	flag.StringVar(&tag, "tag", "", "Series with this tag")
+	flag.StringVar(&fromStr, "from", "", "Start of the time range, RFC3339 or unix milliseconds (defaults to 24 hours ago)")
+	flag.StringVar(&toStr, "to", "", "End of the time range, RFC3339 or unix milliseconds (defaults to now)")
+	flag.StringVar(&format, "format", string(export.FormatCsv), "Output format: csv, ndjson or parquet")
+	flag.StringVar(&outPath, "out", "", "Output file (defaults to stdout)")
+	flag.IntVar(&pageSize, "page", client.DefaultPageSize, "Points read per request")
+	flag.Parse()
+}
+
+// RFC3339 or unix milliseconds
+func parseTime(value string, defaultValue time.Time) (uint64, error) {
+	if len(value) < 1 {
+		return uint64(defaultValue.UnixNano() / int64(time.Millisecond)), nil
+	}
+	if ms, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %s", value)
+	}
+	return uint64(t.UnixNano() / int64(time.Millisecond)), nil
+}
+
+func main() {
+	if len(name) < 1 && len(tag) < 1 {
+		log.Fatal("missing -name or -tag")
+	}
+	now := time.Now()
+	from, err := parseTime(fromStr, now.Add(-24*time.Hour))
+	if err != nil {
+		log.Fatal(err)
+	}
+	to, err := parseTime(toStr, now)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// connection
+	opts := client.NewOpts()
+	if len(configPathsStr) > 0 {
+		connectionOpts := rpc.NewOptsConnection()
+		if err := tools.ReadYamlFileInPath(configPathsStr, &connectionOpts); err != nil {
+			log.Fatalf("failed to read config %s", err)
+		}
+		opts.OptsConnection = connectionOpts
+	}
+	if len(listenHost) > 0 {
+		opts.ListenHost = listenHost
+	}
+	if listenPort > 0 {
+		opts.ListenPort = listenPort
+	}
+	if len(user) > 0 {
+		opts.User = user
+	}
+	if len(authToken) > 0 {
+		opts.AuthToken = authToken
+	}
+	opts.EagerInitSeries = false
+	c := client.New(opts)
+	defer c.Close()
+
+	exporter, err := export.New(c, export.Opts{
+		Format:   export.Format(format),
+		From:     from,
+		To:       to,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	series, err := c.SearchSeries(namespace, name, tag)
+	if err != nil {
+		log.Fatalf("failed to search series %s", err)
+	}
+	if len(series) < 1 {
+		log.Fatal("no series found")
+	}
+
+	var out io.Writer = os.Stdout
+	if len(outPath) > 0 {
+		file, err := os.Create(outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+		out = file
+	}
+	result, err := exporter.Export(out, series)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("exported %d points of %d series", result.Points, result.Series)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/client/export"
+	"github.com/RobinUS2/tsxdb/client/importer"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/tools"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var configPathsStr string
+var listenHost string
+var listenPort int
+var user string
+var authToken string
+var namespace int
+var format string
+var batchSize int
+var parallelism int
+var offset uint64
+var dryRun bool
+
+func init() {
+	flag.StringVar(&configPathsStr, "config", "", "Connection configuration file (path(s)), optional")
+	flag.StringVar(&listenHost, "host", "", "Server host (overrides config)")
+	flag.IntVar(&listenPort, "port", 0, "Server port (overrides config)")
+	flag.StringVar(&user, "user", "", "User with write rights on the namespaces, empty for the shared auth token")
+	flag.StringVar(&authToken, "token", os.Getenv("TSXDB_AUTH_TOKEN"), "Auth token (defaults to $TSXDB_AUTH_TOKEN)")
+	flag.IntVar(&namespace, "namespace", 0, "Namespace of rows without a namespace")
+	flag.StringVar(&format, "format", "", "Input format: csv or ndjson (defaults to the file extension)")
+	flag.IntVar(&batchSize, "batch", importer.DefaultBatchSize, "Points per write and series per metadata request")
+	flag.IntVar(&parallelism, "parallel", 1, "Concurrent writers")
+	flag.Uint64Var(&offset, "offset", 0, "Rows to skip, e.g. the offset reported by a failed import")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only validate the rows, nothing is written")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "usage: tsxdb-import [flags] [file], reads stdin without file\n\nflags:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+func main() {
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// input
+	var in io.Reader = os.Stdin
+	if flag.NArg() == 1 {
+		file, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		in = file
+		if len(format) < 1 {
+			format = strings.TrimPrefix(filepath.Ext(file.Name()), ".")
+		}
+	}
+	if format == "json" || format == "jsonl" {
+		format = string(export.FormatNdjson)
+	}
+
+	// connection, not needed to validate
+	var c *client.Instance
+	if !dryRun {
+		opts := client.NewOpts()
+		if len(configPathsStr) > 0 {
+			connectionOpts := rpc.NewOptsConnection()
+			if err := tools.ReadYamlFileInPath(configPathsStr, &connectionOpts); err != nil {
+				log.Fatalf("failed to read config %s", err)
+			}
+			opts.OptsConnection = connectionOpts
+		}
+		if len(listenHost) > 0 {
+			opts.ListenHost = listenHost
+		}
+		if listenPort > 0 {
+			opts.ListenPort = listenPort
+		}
+		if len(user) > 0 {
+			opts.User = user
+		}
+		if len(authToken) > 0 {
+			opts.AuthToken = authToken
+		}
+		opts.EagerInitSeries = false
+		c = client.New(opts)
+		defer c.Close()
+	}
+
+	imp, err := importer.New(c, importer.Opts{
+		Format:      export.Format(format),
+		Namespace:   namespace,
+		BatchSize:   batchSize,
+		Parallelism: parallelism,
+		Offset:      offset,
+		DryRun:      dryRun,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := imp.Import(in)
+	if err != nil {
+		if dryRun {
+			log.Fatalf("invalid input: %s", err)
+		}
+		log.Fatalf("import failed: %s, resume with -offset %d", err, result.Offset)
+	}
+	if dryRun {
+		log.Printf("validated %d points of %d series", result.Points, result.Series)
+		return
+	}
+	log.Printf("imported %d points of %d series", result.Points, result.Series)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"errors"
+	"github.com/RobinUS2/tsxdb/rpc/types"
 )
 
 var errClientValidationMismatchSent = errors.New("mismatch between expected written values and received")
@@ -16,6 +17,26 @@ func (series *Series) Write(ts uint64, v float64) (res WriteResult) {
 	return b.Execute()
 }
 
+// write a value of a series that is not float, e.g. types.IntValue(42)
+func (series *Series) WriteValue(ts uint64, v types.Value) (res WriteResult) {
+	b := series.client.NewBatchWriter()
+	if err := b.AddValueToBatch(series, ts, v); err != nil {
+		res.Error = err
+		return
+	}
+	return b.Execute()
+}
+
+// write all fields of a fields series at once, see NewSeriesFields
+func (series *Series) WriteFields(ts uint64, fields map[string]float64) (res WriteResult) {
+	b := series.client.NewBatchWriter()
+	if err := b.AddFieldsToBatch(series, ts, fields); err != nil {
+		res.Error = err
+		return
+	}
+	return b.Execute()
+}
+
 type WriteResult struct {
 	Error        error
 	NumPersisted int
@@ -1,10 +1,13 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	tsxdbRpc "github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/pkg/errors"
-	"log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // not concurrent, make sure to lock yourself or use one per go routine
@@ -19,13 +22,18 @@ func (batch *BatchWriter) Size() int {
 }
 
 func (batch *BatchWriter) ToWriteRequest(conn *ManagedConnection) (request types.WriteRequest, err error) {
+	return batch.toWriteRequest(context.Background(), conn)
+}
+
+func (batch *BatchWriter) toWriteRequest(ctx context.Context, conn *ManagedConnection) (request types.WriteRequest, err error) {
 	// Note: this function does not close the connection, need to do in function that uses it
 	seriesTimestamps := make(map[uint64][]uint64) // key of outer slice is the series id
 	seriesValues := make(map[uint64][]float64)    // key of outer slice is the series id
+	seriesTyped := make(map[uint64][]types.Value) // key of outer slice is the series id
 	seriesNamespace := make(map[uint64]int)       // key of outer slice is the series id
 	for _, item := range batch.items {
 		var seriesId uint64
-		if seriesId, err = item.series.Init(conn); err != nil {
+		if seriesId, err = item.series.InitContext(ctx, conn); err != nil {
 			return request, fmt.Errorf("error during series init (1) %s: %s", item.series.name, err)
 		}
 		if seriesId == 0 {
@@ -36,21 +44,28 @@ func (batch *BatchWriter) ToWriteRequest(conn *ManagedConnection) (request types
 			seriesValues[seriesId] = make([]float64, 0)
 		}
 		seriesTimestamps[seriesId] = append(seriesTimestamps[seriesId], item.ts)
-		seriesValues[seriesId] = append(seriesValues[seriesId], item.v)
+		if item.typed != nil {
+			seriesTyped[seriesId] = append(seriesTyped[seriesId], *item.typed)
+		} else {
+			seriesValues[seriesId] = append(seriesValues[seriesId], item.v)
+		}
+		if len(seriesTyped[seriesId]) > 0 && len(seriesValues[seriesId]) > 0 {
+			return request, fmt.Errorf("series %s: float and typed values in one batch", item.series.name)
+		}
 		seriesNamespace[seriesId] = item.series.Namespace()
 	}
 
 	// request (batch)
 	request = types.WriteRequest{
-		Series:        []types.WriteSeriesRequest{},
-		SessionTicket: conn.getSessionTicket(),
+		Series: []types.WriteSeriesRequest{},
 	}
 
 	// assemble request
 	for seriesId, timestamps := range seriesTimestamps {
 		request.Series = append(request.Series, types.WriteSeriesRequest{
-			Times:  timestamps,
-			Values: seriesValues[seriesId],
+			Times:       timestamps,
+			Values:      seriesValues[seriesId],
+			TypedValues: seriesTyped[seriesId],
 			SeriesIdentifier: types.SeriesIdentifier{
 				Id:        seriesId,
 				Namespace: seriesNamespace[seriesId],
@@ -61,6 +76,19 @@ func (batch *BatchWriter) ToWriteRequest(conn *ManagedConnection) (request types
 }
 
 func (batch *BatchWriter) Execute() (res WriteResult) {
+	return batch.ExecuteContext(context.Background())
+}
+
+// execute within the trace of ctx (if any)
+func (batch *BatchWriter) ExecuteContext(ctx context.Context) (res WriteResult) {
+	batch.client.metrics().ObserveBatchSize(len(batch.items))
+
+	// tracing
+	ctx, span := batch.client.tracer().Start(ctx, "BatchWriter.Execute", trace.WithAttributes(attribute.Int("tsxdb.items", len(batch.items))))
+	defer func() {
+		tsxdbRpc.EndSpan(span, res.Error)
+	}()
+
 	// get
 	conn, err := batch.client.GetConnection()
 	if err != nil {
@@ -75,18 +103,24 @@ func (batch *BatchWriter) Execute() (res WriteResult) {
 	}()
 
 	// to request
-	request, err := batch.ToWriteRequest(conn)
+	request, err := batch.toWriteRequest(ctx, conn)
 	if err != nil {
 		res.Error = errors.Wrap(err, "failed ToWriteRequest")
 		return
 	}
+	tsxdbRpc.InjectTraceContext(ctx, &request.TraceContext)
 
 	// execute
 	var response *types.WriteResponse
-	err = handleRetry(func() error {
-		if err := conn.client.Call(types.EndpointWriter.String()+"."+types.MethodName, request, &response); err != nil {
+	err = batch.client.handleRetry(func() error {
+		// signed with a fresh nonce for every attempt, the server rejects replays
+		response = &types.WriteResponse{}
+		if err := conn.call(types.EndpointWriter, &request, response); err != nil {
 			return err
 		}
+		if response.Error != nil && *response.Error == types.RpcErrorRateLimited {
+			return response.Error.Error()
+		}
 		return nil
 	})
 	if err != nil {
@@ -96,14 +130,14 @@ func (batch *BatchWriter) Execute() (res WriteResult) {
 
 	if response.Error != nil {
 		if *response.Error == types.RpcErrorBackendMetadataNotFound {
-			log.Printf("re-transmitting metadata to backend (did server restart?)")
+			batch.client.logger().Info("re-transmitting metadata to backend (did server restart?)")
 			// metadata not found, re-init so that clients send metadata again to server
 			for _, item := range batch.items {
 				item.series.ResetInit()
-				_, _ = item.series.Init(conn)
+				_, _ = item.series.InitContext(ctx, conn)
 			}
 			// re-execute
-			return batch.Execute()
+			return batch.ExecuteContext(ctx)
 		}
 		res.Error = errors.Wrap(response.Error.Error(), "generic response error")
 		return
@@ -121,6 +155,9 @@ func (batch *BatchWriter) Execute() (res WriteResult) {
 }
 
 func (batch *BatchWriter) AddToBatch(series *Series, ts uint64, v float64) error {
+	if valueType := series.ValueType(); valueType.Typed() {
+		return fmt.Errorf("series %s: float value for a %s series, use AddValueToBatch", series.Name(), valueType)
+	}
 	if batch.items == nil {
 		batch.items = make([]BatchItem, 0)
 	}
@@ -132,10 +169,57 @@ func (batch *BatchWriter) AddToBatch(series *Series, ts uint64, v float64) error
 	return nil
 }
 
+// value of a series that is not float, see NewSeriesValueType
+func (batch *BatchWriter) AddValueToBatch(series *Series, ts uint64, v types.Value) error {
+	if !series.ValueType().Typed() {
+		return fmt.Errorf("series %s: typed value for a float series, set the value type with NewSeriesValueType", series.Name())
+	}
+	if err := series.ValueType().Validate(v, series.Enum(), series.Fields()); err != nil {
+		return err
+	}
+	batch.items = append(batch.items, BatchItem{
+		series: series,
+		ts:     ts,
+		typed:  &v,
+	})
+	return nil
+}
+
+// values of all fields of a fields series
+func (batch *BatchWriter) AddFieldsToBatch(series *Series, ts uint64, fields map[string]float64) error {
+	names := series.Fields()
+	values := make([]float64, len(names))
+	for i, name := range names {
+		value, found := fields[name]
+		if !found {
+			return fmt.Errorf("series %s: missing field %s", series.Name(), name)
+		}
+		values[i] = value
+	}
+	if len(fields) != len(names) {
+		for name := range fields {
+			if !hasField(names, name) {
+				return fmt.Errorf("series %s: unknown field %s", series.Name(), name)
+			}
+		}
+	}
+	return batch.AddValueToBatch(series, ts, types.FieldsValue(values...))
+}
+
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
 type BatchItem struct {
 	series *Series
 	ts     uint64
 	v      float64
+	typed  *types.Value // instead of v for series that are not float
 }
 
 func (client *Instance) NewBatchWriter() *BatchWriter {
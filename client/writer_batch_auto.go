@@ -92,7 +92,9 @@ func (instance *AutoBatchWriter) Flush() error {
 
 	// flush function
 	executeFn := func() error {
+		start := time.Now()
 		res := batchCopy.Execute()
+		instance.client.metrics().ObserveFlush(time.Since(start), res.Error)
 		if res.Error != nil {
 			return res.Error
 		}
@@ -0,0 +1,153 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"strings"
+	"testing"
+)
+
+func TestAdmin(t *testing.T) {
+	for backendName, newServer := range map[string]func(init bool, listen bool) *server.Instance{
+		"memory": NewTestServer,
+		"redis":  NewTestServerRedis,
+	} {
+		t.Run(backendName, func(t *testing.T) {
+			s := newServer(false, false)
+			s.Opts().Users = []server.UserOpts{
+				{Name: "teamA", AuthToken: "tokenA", Permissions: []server.PermissionOpts{{Namespaces: []int{1}, Rights: []string{"admin"}}}},
+			}
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.StartListening(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = s.Shutdown()
+			}()
+			opts := client.NewOpts()
+			opts.ListenPort = s.Opts().ListenPort
+			opts.AuthToken = s.Opts().AuthToken
+			opts.EagerInitSeries = false
+			c := client.New(opts)
+			defer c.Close()
+
+			// data
+			series := c.Series("adminSeries", client.NewSeriesNamespace(1))
+			now := c.Now()
+			for i := uint64(0); i < 3; i++ {
+				if res := series.Write(now+i, float64(i)); res.Error != nil {
+					t.Fatal(res.Error)
+				}
+			}
+			id := series.Id()
+			if id < 1 {
+				t.Fatal("series not initialised")
+			}
+
+			// namespaces
+			res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandNamespaces})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Namespaces) != 1 || res.Namespaces[0] != 1 {
+				t.Errorf("unexpected namespaces %v", res.Namespaces)
+			}
+
+			// search
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1, Name: "admin"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Series) != 1 || res.Series[0].Id != id || res.Series[0].Name != "adminSeries" {
+				t.Errorf("unexpected series %+v", res.Series)
+			}
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1, Name: "other"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Series) != 0 {
+				t.Errorf("expected no series %+v", res.Series)
+			}
+
+			// describe
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandDescribe, Namespace: 1, Series: id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Series) != 1 || res.Series[0].Points != 3 || res.Series[0].From != now || res.Series[0].To != now+2 {
+				t.Errorf("unexpected describe %+v", res.Series)
+			}
+
+			// stats, sessions and connections
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandStats})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Stats["values_written"] != 3 {
+				t.Errorf("unexpected stats %v", res.Stats)
+			}
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandConnections})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Connections) < 1 {
+				t.Error("expected connections")
+			}
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandSessions})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Sessions) < 1 {
+				t.Fatal("expected sessions")
+			}
+
+			// kicked sessions authenticate again
+			authentications := s.Statistics().NumAuthentications()
+			for _, session := range res.Sessions {
+				kickRes, err := c.Admin(types.AdminRequest{Command: types.AdminCommandKick, Session: session.Id})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if kickRes.Affected != 1 {
+					t.Errorf("expected session %d kicked", session.Id)
+				}
+			}
+			if _, err := c.Admin(types.AdminRequest{Command: types.AdminCommandFlush}); err != nil {
+				t.Fatal(err)
+			}
+			if s.Statistics().NumAuthentications() <= authentications {
+				t.Error("expected authentication after kick")
+			}
+
+			// delete
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandDelete, Namespace: 1, Series: id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Affected != 1 {
+				t.Error("expected series deleted")
+			}
+			if _, err := c.Admin(types.AdminRequest{Command: types.AdminCommandDescribe, Namespace: 1, Series: id}); err == nil {
+				t.Error("expected deleted series to be gone")
+			}
+			if _, err := c.Admin(types.AdminRequest{Command: types.AdminCommandCompact}); err != nil {
+				t.Fatal(err)
+			}
+
+			// admin of a single namespace
+			userOpts := client.NewOpts()
+			userOpts.ListenPort = s.Opts().ListenPort
+			userOpts.User = "teamA"
+			userOpts.AuthToken = "tokenA"
+			userOpts.EagerInitSeries = false
+			userClient := client.New(userOpts)
+			defer userClient.Close()
+			if _, err := userClient.Admin(types.AdminRequest{Command: types.AdminCommandStats}); err == nil || !strings.Contains(err.Error(), types.RpcErrorPermissionDenied.String()) {
+				t.Errorf("expected permission denied, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlerting(t *testing.T) {
+	notifications := make(chan server.AlertNotification, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification server.AlertNotification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Error(err)
+		}
+		notifications <- notification
+	}))
+	defer webhook.Close()
+
+	s := NewTestServer(false, false)
+	s.Opts().AlertRules = []server.AlertRuleOpts{
+		{
+			Name:        "highTemperature",
+			Namespace:   1,
+			Tag:         "temperature",
+			Aggregation: "last",
+			Window:      time.Minute,
+			Operator:    ">",
+			Threshold:   30,
+			For:         200 * time.Millisecond,
+			Interval:    50 * time.Millisecond,
+			Webhook:     webhook.URL,
+			AlertSeries: "temperatureAlerts",
+		},
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	waitForNotification := func() server.AlertNotification {
+		select {
+		case notification := <-notifications:
+			return notification
+		case <-time.After(10 * time.Second):
+			t.Fatal("no notification")
+		}
+		return server.AlertNotification{}
+	}
+
+	kitchen := c.Series("kitchen", client.NewSeriesNamespace(1), client.NewSeriesTags("temperature"))
+	garden := c.Series("garden", client.NewSeriesNamespace(1), client.NewSeriesTags("temperature"))
+	if res := kitchen.Write(c.Now(), 20); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res := garden.Write(c.Now(), 35); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	// pending before firing
+	time.Sleep(100 * time.Millisecond)
+	alerts, err := c.Admin(types.AdminRequest{Command: types.AdminCommandAlerts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.Alerts) != 1 || alerts.Alerts[0].Name != "garden" || alerts.Alerts[0].State != string(server.AlertStatePending) {
+		t.Errorf("expected pending alert %+v", alerts.Alerts)
+	}
+
+	notification := waitForNotification()
+	if notification.Rule != "highTemperature" || notification.Series != "garden" || notification.Id != garden.Id() || notification.State != server.AlertStateFiring || notification.Value != 35 {
+		t.Errorf("unexpected notification %+v", notification)
+	}
+	alerts, err = c.Admin(types.AdminRequest{Command: types.AdminCommandAlerts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.Alerts) != 1 || alerts.Alerts[0].State != string(server.AlertStateFiring) {
+		t.Errorf("expected firing alert %+v", alerts.Alerts)
+	}
+	stats, err := c.Admin(types.AdminRequest{Command: types.AdminCommandStats})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stats["alerts_firing"] != 1 {
+		t.Errorf("expected 1 firing alert, got %d", stats.Stats["alerts_firing"])
+	}
+
+	// the alert series holds the number of firing series
+	result := c.Series("temperatureAlerts", client.NewSeriesNamespace(1)).QueryBuilder().From(c.Now() - 60*1000).To(c.Now()).Execute()
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	var firing float64
+	var latest uint64
+	for ts, value := range result.Results {
+		if ts > latest {
+			latest = ts
+			firing = value
+		}
+		if value > 1 {
+			t.Errorf("unexpected firing count %v", value)
+		}
+	}
+	if firing != 1 {
+		t.Errorf("expected firing count, got %v", result.Results)
+	}
+
+	// resolved by a lower value
+	if res := garden.Write(c.Now()+1, 25); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	notification = waitForNotification()
+	if notification.Series != "garden" || notification.State != server.AlertStateResolved || notification.Value != 25 {
+		t.Errorf("unexpected notification %+v", notification)
+	}
+	alerts, err = c.Admin(types.AdminRequest{Command: types.AdminCommandAlerts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.Alerts) != 0 {
+		t.Errorf("expected no alerts %+v", alerts.Alerts)
+	}
+}
+
+func TestAlertingInvalidRule(t *testing.T) {
+	for name, rule := range map[string]server.AlertRuleOpts{
+		"name":        {Series: "x", Window: time.Minute, Operator: ">"},
+		"series":      {Name: "x", Window: time.Minute, Operator: ">"},
+		"window":      {Name: "x", Series: "x", Operator: ">"},
+		"operator":    {Name: "x", Series: "x", Window: time.Minute, Operator: "=>"},
+		"aggregation": {Name: "x", Series: "x", Window: time.Minute, Operator: ">", Aggregation: "median"},
+	} {
+		s := NewTestServer(false, false)
+		s.Opts().AlertRules = []server.AlertRuleOpts{rule}
+		if err := s.Init(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAlertingMixedValueTypes(t *testing.T) {
+	notifications := make(chan server.AlertNotification, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification server.AlertNotification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Error(err)
+		}
+		notifications <- notification
+	}))
+	defer webhook.Close()
+
+	s := NewTestServer(false, false)
+	s.Opts().AlertRules = []server.AlertRuleOpts{
+		{
+			Name:        "highTemperature",
+			Namespace:   1,
+			Tag:         "temperature",
+			Aggregation: "last",
+			Window:      time.Minute,
+			Operator:    ">",
+			Threshold:   30,
+			Interval:    50 * time.Millisecond,
+			Webhook:     webhook.URL,
+		},
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+
+	// a string series with the tag of the rule is skipped, the float series still fires
+	state := c.Series("sensorState", client.NewSeriesNamespace(1), client.NewSeriesTags("temperature"), client.NewSeriesValueType(types.ValueTypeString))
+	if res := state.WriteValue(c.Now(), types.StringValue("hot")); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	cellar := c.Series("cellar", client.NewSeriesNamespace(1), client.NewSeriesTags("temperature"))
+	if res := cellar.Write(c.Now(), 40); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	select {
+	case notification := <-notifications:
+		if notification.Series != "cellar" || notification.Id != cellar.Id() || notification.State != server.AlertStateFiring || notification.Value != 40 {
+			t.Errorf("unexpected notification %+v", notification)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no notification")
+	}
+}
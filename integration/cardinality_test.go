@@ -0,0 +1,107 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCardinality(t *testing.T) {
+	for backendName, newServer := range map[string]func(init bool, listen bool) *server.Instance{
+		"memory": NewTestServer,
+		"redis":  NewTestServerRedis,
+	} {
+		t.Run(backendName, func(t *testing.T) {
+			s := newServer(false, false)
+			s.Opts().Limits.MaxLabelValues = 2
+			s.Opts().Limits.Namespaces = map[int]server.NamespaceLimitOpts{
+				2: {MaxSeries: 1},
+			}
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.StartListening(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = s.Shutdown()
+			}()
+			c := newSnapshotTestClient(s)
+			defer c.Close()
+			now := c.Now()
+			isCardinalityExceeded := func(err error) bool {
+				return err != nil && strings.Contains(err.Error(), types.RpcErrorCardinalityExceeded.String())
+			}
+			hostSeries := func(name string, host string) *client.Series {
+				return c.Series(name, client.NewSeriesNamespace(1), client.NewSeriesLabels(map[string]string{"host": host, "metric": "cpu"}))
+			}
+
+			// label values, the third host of one batch is rejected
+			batch := c.NewBatchWriter()
+			for i, host := range []string{"a", "b", "c"} {
+				if err := batch.AddToBatch(hostSeries("cpu."+host, host), now+uint64(i), 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if res := batch.Execute(); !isCardinalityExceeded(res.Error) {
+				t.Errorf("expected cardinality error, was %v", res.Error)
+			}
+
+			// existing values can still be used by new series
+			if res := hostSeries("load.a", "a").Write(now, 1); res.Error != nil {
+				t.Error(res.Error)
+			}
+			if res := hostSeries("load.d", "d").Write(now, 1); !isCardinalityExceeded(res.Error) {
+				t.Errorf("expected cardinality error, was %v", res.Error)
+			}
+
+			// neither through updates
+			_, err := hostSeries("cpu.a", "a").Update(client.SeriesUpdate{SetLabels: map[string]string{"host": "e"}})
+			if !isCardinalityExceeded(err) {
+				t.Errorf("expected cardinality error, was %v", err)
+			}
+
+			// max series of one namespace, others use the default limit
+			if res := c.Series("first", client.NewSeriesNamespace(2)).Write(now, 1); res.Error != nil {
+				t.Error(res.Error)
+			}
+			if res := c.Series("second", client.NewSeriesNamespace(2)).Write(now, 1); !isCardinalityExceeded(res.Error) {
+				t.Errorf("expected cardinality error, was %v", res.Error)
+			}
+
+			// report
+			res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandCardinality, Namespace: 1, Limit: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			cardinality := res.Cardinality
+			if cardinality == nil {
+				t.Fatal("missing cardinality")
+			}
+			if cardinality.Series != 3 {
+				t.Errorf("expected 3 series, was %d", cardinality.Series)
+			}
+			if len(cardinality.Labels) != 1 || cardinality.Labels[0] != (types.AdminCardinalityCount{Key: "host", Count: 2}) {
+				t.Errorf("unexpected labels %+v", cardinality.Labels)
+			}
+			today := time.Now().UTC().Format("2006-01-02")
+			if len(cardinality.Days) < 1 || cardinality.Days[len(cardinality.Days)-1].Key != today {
+				t.Errorf("unexpected days %+v", cardinality.Days)
+			}
+			total := 0
+			for _, day := range cardinality.Days {
+				total += day.Count
+			}
+			if total != 3 {
+				t.Errorf("expected 3 series created, was %d", total)
+			}
+			// sessions of the connection pool, the top one only
+			if len(cardinality.Creators) != 1 || cardinality.Creators[0].Series < 1 || cardinality.Creators[0].Session == 0 {
+				t.Errorf("unexpected creators %+v", cardinality.Creators)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/client/prommetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"testing"
+	"time"
+)
+
+func TestClientMetrics(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	registry := prometheus.NewRegistry()
+	opts := client.NewOpts()
+	opts.ListenPort = s.Opts().ListenPort
+	opts.ListenHost = s.Opts().ListenHost
+	opts.AuthToken = s.Opts().AuthToken
+	opts.EagerInitSeries = false
+	opts.Metrics = prommetrics.New(registry)
+	c := client.New(opts)
+	defer c.Close()
+
+	series := c.Series("clientMetricsSeries")
+	writer := c.NewAutoBatchWriter(2, time.Minute, client.NewAutoBatchOptAsyncFlush(false))
+	defer func() {
+		_ = writer.Close()
+	}()
+	now := c.Now()
+	for i := 0; i < 4; i++ {
+		if err := writer.AddToBatch(series, now+uint64(i), float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make(map[string]uint64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range metric.GetLabel() {
+				name += "," + label.GetName() + "=" + label.GetValue()
+			}
+			switch {
+			case metric.GetHistogram() != nil:
+				samples[name] = metric.GetHistogram().GetSampleCount()
+			case metric.GetCounter() != nil:
+				samples[name] = uint64(metric.GetCounter().GetValue())
+			}
+		}
+	}
+	for name, expected := range map[string]uint64{
+		"tsxdb_client_batch_items":                                                  2,
+		"tsxdb_client_flush_duration_seconds,status=ok":                             2,
+		"tsxdb_client_rpc_duration_seconds,endpoint=Writer,status=ok":               2,
+		"tsxdb_client_series_init_total,state=created":                              1,
+		"tsxdb_client_series_init_total,state=cached":                               3,
+		"tsxdb_client_rpc_duration_seconds,endpoint=SeriesCreateMetadata,status=ok": 1,
+	} {
+		if samples[name] != expected {
+			t.Errorf("%s expected %d was %d", name, expected, samples[name])
+		}
+	}
+	if samples["tsxdb_client_pool_wait_seconds"] < 2 {
+		t.Errorf("expected pool gets %+v", samples)
+	}
+}
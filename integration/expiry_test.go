@@ -0,0 +1,82 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpiry(t *testing.T) {
+	for backendName, newServer := range map[string]func(init bool, listen bool) *server.Instance{
+		"memory": NewTestServer,
+		"redis":  NewTestServerRedis,
+	} {
+		t.Run(backendName, func(t *testing.T) {
+			s := newServer(false, false)
+			s.Opts().Expiry.Interval = 100 * time.Millisecond
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.StartListening(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = s.Shutdown()
+			}()
+			c := newSnapshotTestClient(s)
+			defer c.Close()
+			now := c.Now()
+
+			expiring := c.Series("expiring", client.NewSeriesNamespace(1), client.NewSeriesTTL(1))
+			kept := c.Series("kept", client.NewSeriesNamespace(1), client.NewSeriesTTL(3600))
+			for _, series := range []*client.Series{expiring, kept} {
+				if res := series.Write(now, 1); res.Error != nil {
+					t.Fatal(res.Error)
+				}
+			}
+
+			// reaped in the background, without reading the series
+			deadline := time.Now().Add(5 * time.Second)
+			for s.Statistics().NumSeriesExpired() < 1 && time.Now().Before(deadline) {
+				time.Sleep(100 * time.Millisecond)
+			}
+			if expired := s.Statistics().NumSeriesExpired(); expired != 1 {
+				t.Fatalf("expected 1 expired series, was %d", expired)
+			}
+			res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandStats})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Stats["series_expired"] != 1 {
+				t.Errorf("unexpected stats %v", res.Stats)
+			}
+
+			// metadata and data are gone
+			results, _, err := c.SearchSeriesByName(1, types.NameMatchPrefix, "", "", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0)
+			for _, result := range results {
+				names = append(names, result.Name())
+			}
+			if !reflect.DeepEqual(names, []string{"kept"}) {
+				t.Errorf("unexpected series %v", names)
+			}
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandDescribe, Namespace: 1, Series: expiring.Id()})
+			if err == nil && len(res.Series) == 1 && res.Series[0].Points != 0 {
+				t.Errorf("expected data removed %+v", res.Series)
+			}
+			res, err = c.Admin(types.AdminRequest{Command: types.AdminCommandDescribe, Namespace: 1, Series: kept.Id()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Series) != 1 || res.Series[0].Points != 1 {
+				t.Errorf("expected data kept %+v", res.Series)
+			}
+		})
+	}
+}
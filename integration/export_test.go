@@ -0,0 +1,108 @@
+package integration_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/client/export"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+
+	now := c.Now()
+	for _, name := range []string{"exportA", "exportB"} {
+		series := c.Series(name, client.NewSeriesNamespace(1), client.NewSeriesTags("export"))
+		for i := uint64(0); i < 10; i++ {
+			if res := series.Write(now+i*100, float64(i)); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+		}
+	}
+	// not tagged
+	if res := c.Series("exportC", client.NewSeriesNamespace(1)).Write(now, 1); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	series, err := c.SearchSeries(1, "", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected 2 series %v", series)
+	}
+
+	exportFormat := func(format export.Format) []byte {
+		// small pages so the points span several reads
+		exporter, err := export.New(c, export.Opts{Format: format, From: now, To: now + 1000, PageSize: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		result, err := exporter.Export(&buf, series)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Series != 2 || result.Points != 20 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		return buf.Bytes()
+	}
+
+	// csv
+	records, err := csv.NewReader(bytes.NewReader(exportFormat(export.FormatCsv))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 21 || records[0][0] != "series" {
+		t.Fatalf("unexpected csv %v", records)
+	}
+	if records[2][0] != records[1][0] || records[2][3] != "1" {
+		t.Errorf("expected ordered points %v", records[2])
+	}
+
+	// ndjson
+	scanner := bufio.NewScanner(bytes.NewReader(exportFormat(export.FormatNdjson)))
+	var rows []export.Row
+	for scanner.Scan() {
+		var row export.Row
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 20 || rows[1].Timestamp != now+100 || rows[1].Value != 1 || rows[1].Namespace != 1 {
+		t.Errorf("unexpected ndjson %v", rows)
+	}
+
+	// parquet
+	file, err := buffer.NewBufferFile(exportFormat(export.FormatParquet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetReader(file, new(export.ParquetRow), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.GetNumRows() != 20 {
+		t.Fatalf("expected 20 parquet rows, got %d", pr.GetNumRows())
+	}
+	parquetRows := make([]export.ParquetRow, 20)
+	if err := pr.Read(&parquetRows); err != nil {
+		t.Fatal(err)
+	}
+	pr.ReadStop()
+	if parquetRows[19].Value != 9 || parquetRows[19].Timestamp != int64(now+900) {
+		t.Errorf("unexpected parquet row %+v", parquetRows[19])
+	}
+}
@@ -0,0 +1,114 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/server"
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	fieldsTestSuite(t, s)
+}
+
+func TestFieldsRedis(t *testing.T) {
+	s := NewTestServerRedis(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	fieldsTestSuite(t, s)
+}
+
+func fieldsTestSuite(t *testing.T, s *server.Instance) {
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	now := c.Now()
+
+	// one metadata round trip and one point per timestamp for all fields
+	device := c.Series("device1", client.NewSeriesNamespace(1), client.NewSeriesFields("temperature", "humidity", "pressure"))
+	batch := c.NewBatchWriter()
+	for i := uint64(0); i < 3; i++ {
+		if err := batch.AddFieldsToBatch(device, now+i, map[string]float64{
+			"temperature": 20 + float64(i),
+			"humidity":    40.25,
+			"pressure":    1013.5 - float64(i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := batch.Execute(); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res := device.WriteFields(now+3, map[string]float64{"temperature": 1}); res.Error == nil {
+		t.Error("expected missing fields error")
+	}
+	if res := device.WriteFields(now+3, map[string]float64{"temperature": 1, "humidity": 1, "pressure": 1, "wind": 1}); res.Error == nil {
+		t.Error("expected unknown field error")
+	}
+
+	// all fields
+	result := device.QueryBuilder().From(now).To(now + 10).Execute()
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if !reflect.DeepEqual(result.Fields, []string{"temperature", "humidity", "pressure"}) || len(result.TypedResults) != 3 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if !reflect.DeepEqual(result.TypedResults[now+1].Fields, []float64{21, 40.25, 1012.5}) {
+		t.Errorf("unexpected row %v", result.TypedResults[now+1].Fields)
+	}
+
+	// selected fields aligned per timestamp
+	result = device.QueryBuilder().From(now).To(now+10).Fields("pressure", "temperature").Execute()
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if !reflect.DeepEqual(result.Fields, []string{"pressure", "temperature"}) || !reflect.DeepEqual(result.TypedResults[now+2].Fields, []float64{1011.5, 22}) {
+		t.Errorf("unexpected selection %+v", result)
+	}
+	if pressure := result.Field("pressure"); len(pressure) != 3 || pressure[now] != 1013.5 {
+		t.Errorf("unexpected field values %v", pressure)
+	}
+	if result.Field("humidity") != nil {
+		t.Error("expected no values of a field that is not selected")
+	}
+	if result = device.QueryBuilder().From(now).To(now + 10).Fields("wind").Execute(); result.Error == nil {
+		t.Error("expected unknown field error")
+	}
+
+	// pages
+	pages := device.QueryBuilder().From(now).To(now + 10).Fields("humidity").Pages(2)
+	points := 0
+	for pages.Next() {
+		if _, value := pages.TypedValue(); len(value.Fields) != 1 || value.Fields[0] != 40.25 {
+			t.Errorf("unexpected page value %+v", value)
+		}
+		points++
+	}
+	if pages.Err() != nil {
+		t.Fatal(pages.Err())
+	}
+	if points != 3 || pages.Pages() != 2 || !reflect.DeepEqual(pages.Fields(), []string{"humidity"}) {
+		t.Errorf("unexpected pages %d %d %v", points, pages.Pages(), pages.Fields())
+	}
+
+	// invalid schemas
+	for name, fields := range map[string][]string{
+		"none":      nil,
+		"duplicate": {"a", "a"},
+		"invalid":   {"a b"},
+	} {
+		if _, err := c.Series("invalid"+name, client.NewSeriesNamespace(1), client.NewSeriesFields(fields...)).Create(); err == nil {
+			t.Errorf("%s: expected invalid fields error", name)
+		}
+	}
+	other := newSnapshotTestClient(s)
+	defer other.Close()
+	if _, err := other.Series("device1", client.NewSeriesNamespace(1), client.NewSeriesFields("temperature")).Create(); err == nil {
+		t.Error("expected fields mismatch")
+	}
+}
@@ -18,4 +18,9 @@ require (
 	github.com/RobinUS2/tsxdb/client v0.0.0-20200901130747-de49413515ff
 	github.com/RobinUS2/tsxdb/rpc v0.0.0-20200831110925-b62f451e618d
 	github.com/RobinUS2/tsxdb/server v0.0.0-20190523121601-0130f23bf035
+	github.com/RobinUS2/tsxdb/tools v0.0.0-20200901125404-22137cdbe6ba
+	github.com/prometheus/client_golang v1.11.1
+	github.com/xitongsys/parquet-go v1.5.1
+	github.com/xitongsys/parquet-go-source v0.0.0-20190524061010-2b72cbee77d5
+	go.opentelemetry.io/otel/sdk v1.7.0
 )
@@ -0,0 +1,138 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/client/export"
+	"github.com/RobinUS2/tsxdb/client/importer"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCreateSeriesBatch(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+
+	existing := c.Series("batchExisting", client.NewSeriesNamespace(1))
+	id, err := existing.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing.ResetInit()
+	series := []*client.Series{
+		c.Series("batchA", client.NewSeriesNamespace(1), client.NewSeriesTags("batch")),
+		c.Series("batchB", client.NewSeriesNamespace(1)),
+		existing,
+	}
+	if err := c.CreateSeries(series); err != nil {
+		t.Fatal(err)
+	}
+	if series[0].Id() < 1 || series[1].Id() < 1 || series[0].Id() == series[1].Id() {
+		t.Errorf("expected ids %d %d", series[0].Id(), series[1].Id())
+	}
+	if existing.Id() != id {
+		t.Errorf("expected existing id %d was %d", id, existing.Id())
+	}
+	res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Series) != 3 {
+		t.Errorf("expected 3 series %+v", res.Series)
+	}
+
+	// invalid series fail on their own
+	invalid := c.Series("batch invalid", client.NewSeriesNamespace(1))
+	valid := c.Series("batchC", client.NewSeriesNamespace(1))
+	if err := c.CreateSeries([]*client.Series{invalid, valid}); err == nil || !strings.Contains(err.Error(), types.RpcErrorSeriesNameWhitespace.String()) {
+		t.Errorf("expected whitespace error %v", err)
+	}
+	if invalid.Id() != 0 || valid.Id() < 1 {
+		t.Errorf("unexpected ids %d %d", invalid.Id(), valid.Id())
+	}
+}
+
+func TestImport(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+
+	now := c.Now()
+	var csv strings.Builder
+	csv.WriteString("series,namespace,tags,timestamp,value\n")
+	for i := uint64(0); i < 10; i++ {
+		for _, name := range []string{"importA", "importB", "importC"} {
+			csv.WriteString(name + ",1,import|csv," + strconv.FormatUint(now+i, 10) + "," + strconv.FormatUint(i, 10) + "\n")
+		}
+	}
+
+	// dry run
+	dryRun, err := importer.New(nil, importer.Opts{Format: export.FormatCsv, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := dryRun.Import(strings.NewReader(csv.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Points != 30 || result.Series != 3 {
+		t.Errorf("unexpected dry run %+v", result)
+	}
+	if _, err := dryRun.Import(strings.NewReader("series,timestamp,value\nimportA,1,x\n")); err == nil || !strings.Contains(err.Error(), "row 0: invalid value x") {
+		t.Errorf("expected invalid value %v", err)
+	}
+	if found, err := c.SearchSeries(1, "importA", ""); err != nil || len(found) != 0 {
+		t.Errorf("dry run created series %v %v", found, err)
+	}
+
+	// resume after the first 6 rows
+	imp, err := importer.New(c, importer.Opts{Format: export.FormatCsv, BatchSize: 4, Parallelism: 2, Offset: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = imp.Import(strings.NewReader(csv.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Points != 24 || result.Rows != 30 || result.Offset != 30 || result.Series != 3 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	for _, name := range []string{"importA", "importB", "importC"} {
+		results := readAll(t, c, name, now, now+10)
+		if len(results) != 8 || results[now+9] != 9 {
+			t.Errorf("unexpected points of %s %v", name, results)
+		}
+	}
+	found, err := c.SearchSeries(1, "", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 3 {
+		t.Errorf("expected tagged series %v", found)
+	}
+
+	// ndjson, default namespace
+	imp, err = importer.New(c, importer.Opts{Format: export.FormatNdjson, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ndjson := `{"series":"importA","timestamp":` + strconv.FormatUint(now, 10) + `,"value":100}` + "\n\n" +
+		`{"series":"importNdjson","tags":["ndjson"],"timestamp":` + strconv.FormatUint(now, 10) + `,"value":1.5}` + "\n"
+	if result, err = imp.Import(strings.NewReader(ndjson)); err != nil {
+		t.Fatal(err)
+	}
+	if result.Points != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if results := readAll(t, c, "importNdjson", now, now+1); results[now] != 1.5 {
+		t.Errorf("unexpected points %v", results)
+	}
+}
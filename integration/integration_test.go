@@ -598,8 +598,9 @@ func TestBatchWritePerformance(t *testing.T) {
 	if stats.NumSeriesInitialised() != 0 {
 		t.Errorf("init is only if we redo an existing one")
 	}
-	if stats.NumAuthentications() < uint64(numIterations) {
-		t.Errorf("at least 1 auth per flush %d vs %d", stats.NumAuthentications(), numIterations)
+	if stats.NumAuthentications() < 1 || stats.NumAuthentications() >= uint64(numIterations) {
+		// pooled connections keep their session
+		t.Errorf("expected sessions to be reused across flushes %d vs %d", stats.NumAuthentications(), numIterations)
 	}
 	if stats.NumReads() != 0 {
 		t.Errorf("no reads")
@@ -666,8 +667,9 @@ func runBatchWritePerformanceMultiSeries(t *testing.T, s *server.Instance) {
 	if stats.NumSeriesInitialised() < 1 {
 		t.Errorf("init should be done a few times")
 	}
-	if stats.NumAuthentications() < uint64(numIterations) {
-		t.Errorf("at least 1 auth per flush %d vs %d", stats.NumAuthentications(), numIterations)
+	if stats.NumAuthentications() < 1 || stats.NumAuthentications() >= uint64(numIterations) {
+		// pooled connections keep their session
+		t.Errorf("expected sessions to be reused across flushes %d vs %d", stats.NumAuthentications(), numIterations)
 	}
 	if stats.NumReads() != 0 {
 		t.Errorf("no reads")
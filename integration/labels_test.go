@@ -0,0 +1,103 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	labelsTestSuite(t, s)
+}
+
+func TestLabelsRedis(t *testing.T) {
+	s := NewTestServerRedis(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	labelsTestSuite(t, s)
+}
+
+func labelsTestSuite(t *testing.T, s *server.Instance) {
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	now := c.Now()
+
+	batch := c.NewBatchWriter()
+	for name, host := range map[string]string{"cpu.a": "web-a", "cpu.b": "web-b", "cpu.c": "db-c"} {
+		series := c.Series(name, client.NewSeriesNamespace(1), client.NewSeriesLabels(map[string]string{"host": host, "metric": "cpu"}), client.NewSeriesTags("host:"+host))
+		if err := batch.AddToBatch(series, now, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := batch.Execute(); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	search := func(matchers ...types.LabelMatcher) (names []string) {
+		results, err := c.SearchSeriesByLabels(1, matchers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			names = append(names, result.Name())
+		}
+		sort.Strings(names)
+		return
+	}
+
+	// all hosts, which tags could not express
+	hosts, err := c.LabelValues(1, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hosts, []string{"db-c", "web-a", "web-b"}) {
+		t.Errorf("unexpected hosts %v", hosts)
+	}
+	names, err := c.LabelNames(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"host", "metric"}) {
+		t.Errorf("unexpected label names %v", names)
+	}
+
+	// matchers
+	if found := search(types.LabelMatcher{Name: "host", Type: types.MatchRegexp, Value: "web-.*"}); !reflect.DeepEqual(found, []string{"cpu.a", "cpu.b"}) {
+		t.Errorf("unexpected regex matches %v", found)
+	}
+	if found := search(types.LabelMatcher{Name: "metric", Type: types.MatchEqual, Value: "cpu"}, types.LabelMatcher{Name: "host", Type: types.MatchNotEqual, Value: "web-a"}); !reflect.DeepEqual(found, []string{"cpu.b", "cpu.c"}) {
+		t.Errorf("unexpected matches %v", found)
+	}
+	results, err := c.SearchSeriesByLabels(1, types.LabelMatcher{Name: "host", Type: types.MatchNotRegexp, Value: "web-.*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Labels()["host"] != "db-c" || !reflect.DeepEqual(results[0].Tags(), []string{"host:db-c"}) {
+		t.Errorf("unexpected search result %+v", results)
+	}
+	if result := results[0].QueryBuilder().From(now).To(now).Execute(); result.Error != nil || result.Results[now] != 1 {
+		t.Errorf("expected data of the found series %+v", result)
+	}
+	if _, err := c.SearchSeriesByLabels(1, types.LabelMatcher{Name: "host", Type: types.MatchRegexp, Value: "("}); err == nil {
+		t.Error("expected invalid matcher error")
+	}
+
+	// updates
+	series := c.Series("cpu.a")
+	if changed, err := series.Update(client.SeriesUpdate{SetLabels: map[string]string{"host": "web-d"}}); err != nil || !changed || series.Labels()["host"] != "web-d" {
+		t.Errorf("unexpected label update %v %v %v", changed, err, series.Labels())
+	}
+	if found := search(types.LabelMatcher{Name: "host", Type: types.MatchEqual, Value: "web-a"}); len(found) != 0 {
+		t.Errorf("expected no series of the old value, found %v", found)
+	}
+	if _, err := c.Series("invalid", client.NewSeriesNamespace(1), client.NewSeriesLabels(map[string]string{"a b": "c"})).Create(); err == nil {
+		t.Error("expected invalid label error")
+	}
+}
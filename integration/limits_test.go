@@ -0,0 +1,130 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	s := NewTestServer(false, false)
+	s.Opts().Limits.PointsPerSecond = 5
+	s.Opts().Limits.MaxSeriesPerNamespace = 2
+	s.Opts().Limits.MaxPointsPerRead = 3
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := NewTestClient(s)
+	defer c.Close()
+	isQuotaExceeded := func(err error) bool {
+		return err != nil && strings.Contains(err.Error(), types.RpcErrorQuotaExceeded.String())
+	}
+
+	// points per second, the client backs off until the bucket refilled
+	series := c.Series("limitedSeries", client.NewSeriesNamespace(1))
+	now := c.Now()
+	for i := 0; i < 8; i++ {
+		if result := series.Write(now+uint64(i), float64(i)); result.Error != nil {
+			t.Fatal(result.Error)
+		}
+	}
+	if s.Statistics().NumRateLimited() < 1 {
+		t.Error("expected writes to be rate limited")
+	}
+
+	// points per read
+	result := series.QueryBuilder().From(now).To(now + 10).Execute()
+	if !isQuotaExceeded(result.Error) {
+		t.Error(result.Error)
+	}
+
+	// series per namespace, existing series can still be used
+	if result := c.Series("secondSeries", client.NewSeriesNamespace(1)).Write(now, 1); result.Error != nil {
+		t.Error(result.Error)
+	}
+	if result := c.Series("thirdSeries", client.NewSeriesNamespace(1)).Write(now, 1); result.Error == nil || !strings.Contains(result.Error.Error(), types.RpcErrorCardinalityExceeded.String()) {
+		t.Error(result.Error)
+	}
+	if result := c.Series("thirdSeries", client.NewSeriesNamespace(2)).Write(now, 1); result.Error != nil {
+		t.Error(result.Error)
+	}
+	if result := series.QueryBuilder().From(now).To(now + 2).Execute(); result.Error != nil {
+		t.Error(result.Error)
+	}
+}
+
+func TestLimitsRequestsPerSecond(t *testing.T) {
+	s := NewTestServer(false, false)
+	s.Opts().Limits.RequestsPerSecond = 1
+	s.Opts().Limits.RequestsBurst = 1
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := NewTestClient(s)
+	defer c.Close()
+
+	// the auth handshake uses the only call of the new session, the client backs off until the bucket refilled
+	series := c.Series("requestLimitedSeries")
+	if err := series.NoOp(); err != nil {
+		t.Error(err)
+	}
+	if s.Statistics().NumRateLimited() < 1 {
+		t.Error("expected calls to be rate limited")
+	}
+}
+
+func TestLimitsDeniedWritesDoNotSpendPoints(t *testing.T) {
+	s := NewTestServer(false, false)
+	s.Opts().Limits.PointsPerSecond = 5
+	s.Opts().Users = []server.UserOpts{
+		{Name: "owner", AuthToken: "tokenA", Permissions: []server.PermissionOpts{{Namespaces: []int{1}, Rights: []string{"read", "write"}}}},
+		{Name: "auditor", AuthToken: "tokenB", Permissions: []server.PermissionOpts{{Namespaces: []int{1}, Rights: []string{"read"}}}},
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	owner := newTestUserClient(s, "owner", "tokenA")
+	defer owner.Close()
+	auditor := newTestUserClient(s, "auditor", "tokenB")
+	defer auditor.Close()
+	now := owner.Now()
+	if result := owner.Series("ownedSeries", client.NewSeriesNamespace(1)).Write(now, 1); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	// writes without permission are refused before the points are counted
+	for i := 0; i < 10; i++ {
+		result := auditor.Series("ownedSeries", client.NewSeriesNamespace(1)).Write(now+uint64(i), 1)
+		if result.Error == nil || !strings.Contains(result.Error.Error(), types.RpcErrorPermissionDenied.String()) {
+			t.Fatal(result.Error)
+		}
+	}
+	for i := 1; i < 4; i++ {
+		if result := owner.Series("ownedSeries", client.NewSeriesNamespace(1)).Write(now+uint64(i), 1); result.Error != nil {
+			t.Error(result.Error)
+		}
+	}
+	if n := s.Statistics().NumRateLimited(); n != 0 {
+		t.Errorf("expected no rate limited writes, was %d", n)
+	}
+}
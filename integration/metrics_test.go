@@ -0,0 +1,67 @@
+package integration_test
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/server"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	s := NewTestServerRedis(false, false)
+	s.Opts().MetricsHost = "127.0.0.1"
+	s.Opts().MetricsPort = int(atomic.AddUint64(&lastPort, 1))
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := NewTestClient(s)
+	defer c.Close()
+
+	series := c.Series("metricsSeries")
+	now := c.Now()
+	if result := series.Write(now, 1); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result := series.QueryBuilder().From(now).To(now + 1).Execute(); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+
+	res, err := http.Get(fmt.Sprintf("http://%s%s", s.Metrics().Addr(), server.MetricsPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := string(body)
+	for _, expected := range []string{
+		`tsxdb_values_written_total 1`,
+		`tsxdb_reads_total 1`,
+		`tsxdb_sessions_active`,
+		`tsxdb_endpoint_duration_seconds_count{endpoint="Auth",status="ok"}`,
+		`tsxdb_endpoint_duration_seconds_count{endpoint="Writer",status="ok"} 1`,
+		`tsxdb_backend_duration_seconds_count{backend="default",operation="write"} 1`,
+		`tsxdb_backend_duration_seconds_count{backend="default",operation="flush"} 1`,
+		`tsxdb_backend_duration_seconds_count{backend="default",operation="read"} 1`,
+		`tsxdb_redis_pipeline_commands_count{backend="default"} 1`,
+		`tsxdb_pending_requests`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("missing %s", expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/server"
+	"testing"
+)
+
+func TestReadPages(t *testing.T) {
+	for backendName, newServer := range map[string]func(init bool, listen bool) *server.Instance{
+		"memory": NewTestServer,
+		"redis":  NewTestServerRedis,
+	} {
+		t.Run(backendName, func(t *testing.T) {
+			s := newServer(false, false)
+			s.Opts().Limits.MaxPointsPerRead = 5
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.StartListening(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = s.Shutdown()
+			}()
+			c := newSnapshotTestClient(s)
+			defer c.Close()
+
+			// spans two days, written in random order
+			const day = 86400 * 1000
+			now := c.Now()
+			series := c.Series("pagedSeries", client.NewSeriesNamespace(1))
+			batch := c.NewBatchWriter()
+			for _, i := range []uint64{7, 3, 0, 9, 1, 8, 2, 6, 4, 5, 11, 10} {
+				if err := batch.AddToBatch(series, now+i*day/6, float64(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if res := batch.Execute(); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			// pages larger than the server maximum are reduced to it
+			it := series.QueryBuilder().From(now).To(now + 2*day).Pages(100)
+			expected := uint64(0)
+			for it.Next() {
+				ts, value := it.Value()
+				if ts != now+expected*day/6 || value != float64(expected) {
+					t.Errorf("unexpected point %d: %d %v", expected, ts, value)
+				}
+				expected++
+			}
+			if err := it.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if expected != 12 || it.Pages() != 3 {
+				t.Errorf("expected 12 points in 3 pages, got %d in %d", expected, it.Pages())
+			}
+
+			// unlimited reads are still bound to the maximum
+			if res := series.QueryBuilder().From(now).To(now + 2*day).Execute(); res.Error == nil {
+				t.Error("expected quota error")
+			}
+
+			// empty range
+			it = series.QueryBuilder().From(now + 3*day).To(now + 4*day).Pages(2)
+			if it.Next() || it.Err() != nil {
+				t.Errorf("expected no points %v", it.Err())
+			}
+		})
+	}
+}
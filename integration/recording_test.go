@@ -0,0 +1,124 @@
+package integration_test
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"testing"
+	"time"
+)
+
+func TestRecordingRules(t *testing.T) {
+	s := NewTestServer(false, false)
+	s.Opts().RecordingRules = []server.RecordingRuleOpts{
+		{
+			Name:        "apiRequestRate",
+			Tags:        []string{"recorded"},
+			Namespace:   1,
+			Tag:         "service:api",
+			Aggregation: "rate",
+			Window:      time.Minute,
+			Interval:    50 * time.Millisecond,
+		},
+		{
+			Name:        "apiHosts",
+			Namespace:   1,
+			Tag:         "service:api",
+			Aggregation: "last",
+			Window:      time.Minute,
+			Combine:     "count",
+			Interval:    50 * time.Millisecond,
+		},
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+
+	// request counters of three hosts, host i handles i requests per second
+	now := c.Now()
+	batch := c.NewBatchWriter()
+	for i := 1; i <= 3; i++ {
+		series := c.Series(fmt.Sprintf("requests.host%d", i), client.NewSeriesNamespace(1), client.NewSeriesTags("service:api"))
+		if err := batch.AddToBatch(series, now-50*1000, 100); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.AddToBatch(series, now-10*1000, 100+float64(60*i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := batch.Execute(); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	// a string series with the same tag is left out of both rules
+	status := c.Series("status.host1", client.NewSeriesNamespace(1), client.NewSeriesTags("service:api"), client.NewSeriesValueType(types.ValueTypeString))
+	if res := status.WriteValue(now-10*1000, types.StringValue("ok")); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	// both rules evaluated, reading a series that does not exist yet would create it
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		stats, err := c.Admin(types.AdminRequest{Command: types.AdminCommandStats})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Stats["points_recorded"] >= 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected recorded points, got %d", stats.Stats["points_recorded"])
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// latest recorded value
+	latest := func(name string) (value float64, found bool) {
+		result := c.Series(name, client.NewSeriesNamespace(1)).QueryBuilder().From(now).To(c.Now()).Execute()
+		if result.Error != nil {
+			return 0, false
+		}
+		var latestTs uint64
+		for ts, v := range result.Results {
+			if ts > latestTs {
+				latestTs = ts
+				value = v
+			}
+		}
+		return value, latestTs > 0
+	}
+	var rate, hosts float64
+	for time.Now().Before(deadline) {
+		var rateFound, hostsFound bool
+		rate, rateFound = latest("apiRequestRate")
+		hosts, hostsFound = latest("apiHosts")
+		if rateFound && hostsFound && rate == 6 && hosts == 3 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if rate != 6 {
+		t.Errorf("expected a sum of rates of 6, got %v", rate)
+	}
+	if hosts != 3 {
+		t.Errorf("expected 3 hosts, got %v", hosts)
+	}
+
+	// recorded series are regular series with the configured tags
+	search, err := c.SearchSeries(1, "", "recorded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(search) != 1 || search[0].Name() != "apiRequestRate" {
+		t.Errorf("expected recorded series, got %v", search)
+	}
+}
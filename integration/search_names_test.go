@@ -0,0 +1,84 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchNames(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	searchNamesTestSuite(t, s)
+}
+
+func TestSearchNamesRedis(t *testing.T) {
+	s := NewTestServerRedis(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	searchNamesTestSuite(t, s)
+}
+
+func searchNamesTestSuite(t *testing.T, s *server.Instance) {
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	now := c.Now()
+
+	batch := c.NewBatchWriter()
+	for _, name := range []string{"servers.web1.cpu.idle", "servers.web2.cpu.idle", "servers.web2.cpu.user", "servers.db1.cpu.idle", "servers.db1.disk.free", "uptime"} {
+		if err := batch.AddToBatch(c.Series(name, client.NewSeriesNamespace(1), client.NewSeriesTags("servers")), now, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res := batch.Execute(); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	names := func(results []*client.Series) []string {
+		found := make([]string, 0, len(results))
+		for _, result := range results {
+			if result.Id() < 1 {
+				t.Errorf("series %s without id", result.Name())
+			}
+			found = append(found, result.Name())
+		}
+		return found
+	}
+
+	// graphite style
+	results, next, err := c.SearchSeriesByName(1, types.NameMatchGlob, "servers.*.cpu.idle", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found := names(results); !reflect.DeepEqual(found, []string{"servers.db1.cpu.idle", "servers.web1.cpu.idle", "servers.web2.cpu.idle"}) || next != "" {
+		t.Errorf("unexpected %v next %q", found, next)
+	}
+
+	// browsing all series page by page
+	var pages [][]string
+	after := ""
+	for {
+		results, next, err := c.SearchSeriesByName(1, types.NameMatchPrefix, "", after, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, names(results))
+		if next == "" {
+			break
+		}
+		after = next
+	}
+	if !reflect.DeepEqual(pages, [][]string{{"servers.db1.cpu.idle", "servers.db1.disk.free", "servers.web1.cpu.idle", "servers.web2.cpu.idle"}, {"servers.web2.cpu.user", "uptime"}}) {
+		t.Errorf("unexpected pages %v", pages)
+	}
+
+	// invalid patterns are not retried
+	if _, _, err := c.SearchSeriesByName(1, types.NameMatchRegexp, "servers.(", "", 0); err == nil || !strings.Contains(err.Error(), types.RpcErrorInvalidNameMatcher.String()) {
+		t.Errorf("expected invalid name matcher, was %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"reflect"
+	"testing"
+)
+
+func TestSeriesUpdate(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	seriesUpdateTestSuite(t, s)
+}
+
+func TestSeriesUpdateRedis(t *testing.T) {
+	s := NewTestServerRedis(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	seriesUpdateTestSuite(t, s)
+}
+
+func seriesUpdateTestSuite(t *testing.T, s *server.Instance) {
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	now := c.Now()
+	searchNames := func(name string, tag string) (names []string) {
+		results, err := c.SearchSeries(1, name, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range results {
+			names = append(names, result.Name())
+		}
+		return
+	}
+
+	series := c.Series("requests", client.NewSeriesNamespace(1), client.NewSeriesTags("host:a", "env:test"))
+	if res := series.Write(now, 1); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if series.MetadataChanged() {
+		t.Error("expected new series")
+	}
+
+	// another client with other tags updates the series instead of silently using the old tags
+	other := newSnapshotTestClient(s)
+	defer other.Close()
+	moved := other.Series("requests", client.NewSeriesNamespace(1), client.NewSeriesTags("host:b", "env:test"))
+	if _, err := moved.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if !moved.MetadataChanged() || moved.Id() != series.Id() {
+		t.Error("expected changed metadata")
+	}
+	if names := searchNames("", "host:a"); len(names) != 0 {
+		t.Errorf("expected removed tag, found %v", names)
+	}
+	if names := searchNames("", "host:b"); !reflect.DeepEqual(names, []string{"requests"}) {
+		t.Errorf("expected added tag, found %v", names)
+	}
+
+	// explicit updates
+	changed, err := series.Update(client.SeriesUpdate{AddTags: []string{"team:web"}, RemoveTags: []string{"env:test"}, Rename: "http.requests"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || series.Name() != "http.requests" || !reflect.DeepEqual(series.Tags(), []string{"host:b", "team:web"}) {
+		t.Errorf("unexpected update %v %s %v", changed, series.Name(), series.Tags())
+	}
+	if changed, err := series.Update(client.SeriesUpdate{AddTags: []string{"team:web"}}); err != nil || changed {
+		t.Errorf("expected unchanged series %v %v", changed, err)
+	}
+	if names := searchNames("http.requests", ""); len(names) != 1 {
+		t.Errorf("expected renamed series, found %v", names)
+	}
+	if names := searchNames("requests", ""); len(names) != 0 {
+		t.Errorf("expected old name to be free, found %v", names)
+	}
+	if c.Series("http.requests") != series {
+		t.Error("expected renamed series in the pool")
+	}
+	if result := series.QueryBuilder().From(now).To(now).Execute(); result.Error != nil || result.Results[now] != 1 {
+		t.Errorf("expected data of the renamed series %+v", result)
+	}
+
+	// the name of another series can not be taken
+	if _, err := c.Series("errors", client.NewSeriesNamespace(1)).Update(client.SeriesUpdate{Rename: "http.requests"}); err == nil {
+		t.Error("expected name exists error")
+	}
+
+	// ttl
+	if changed, err := series.Update(client.SeriesUpdate{SetTtl: true, Ttl: 3600}); err != nil || !changed || series.TTL() != 3600 {
+		t.Errorf("expected ttl %v %v", changed, err)
+	}
+	res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, meta := range res.Series {
+		if meta.Name == "http.requests" && meta.TtlExpire < uint64(now/1000) {
+			t.Errorf("expected ttl expiry %+v", meta)
+		}
+	}
+	stats, err := c.Admin(types.AdminRequest{Command: types.AdminCommandStats})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stats["series_updated"] != 3 {
+		t.Errorf("expected 3 updates, got %d", stats.Stats["series_updated"])
+	}
+}
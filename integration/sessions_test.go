@@ -0,0 +1,105 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"testing"
+	"time"
+)
+
+func TestSessionSlidingExpiry(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := NewTestClient(s)
+	defer c.Close()
+	sessions := func() map[int]int64 {
+		res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandSessions})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expires := make(map[int]int64)
+		for _, session := range res.Sessions {
+			expires[session.Id] = session.Expires
+		}
+		return expires
+	}
+
+	// every validated call extends the session
+	before := sessions()
+	if len(before) < 1 {
+		t.Fatal("expected active session")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	series := c.Series("slidingSeries")
+	now := c.Now()
+	for i := 0; i < 20; i++ {
+		// round robin over the pooled connections
+		if result := series.Write(now+uint64(i), 1.0); result.Error != nil {
+			t.Fatal(result.Error)
+		}
+	}
+	extended := 0
+	for id, expires := range sessions() {
+		if previous, found := before[id]; found && expires > previous {
+			extended++
+		}
+	}
+	if extended < 1 {
+		t.Error("expected session expiry to be extended on use")
+	}
+}
+
+func TestSessionReauthAndLogout(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := NewTestClient(s)
+	series := c.Series("sessionSeries")
+	now := c.Now()
+	if result := series.Write(now, 1.0); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if s.Statistics().NumSessionsActive() < 1 {
+		t.Error("expected active session")
+	}
+
+	// an admin ends all sessions, pooled connections authenticate again on their next call
+	kickAll(t, c)
+	for i := 0; i < 20; i++ {
+		if result := series.Write(now+uint64(i), 2.0); result.Error != nil {
+			t.Fatal(result.Error)
+		}
+	}
+	if c.NumReauthentications() < 1 {
+		t.Error("expected transparent re-authentication")
+	}
+
+	// close logs out
+	c.Close()
+	stats := s.Statistics()
+	if stats.NumSessionsLoggedOut() < 1 {
+		t.Error("expected logged out sessions")
+	}
+	if stats.NumSessionsActive() != 0 {
+		t.Errorf("expected no active sessions, got %d", stats.NumSessionsActive())
+	}
+}
+
+func kickAll(t *testing.T, c *client.Instance) {
+	res, err := c.Admin(types.AdminRequest{Command: types.AdminCommandSessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Sessions) < 1 {
+		t.Fatal("expected sessions")
+	}
+	for _, session := range res.Sessions {
+		// kicking its own session makes the admin client authenticate again as well
+		if _, err := c.Admin(types.AdminRequest{Command: types.AdminCommandKick, Session: session.Id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newSnapshotTestClient(s *server.Instance) *client.Instance {
+	opts := client.NewOpts()
+	opts.ListenPort = s.Opts().ListenPort
+	opts.AuthToken = s.Opts().AuthToken
+	opts.EagerInitSeries = false
+	return client.New(opts)
+}
+
+func readAll(t *testing.T, c *client.Instance, name string, from uint64, to uint64) map[uint64]float64 {
+	result := c.Series(name, client.NewSeriesNamespace(1)).QueryBuilder().From(from).To(to).Execute()
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	return result.Results
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsxdb-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	const archive = "snapshot.json.gz" // in the snapshot directory of the servers
+
+	// memory
+	source := NewTestServer(false, false)
+	source.Opts().SnapshotDir = dir
+	if err := source.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = source.Shutdown()
+	}()
+	sourceClient := newSnapshotTestClient(source)
+	defer sourceClient.Close()
+	now := sourceClient.Now()
+	series := sourceClient.Series("snapshotSeries", client.NewSeriesNamespace(1), client.NewSeriesTags("a", "b"))
+	for i := uint64(0); i < 10; i++ {
+		if res := series.Write(now+i, float64(i)); res.Error != nil {
+			t.Fatal(res.Error)
+		}
+	}
+	// only files in the snapshot directory
+	for _, invalid := range []string{filepath.Join(dir, archive), "../" + archive, "..", ""} {
+		if _, err := sourceClient.Admin(types.AdminRequest{Command: types.AdminCommandSnapshot, Path: invalid}); err == nil {
+			t.Errorf("expected path %q to be refused", invalid)
+		}
+	}
+	sourceSeries, err := sourceClient.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceCardinality, err := sourceClient.Admin(types.AdminRequest{Command: types.AdminCommandCardinality, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sourceSeries.Series) != 1 || sourceSeries.Series[0].Created < 1 || len(sourceCardinality.Cardinality.Creators) != 1 {
+		t.Fatalf("expected creation recorded %+v %+v", sourceSeries.Series, sourceCardinality.Cardinality)
+	}
+	res, err := sourceClient.Admin(types.AdminRequest{Command: types.AdminCommandSnapshot, Path: archive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Affected != 1 || res.Points != 10 {
+		t.Errorf("unexpected snapshot %+v", res)
+	}
+
+	// redis
+	target := NewTestServerRedis(false, false)
+	target.Opts().SnapshotDir = dir
+	if err := target.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := target.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = target.Shutdown()
+	}()
+	targetClient := newSnapshotTestClient(target)
+	defer targetClient.Close()
+	res, err = targetClient.Admin(types.AdminRequest{Command: types.AdminCommandRestore, Path: archive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Affected != 1 || res.Points != 10 {
+		t.Errorf("unexpected restore %+v", res)
+	}
+	results := readAll(t, targetClient, "snapshotSeries", now, now+10)
+	if len(results) != 10 {
+		t.Fatalf("expected 10 points %v", results)
+	}
+	for i := uint64(0); i < 10; i++ {
+		if results[now+i] != float64(i) {
+			t.Errorf("unexpected value at %d: %v", i, results[now+i])
+		}
+	}
+	res, err = targetClient.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Series) != 1 || len(res.Series[0].Tags) != 2 {
+		t.Errorf("expected tags restored %+v", res.Series)
+	}
+	if len(res.Series) == 1 && res.Series[0].Created != sourceSeries.Series[0].Created {
+		t.Errorf("expected creation time %d restored, got %d", sourceSeries.Series[0].Created, res.Series[0].Created)
+	}
+	res, err = targetClient.Admin(types.AdminRequest{Command: types.AdminCommandCardinality, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Cardinality.Creators, sourceCardinality.Cardinality.Creators) {
+		t.Errorf("expected creators %+v restored, got %+v", sourceCardinality.Cardinality.Creators, res.Cardinality.Creators)
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsxdb-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "snapshot.json.gz")
+
+	// written during shutdown
+	s := NewTestServer(false, false)
+	s.Opts().SnapshotPath = path
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	c := newSnapshotTestClient(s)
+	now := c.Now()
+	if res := c.Series("restartSeries", client.NewSeriesNamespace(1)).Write(now, 42); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	c.Close()
+	if err := s.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restored during init
+	restarted := NewTestServer(false, false)
+	restarted.Opts().SnapshotPath = path
+	if err := restarted.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = restarted.Shutdown()
+	}()
+	restartedClient := newSnapshotTestClient(restarted)
+	defer restartedClient.Close()
+	results := readAll(t, restartedClient, "restartSeries", now, now+1)
+	if results[now] != 42 {
+		t.Errorf("expected value restored %v", results)
+	}
+}
@@ -0,0 +1,177 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"strings"
+	"testing"
+	"time"
+)
+
+// read points until n arrived, the subscription ended or the timeout passed
+func receivePoints(sub *client.Subscription, n int) []types.SubscriptionPoint {
+	points := make([]types.SubscriptionPoint, 0)
+	timeout := time.After(10 * time.Second)
+	for len(points) < n {
+		select {
+		case point, ok := <-sub.Points():
+			if !ok {
+				return points
+			}
+			points = append(points, point)
+		case <-timeout:
+			return points
+		}
+	}
+	return points
+}
+
+func TestSubscribe(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	now := c.Now()
+
+	existing := c.Series("subscribedSeries", client.NewSeriesNamespace(1))
+	existingId, err := existing.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// filters
+	if _, err := c.Subscribe(1, client.SubscriptionFilter{}); err == nil {
+		t.Error("expected missing filter error")
+	}
+	if _, err := c.Subscribe(1, client.SubscriptionFilter{Ids: []uint64{existingId + 1000}}); err == nil {
+		t.Error("expected unknown series error")
+	}
+	byId, err := c.Subscribe(1, client.SubscriptionFilter{Ids: []uint64{existingId}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName, err := c.Subscribe(1, client.SubscriptionFilter{Name: "laterSeries"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byTag, err := c.Subscribe(1, client.SubscriptionFilter{Tag: "live"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ActiveSubscriptions() != 3 {
+		t.Errorf("expected 3 subscriptions, got %d", s.ActiveSubscriptions())
+	}
+
+	// series created after subscribing are matched by name and tag
+	later := c.Series("laterSeries", client.NewSeriesNamespace(1), client.NewSeriesTags("live"))
+	other := c.Series("otherSeries", client.NewSeriesNamespace(1))
+	for i := uint64(0); i < 3; i++ {
+		for _, series := range []*client.Series{existing, later, other} {
+			if res := series.Write(now+i, float64(i)); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+		}
+	}
+	for name, sub := range map[string]*client.Subscription{"id": byId, "name": byName, "tag": byTag} {
+		points := receivePoints(sub, 3)
+		if len(points) != 3 {
+			t.Fatalf("%s expected 3 points, got %v", name, points)
+		}
+		for i, point := range points {
+			if point.Time != now+uint64(i) || point.Value != float64(i) || point.Namespace != 1 {
+				t.Errorf("%s unexpected point %d %+v", name, i, point)
+			}
+		}
+		if name == "id" && points[0].Id != existingId {
+			t.Errorf("expected series %d got %d", existingId, points[0].Id)
+		}
+		if name != "id" && points[0].Id != later.Id() {
+			t.Errorf("expected series %d got %d", later.Id(), points[0].Id)
+		}
+	}
+
+	// close ends the channel
+	for _, sub := range []*client.Subscription{byId, byName, byTag} {
+		if err := sub.Close(); err != nil {
+			t.Error(err)
+		}
+		if err := sub.Close(); err != nil {
+			t.Error(err)
+		}
+		if sub.Err() != nil {
+			t.Error(sub.Err())
+		}
+	}
+	if s.ActiveSubscriptions() != 0 {
+		t.Errorf("expected no subscriptions, got %d", s.ActiveSubscriptions())
+	}
+}
+
+func TestSubscribeSlowConsumer(t *testing.T) {
+	const points = 2000
+	for _, slowConsumer := range []string{server.SlowConsumerDrop, server.SlowConsumerDisconnect} {
+		t.Run(slowConsumer, func(t *testing.T) {
+			s := NewTestServer(false, false)
+			s.Opts().Subscriptions = server.SubscriptionOpts{BufferSize: 10, SlowConsumer: slowConsumer}
+			if err := s.Init(); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.StartListening(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = s.Shutdown()
+			}()
+			c := newSnapshotTestClient(s)
+			defer c.Close()
+
+			sub, err := c.Subscribe(1, client.SubscriptionFilter{Name: "busySeries"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = sub.Close()
+			}()
+
+			// more points in one write than the server buffers
+			now := c.Now()
+			series := c.Series("busySeries", client.NewSeriesNamespace(1))
+			batch := c.NewBatchWriter()
+			for i := uint64(0); i < points; i++ {
+				if err := batch.AddToBatch(series, now+i, float64(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if res := batch.Execute(); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+
+			switch slowConsumer {
+			case server.SlowConsumerDrop:
+				received := receivePoints(sub, 10)
+				// the dropped count arrives with a later poll
+				deadline := time.Now().Add(10 * time.Second)
+				for len(received)+int(sub.Dropped()) < points && time.Now().Before(deadline) {
+					time.Sleep(10 * time.Millisecond)
+				}
+				if len(received) != 10 || sub.Dropped() != points-10 {
+					t.Errorf("expected 10 points and %d dropped, got %d and %d", points-10, len(received), sub.Dropped())
+				}
+			case server.SlowConsumerDisconnect:
+				received := receivePoints(sub, points)
+				if len(received) != 0 {
+					t.Errorf("expected no points, got %d", len(received))
+				}
+				if err := sub.Err(); err == nil || !strings.Contains(err.Error(), types.RpcErrorSubscriptionSlowConsumer.String()) {
+					t.Errorf("expected slow consumer error, got %v", err)
+				}
+				if s.ActiveSubscriptions() != 0 {
+					t.Errorf("expected no subscriptions, got %d", s.ActiveSubscriptions())
+				}
+			}
+		})
+	}
+}
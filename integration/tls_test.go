@@ -0,0 +1,83 @@
+package integration_test
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/tlstest"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
+	"io/ioutil"
+	netRpc "net/rpc"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMutualTls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "integration_tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	if err := tlstest.WriteCertificates(dir, "writer", "intruder"); err != nil {
+		t.Fatal(err)
+	}
+
+	// server only accepting the writer identity
+	s := NewTestServer(false, false)
+	s.Opts().Tls = tlstest.ServerOpts(dir)
+	s.Opts().Tls.AllowedIdentities = []string{"writer"}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	clientTls := func(commonName string) rpc.OptsTls {
+		return tlstest.ClientOpts(dir, commonName)
+	}
+
+	// allowed identity can write and read
+	{
+		opts := client.NewOpts()
+		opts.ListenPort = s.Opts().ListenPort
+		opts.ListenHost = s.Opts().ListenHost
+		opts.AuthToken = s.Opts().AuthToken
+		opts.Tls = clientTls("writer")
+		c := client.New(opts)
+		now := c.Now()
+		series := c.Series("tlsSeries")
+		if result := series.Write(now, 1.5); result.Error != nil {
+			t.Error(result.Error)
+		}
+		result := series.QueryBuilder().From(now - 1).To(now + 1).Execute()
+		if result.Error != nil {
+			t.Error(result.Error)
+		}
+		if result.Results[now] != 1.5 {
+			t.Error(result.Results)
+		}
+		c.Close()
+	}
+
+	// other identity is refused during the handshake
+	{
+		conn, err := rpc.Dial(fmt.Sprintf("%s:%d", s.Opts().ListenHost, s.Opts().ListenPort), time.Second, clientTls("intruder"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpcClient := netRpc.NewClientWithCodec(rpc.NewGobClientCodec(conn))
+		authRequest, _ := tools.BasicAuthRequest(s.Opts().OptsConnection)
+		var authReply *types.AuthResponse
+		if err := rpcClient.Call(types.EndpointAuth.String()+"."+types.MethodName, authRequest, &authReply); err == nil {
+			t.Error("expected intruder to be refused")
+		}
+		_ = rpcClient.Close()
+	}
+}
@@ -0,0 +1,85 @@
+package integration_test
+
+import (
+	"context"
+	"github.com/RobinUS2/tsxdb/client"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	// client and server share the in-process recorder, no collector needed
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := NewTestServer(false, false)
+	s.Opts().TracerProvider = provider
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	opts := client.NewOpts()
+	opts.ListenPort = s.Opts().ListenPort
+	opts.ListenHost = s.Opts().ListenHost
+	opts.AuthToken = s.Opts().AuthToken
+	opts.EagerInitSeries = false
+	opts.TracerProvider = provider
+	c := client.New(opts)
+	defer c.Close()
+
+	// parent span of the application
+	ctx, root := provider.Tracer("test").Start(context.Background(), "ingest")
+	series := c.Series("tracedSeries")
+	batch := c.NewBatchWriter()
+	now := c.Now()
+	if err := batch.AddToBatch(series, now, 1); err != nil {
+		t.Fatal(err)
+	}
+	if res := batch.ExecuteContext(ctx); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	multi := c.MultiQueryBuilder()
+	if err := multi.AddQuery(series.QueryBuilder().From(now).To(now + 1)); err != nil {
+		t.Fatal(err)
+	}
+	if res := multi.ExecuteContext(ctx); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	root.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	// span => expected parent
+	for name, parent := range map[string]string{
+		"BatchWriter.Execute":          "ingest",
+		"Series.Init":                  "BatchWriter.Execute",
+		"SeriesCreateMetadata":         "Series.Init",
+		"backend.CreateOrUpdateSeries": "SeriesCreateMetadata",
+		"Writer":                       "BatchWriter.Execute",
+		"backend.Write":                "Writer",
+		"backend.FlushPendingWrites":   "Writer",
+		"MultiQueryBuilder.Execute":    "ingest",
+		"Reader":                       "MultiQueryBuilder.Execute",
+		"backend.Read":                 "Reader",
+	} {
+		span, found := spans[name]
+		if !found {
+			t.Errorf("missing span %s", name)
+			continue
+		}
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("span %s not part of the trace", name)
+		}
+		if span.Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Errorf("span %s expected parent %s", name, parent)
+		}
+	}
+}
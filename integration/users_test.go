@@ -0,0 +1,107 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"strings"
+	"testing"
+)
+
+func newTestUserClient(s *server.Instance, user string, authToken string) *client.Instance {
+	opts := client.NewOpts()
+	opts.ListenPort = s.Opts().ListenPort
+	opts.ListenHost = s.Opts().ListenHost
+	opts.User = user
+	opts.AuthToken = authToken
+	return client.New(opts)
+}
+
+func TestUserPermissions(t *testing.T) {
+	s := NewTestServer(false, false)
+	s.Opts().Users = []server.UserOpts{
+		{Name: "teamA", AuthToken: "tokenA", Permissions: []server.PermissionOpts{{Namespaces: []int{1}, Rights: []string{"read", "write"}}}},
+		{Name: "teamB", AuthToken: "tokenB", Permissions: []server.PermissionOpts{{Namespaces: []int{2}, Rights: []string{"admin"}}}},
+		{Name: "auditor", AuthToken: "tokenC", Permissions: []server.PermissionOpts{{Namespaces: []int{1}, Rights: []string{"read"}}}},
+	}
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	const oneMinute = 60 * 1000
+	isPermissionDenied := func(err error) bool {
+		return err != nil && strings.Contains(err.Error(), types.RpcErrorPermissionDenied.String())
+	}
+
+	// own namespace
+	teamA := newTestUserClient(s, "teamA", "tokenA")
+	now := teamA.Now()
+	{
+		series := teamA.Series("teamSeries", client.NewSeriesNamespace(1))
+		if result := series.Write(now, 1.0); result.Error != nil {
+			t.Error(result.Error)
+		}
+		result := series.QueryBuilder().From(now - oneMinute).To(now + oneMinute).Execute()
+		if result.Error != nil {
+			t.Error(result.Error)
+		}
+		if result.Results[now] != 1.0 {
+			t.Error(result.Results)
+		}
+	}
+
+	// other namespace
+	if result := teamA.Series("otherTeamSeries", client.NewSeriesNamespace(2)).Write(now, 2.0); !isPermissionDenied(result.Error) {
+		t.Error(result.Error)
+	}
+	teamA.Close()
+
+	// other team can not read the series
+	teamB := newTestUserClient(s, "teamB", "tokenB")
+	{
+		result := teamB.Series("teamSeries", client.NewSeriesNamespace(1)).QueryBuilder().From(now - oneMinute).To(now + oneMinute).Execute()
+		if !isPermissionDenied(result.Error) {
+			t.Error(result.Error)
+		}
+	}
+	teamB.Close()
+
+	// read only
+	auditor := newTestUserClient(s, "auditor", "tokenC")
+	{
+		series := auditor.Series("teamSeries", client.NewSeriesNamespace(1))
+		result := series.QueryBuilder().From(now - oneMinute).To(now + oneMinute).Execute()
+		if result.Error != nil {
+			t.Error(result.Error)
+		}
+		if result.Results[now] != 1.0 {
+			t.Error(result.Results)
+		}
+		if result := series.Write(now, 3.0); !isPermissionDenied(result.Error) {
+			t.Error(result.Error)
+		}
+		if result := auditor.Series("newSeries", client.NewSeriesNamespace(1)).Write(now, 3.0); !isPermissionDenied(result.Error) {
+			t.Error(result.Error)
+		}
+	}
+	auditor.Close()
+
+	// invalid credentials
+	invalid := newTestUserClient(s, "teamA", "tokenB")
+	if _, err := invalid.GetConnection(); err == nil || !strings.Contains(err.Error(), types.RpcErrorAuthFailed.String()) {
+		t.Error(err)
+	}
+	invalid.Close()
+
+	// shared auth token still has all permissions
+	c := NewTestClient(s)
+	if result := c.Series("sharedSeries", client.NewSeriesNamespace(2)).Write(now, 4.0); result.Error != nil {
+		t.Error(result.Error)
+	}
+	c.Close()
+}
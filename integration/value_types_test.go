@@ -0,0 +1,208 @@
+package integration_test
+
+import (
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestValueTypes(t *testing.T) {
+	s := NewTestServer(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	valueTypesTestSuite(t, s)
+}
+
+func TestValueTypesRedis(t *testing.T) {
+	s := NewTestServerRedis(true, true)
+	defer func() {
+		_ = s.Shutdown()
+	}()
+	valueTypesTestSuite(t, s)
+}
+
+func valueTypesTestSuite(t *testing.T, s *server.Instance) {
+	c := newSnapshotTestClient(s)
+	defer c.Close()
+	now := c.Now()
+	read := func(series *client.Series) client.QueryResult {
+		result := series.QueryBuilder().From(now).To(now + 10).Execute()
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		return result
+	}
+
+	// int64 without loss of precision
+	counter := c.Series("counter", client.NewSeriesNamespace(1), client.NewSeriesValueType(types.ValueTypeInt))
+	large := int64(1<<62 + 1)
+	if res := counter.WriteValue(now, types.IntValue(large)); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res := counter.WriteValue(now+1, types.IntValue(-3)); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	result := read(counter)
+	if result.ValueType != types.ValueTypeInt || len(result.Results) != 0 || result.TypedResults[now].Int != large || result.TypedResults[now+1].Int != -3 {
+		t.Errorf("unexpected int results %+v", result)
+	}
+
+	// bool
+	up := c.Series("up", client.NewSeriesNamespace(1), client.NewSeriesValueType(types.ValueTypeBool))
+	if res := up.WriteValue(now, types.BoolValue(true)); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res := up.WriteValue(now+1, types.BoolValue(false)); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	result = read(up)
+	if len(result.TypedResults) != 2 || !result.TypedResults[now].Bool || result.TypedResults[now+1].Bool {
+		t.Errorf("unexpected bool results %+v", result)
+	}
+
+	// enum
+	state := c.Series("state", client.NewSeriesNamespace(1), client.NewSeriesEnum("ok", "warn:disk", "error"))
+	if res := state.WriteValue(now, types.StringValue("warn:disk")); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res := state.WriteValue(now+1, types.StringValue("unknown")); res.Error == nil {
+		t.Error("expected enum error")
+	}
+	result = read(state)
+	if len(result.TypedResults) != 1 || result.TypedResults[now].String != "warn:disk" {
+		t.Errorf("unexpected enum results %+v", result)
+	}
+	it := result.Iterator()
+	if !it.Next() {
+		t.Fatal("expected a point")
+	}
+	if ts, value := it.TypedValue(); ts != now || value.String != "warn:disk" {
+		t.Errorf("unexpected iterator value %d %+v", ts, value)
+	}
+
+	// histogram
+	latency := c.Series("latency", client.NewSeriesNamespace(1), client.NewSeriesValueType(types.ValueTypeHistogram))
+	histogram := types.NewHistogram(1, 5, 10)
+	for _, v := range []float64{0.5, 1, 7, 20} {
+		histogram.Observe(v)
+	}
+	if res := latency.WriteValue(now, types.HistogramValue(histogram)); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if res := latency.WriteValue(now+1, types.HistogramValue(&types.Histogram{Bounds: []float64{1}, Counts: []uint64{1}})); res.Error == nil {
+		t.Error("expected histogram error")
+	}
+	result = read(latency)
+	value := result.TypedResults[now].Histogram
+	if value == nil || !reflect.DeepEqual(value.Counts, []uint64{2, 0, 1, 1}) || value.Sum != 28.5 || value.Count() != 4 {
+		t.Errorf("unexpected histogram %+v", value)
+	}
+
+	// pages
+	pages := counter.QueryBuilder().From(now).To(now + 10).Pages(1)
+	var values []int64
+	for pages.Next() {
+		_, value := pages.TypedValue()
+		values = append(values, value.Int)
+	}
+	if pages.Err() != nil {
+		t.Fatal(pages.Err())
+	}
+	if pages.ValueType() != types.ValueTypeInt || !reflect.DeepEqual(values, []int64{large, -3}) {
+		t.Errorf("unexpected pages %v", values)
+	}
+
+	// the value type is checked by the server, also for clients that do not know it
+	other := newSnapshotTestClient(s)
+	defer other.Close()
+	if res := other.Series("counter", client.NewSeriesNamespace(1)).Write(now+2, 1.5); res.Error == nil {
+		t.Error("expected value type mismatch")
+	}
+	if res := other.Series("up", client.NewSeriesNamespace(1), client.NewSeriesValueType(types.ValueTypeInt)).WriteValue(now+2, types.IntValue(1)); res.Error == nil {
+		t.Error("expected series init error")
+	}
+	if _, err := other.Series("invalid", client.NewSeriesNamespace(1), client.NewSeriesValueType("complex")).Create(); err == nil {
+		t.Error("expected invalid value type")
+	}
+
+	// float series are unchanged
+	temperature := c.Series("temperature", client.NewSeriesNamespace(1))
+	if err := c.NewBatchWriter().AddValueToBatch(temperature, now, types.IntValue(1)); err == nil {
+		t.Error("expected float series error")
+	}
+	if res := temperature.Write(now, 21.5); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if result = read(temperature); result.Results[now] != 21.5 || result.ValueType != types.ValueTypeFloat {
+		t.Errorf("unexpected float results %+v", result)
+	}
+}
+
+func TestValueTypesSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsxdb-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	const archive = "snapshot.json.gz"
+
+	source := NewTestServer(false, false)
+	source.Opts().SnapshotDir = dir
+	if err := source.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = source.Shutdown()
+	}()
+	sourceClient := newSnapshotTestClient(source)
+	defer sourceClient.Close()
+	now := sourceClient.Now()
+	state := sourceClient.Series("state", client.NewSeriesNamespace(1), client.NewSeriesEnum("ok", "error"))
+	if res := state.WriteValue(now, types.StringValue("error")); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if _, err := sourceClient.Admin(types.AdminRequest{Command: types.AdminCommandSnapshot, Path: archive}); err != nil {
+		t.Fatal(err)
+	}
+
+	target := NewTestServerRedis(false, false)
+	target.Opts().SnapshotDir = dir
+	if err := target.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := target.StartListening(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = target.Shutdown()
+	}()
+	targetClient := newSnapshotTestClient(target)
+	defer targetClient.Close()
+	if _, err := targetClient.Admin(types.AdminRequest{Command: types.AdminCommandRestore, Path: archive}); err != nil {
+		t.Fatal(err)
+	}
+	result := targetClient.Series("state", client.NewSeriesNamespace(1)).QueryBuilder().From(now).To(now).Execute()
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.ValueType != types.ValueTypeString || result.TypedResults[now].String != "error" {
+		t.Errorf("unexpected restored results %+v", result)
+	}
+	res, err := targetClient.Admin(types.AdminRequest{Command: types.AdminCommandSeries, Namespace: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Series) != 1 || res.Series[0].ValueType != types.ValueTypeString || !reflect.DeepEqual(res.Series[0].Enum, []string{"ok", "error"}) {
+		t.Errorf("expected value type restored %+v", res.Series)
+	}
+}
@@ -3,28 +3,66 @@ package rpc
 import (
 	"bufio"
 	"encoding/gob"
+	"github.com/RobinUS2/tsxdb/rpc/types"
 	"io"
 	"log"
 	"net/rpc"
+	"sync"
+	"time"
 )
 
+// called after every response has been written, e.g. to record latencies
+type ResponseObserver func(serviceMethod string, duration time.Duration, body interface{})
+
 type GobServerCodec struct {
 	rwc    io.ReadWriteCloser
 	dec    *gob.Decoder
 	enc    *gob.Encoder
 	encBuf *bufio.Writer
 	closed bool
+
+	peerIdentity string // verified tls client certificate identity of this connection
+
+	observer    ResponseObserver
+	requestsMux sync.Mutex
+	requests    map[uint64]time.Time // sequence => time the request header was read, only tracked with an observer
+}
+
+func (c *GobServerCodec) SetPeerIdentity(identity string) {
+	c.peerIdentity = identity
+}
+
+func (c *GobServerCodec) SetObserver(observer ResponseObserver) {
+	c.observer = observer
 }
 
 func (c *GobServerCodec) ReadRequestHeader(r *rpc.Request) error {
-	return c.dec.Decode(r)
+	if err := c.dec.Decode(r); err != nil {
+		return err
+	}
+	if c.observer != nil {
+		c.requestsMux.Lock()
+		c.requests[r.Seq] = time.Now()
+		c.requestsMux.Unlock()
+	}
+	return nil
 }
 
 func (c *GobServerCodec) ReadRequestBody(body interface{}) error {
-	return c.dec.Decode(body)
+	if err := c.dec.Decode(body); err != nil {
+		return err
+	}
+	// stamp the connection identity on the request, this can not be set by the client since it is never transmitted
+	if aware, ok := body.(types.PeerIdentityAware); ok {
+		aware.SetPeerIdentity(c.peerIdentity)
+	}
+	return nil
 }
 
 func (c *GobServerCodec) WriteResponse(r *rpc.Response, body interface{}) (err error) {
+	if c.observer != nil {
+		defer c.observe(r, body)
+	}
 	if err = c.enc.Encode(r); err != nil {
 		if c.encBuf.Flush() == nil {
 			// Gob couldn't encode the header. Should not happen, so if it does,
@@ -46,6 +84,17 @@ func (c *GobServerCodec) WriteResponse(r *rpc.Response, body interface{}) (err e
 	return c.encBuf.Flush()
 }
 
+func (c *GobServerCodec) observe(r *rpc.Response, body interface{}) {
+	c.requestsMux.Lock()
+	start, found := c.requests[r.Seq]
+	delete(c.requests, r.Seq)
+	c.requestsMux.Unlock()
+	if !found {
+		return
+	}
+	c.observer(r.ServiceMethod, time.Since(start), body)
+}
+
 func (c *GobServerCodec) Close() error {
 	if c.closed {
 		// Only call c.rwc.Close once; otherwise the semantics are undefined.
@@ -59,9 +108,10 @@ func NewGobServerCodec(rwc io.ReadWriteCloser) *GobServerCodec {
 	encBuf := bufio.NewWriter(rwc)
 	dec := gob.NewDecoder(rwc)
 	return &GobServerCodec{
-		rwc:    rwc,
-		dec:    dec,
-		enc:    gob.NewEncoder(rwc),
-		encBuf: encBuf,
+		rwc:      rwc,
+		dec:      dec,
+		enc:      gob.NewEncoder(rwc),
+		encBuf:   encBuf,
+		requests: make(map[uint64]time.Time),
 	}
 }
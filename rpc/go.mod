@@ -2,4 +2,8 @@ module github.com/RobinUS2/tsxdb/rpc
 
 go 1.13
 
-require github.com/pkg/errors v0.9.1
+require (
+	github.com/pkg/errors v0.9.1
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+)
@@ -10,9 +10,11 @@ const DefaultTimeout = 60 * time.Second        // timeout for a connection
 type OptsConnection struct {
 	ListenPort     int           `yaml:"listen_port"`
 	ListenHost     string        `yaml:"listen_host"`
+	User           string        `yaml:"user"` // named user, leave empty to use the shared auth token
 	AuthToken      string        `yaml:"auth_token"`
 	ConnectTimeout time.Duration `yaml:"connection_timeout"`
 	Debug          bool          `yaml:"debug"`
+	Tls            OptsTls       `yaml:"tls"`
 }
 
 func NewOptsConnection() OptsConnection {
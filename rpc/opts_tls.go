@@ -0,0 +1,135 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+)
+
+type ClientAuthMode string
+
+const ClientAuthNone ClientAuthMode = "none"                           // no client certificate requested
+const ClientAuthRequest ClientAuthMode = "request"                     // requested, not required, not verified
+const ClientAuthRequire ClientAuthMode = "require"                     // required, not verified
+const ClientAuthVerifyIfGiven ClientAuthMode = "verify_if_given"       // not required, verified if provided
+const ClientAuthRequireAndVerify ClientAuthMode = "require_and_verify" // mutual tls
+
+type OptsTls struct {
+	Enabled            bool           `yaml:"enabled"`
+	CertFile           string         `yaml:"cert_file"`            // server certificate, or client certificate for mutual tls
+	KeyFile            string         `yaml:"key_file"`             // key belonging to the certificate
+	CaFile             string         `yaml:"ca_file"`              // server: CA to verify clients, client: CA to verify server (system pool if empty)
+	ClientAuth         ClientAuthMode `yaml:"client_auth"`          // server only, see ClientAuth* modes
+	AllowedIdentities  []string       `yaml:"allowed_identities"`   // server only, if set only verified client certificates with these identities are accepted
+	ServerName         string         `yaml:"server_name"`          // client only, overrides the host name used to verify the server certificate
+	InsecureSkipVerify bool           `yaml:"insecure_skip_verify"` // client only, testing only
+}
+
+func (opts OptsTls) clientAuthType() (tls.ClientAuthType, error) {
+	switch opts.ClientAuth {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthRequire:
+		return tls.RequireAnyClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("client auth mode %s not supported", opts.ClientAuth)
+	}
+}
+
+func (opts OptsTls) certificates() ([]tls.Certificate, error) {
+	if opts.CertFile == "" && opts.KeyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load tls key pair")
+	}
+	return []tls.Certificate{cert}, nil
+}
+
+func (opts OptsTls) certPool() (*x509.CertPool, error) {
+	if opts.CaFile == "" {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(opts.CaFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read tls ca")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in %s", opts.CaFile)
+	}
+	return pool, nil
+}
+
+func (opts OptsTls) ServerConfig() (*tls.Config, error) {
+	certificates, err := opts.certificates()
+	if err != nil {
+		return nil, err
+	}
+	if len(certificates) < 1 {
+		return nil, errors.New("tls server requires cert_file and key_file")
+	}
+	clientAuth, err := opts.clientAuthType()
+	if err != nil {
+		return nil, err
+	}
+	clientCAs, err := opts.certPool()
+	if err != nil {
+		return nil, err
+	}
+	verifiesClients := clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert
+	if len(opts.AllowedIdentities) > 0 && !verifiesClients {
+		return nil, errors.New("allowed_identities requires a client_auth mode that verifies certificates")
+	}
+	config := &tls.Config{
+		Certificates: certificates,
+		ClientAuth:   clientAuth,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(opts.AllowedIdentities) > 0 {
+		allowed := make(map[string]bool, len(opts.AllowedIdentities))
+		for _, identity := range opts.AllowedIdentities {
+			allowed[identity] = true
+		}
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.VerifiedChains) < 1 {
+				return errors.New("client certificate required")
+			}
+			for _, identity := range certificateIdentities(state.PeerCertificates[0]) {
+				if allowed[identity] {
+					return nil
+				}
+			}
+			return fmt.Errorf("client identity %s not allowed", PeerIdentity(state))
+		}
+	}
+	return config, nil
+}
+
+func (opts OptsTls) ClientConfig() (*tls.Config, error) {
+	certificates, err := opts.certificates()
+	if err != nil {
+		return nil, err
+	}
+	rootCAs, err := opts.certPool()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates:       certificates,
+		RootCAs:            rootCAs,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}, nil
+}
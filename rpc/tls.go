@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
+)
+
+// listen on tcp, wrapped in tls if enabled
+func Listen(address string, opts OptsTls) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Enabled {
+		return listener, nil
+	}
+	config, err := opts.ServerConfig()
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return tls.NewListener(listener, config), nil
+}
+
+// dial over tcp, wrapped in tls if enabled
+func Dial(address string, timeout time.Duration, opts OptsTls) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !opts.Enabled {
+		return dialer.Dial("tcp", address)
+	}
+	config, err := opts.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return tls.DialWithDialer(dialer, "tcp", address, config)
+}
+
+// complete the tls handshake (if any) and return the identity of the verified client certificate
+// plain connections and connections without verified client certificate have an empty identity
+func HandshakePeerIdentity(conn net.Conn, timeout time.Duration) (string, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", nil
+	}
+	if timeout > 0 {
+		if err := tlsConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return "", err
+		}
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	if err := tlsConn.SetDeadline(time.Time{}); err != nil {
+		return "", err
+	}
+	state := tlsConn.ConnectionState()
+	if len(state.VerifiedChains) < 1 {
+		return "", nil
+	}
+	return PeerIdentity(state), nil
+}
+
+// the identity of a peer is the common name of its certificate, or the first subject alternative name if not set
+func PeerIdentity(state tls.ConnectionState) string {
+	if len(state.PeerCertificates) < 1 {
+		return ""
+	}
+	identities := certificateIdentities(state.PeerCertificates[0])
+	if len(identities) < 1 {
+		return ""
+	}
+	return identities[0]
+}
+
+func certificateIdentities(cert *x509.Certificate) []string {
+	identities := make([]string, 0)
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
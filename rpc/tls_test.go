@@ -0,0 +1,160 @@
+package rpc_test
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/tlstest"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+type testCertificates struct {
+	dir    string
+	client string
+}
+
+func newTestCertificates(t *testing.T, clientCommonName string) *testCertificates {
+	dir, err := ioutil.TempDir("", "tls_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tlstest.WriteCertificates(dir, clientCommonName); err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificates{dir: dir, client: clientCommonName}
+}
+
+func (certs *testCertificates) Close() {
+	_ = os.RemoveAll(certs.dir)
+}
+
+func (certs *testCertificates) serverOpts() rpc.OptsTls {
+	return tlstest.ServerOpts(certs.dir)
+}
+
+func (certs *testCertificates) clientOpts() rpc.OptsTls {
+	return tlstest.ClientOpts(certs.dir, certs.client)
+}
+
+// accept a single connection and report the peer identity (or handshake error)
+func acceptOne(listener net.Listener) chan interface{} {
+	result := make(chan interface{}, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			result <- err
+			return
+		}
+		identity, err := rpc.HandshakePeerIdentity(conn, time.Second)
+		if err != nil {
+			result <- err
+		} else {
+			result <- identity
+		}
+		_ = conn.Close()
+	}()
+	return result
+}
+
+func TestMutualTls(t *testing.T) {
+	certs := newTestCertificates(t, "writer")
+	defer certs.Close()
+
+	listener, err := rpc.Listen("127.0.0.1:0", certs.serverOpts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	result := acceptOne(listener)
+
+	conn, err := rpc.Dial(listener.Addr().String(), time.Second, certs.clientOpts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if identity := <-result; identity != "writer" {
+		t.Error(identity)
+	}
+}
+
+func TestMutualTlsAllowedIdentities(t *testing.T) {
+	certs := newTestCertificates(t, "intruder")
+	defer certs.Close()
+
+	serverOpts := certs.serverOpts()
+	serverOpts.AllowedIdentities = []string{"writer"}
+	listener, err := rpc.Listen("127.0.0.1:0", serverOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	result := acceptOne(listener)
+
+	conn, err := rpc.Dial(listener.Addr().String(), time.Second, certs.clientOpts())
+	if err == nil {
+		// tls 1.3 reports client certificate rejections on first read
+		_, err = conn.Read(make([]byte, 1))
+		_ = conn.Close()
+	}
+	if err == nil {
+		t.Error("expected identity to be rejected")
+	}
+	if _, ok := (<-result).(error); !ok {
+		t.Error("expected handshake error on server")
+	}
+}
+
+func TestMutualTlsMissingClientCertificate(t *testing.T) {
+	certs := newTestCertificates(t, "writer")
+	defer certs.Close()
+
+	listener, err := rpc.Listen("127.0.0.1:0", certs.serverOpts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	result := acceptOne(listener)
+
+	clientOpts := certs.clientOpts()
+	clientOpts.CertFile = ""
+	clientOpts.KeyFile = ""
+	conn, err := rpc.Dial(listener.Addr().String(), time.Second, clientOpts)
+	if err == nil {
+		_, err = conn.Read(make([]byte, 1))
+		_ = conn.Close()
+	}
+	if err == nil {
+		t.Error("expected missing client certificate to be rejected")
+	}
+	if _, ok := (<-result).(error); !ok {
+		t.Error("expected handshake error on server")
+	}
+}
+
+func TestOptsTlsInvalid(t *testing.T) {
+	if _, err := (rpc.OptsTls{Enabled: true}).ServerConfig(); err == nil {
+		t.Error("server requires key pair")
+	}
+	certs := newTestCertificates(t, "writer")
+	defer certs.Close()
+	opts := certs.serverOpts()
+	opts.ClientAuth = "bogus"
+	if _, err := opts.ServerConfig(); err == nil {
+		t.Error("invalid client auth mode")
+	}
+	opts.ClientAuth = rpc.ClientAuthRequest
+	opts.AllowedIdentities = []string{"writer"}
+	if _, err := opts.ServerConfig(); err == nil {
+		t.Error("allowed identities require verified client certificates")
+	}
+}
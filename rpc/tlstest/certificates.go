@@ -0,0 +1,105 @@
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+const CaFile = "ca.pem"
+const ServerCommonName = "server"
+
+// writes a CA (ca.pem), a server certificate for 127.0.0.1 (server.pem, server.key) and a client certificate per common name (<name>.pem, <name>.key) into dir
+func WriteCertificates(dir string, clientCommonNames ...string) error {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tsxdb test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	if err := writePem(filepath.Join(dir, CaFile), "CERTIFICATE", caDer); err != nil {
+		return err
+	}
+
+	// leaves
+	issue := func(serial int64, commonName string, usage x509.ExtKeyUsage) error {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return err
+		}
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: commonName},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}, ca, &key.PublicKey, caKey)
+		if err != nil {
+			return err
+		}
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return err
+		}
+		if err := writePem(filepath.Join(dir, commonName+".pem"), "CERTIFICATE", der); err != nil {
+			return err
+		}
+		return writePem(filepath.Join(dir, commonName+".key"), "EC PRIVATE KEY", keyDer)
+	}
+	if err := issue(2, ServerCommonName, x509.ExtKeyUsageServerAuth); err != nil {
+		return err
+	}
+	for i, commonName := range clientCommonNames {
+		if err := issue(int64(3+i), commonName, x509.ExtKeyUsageClientAuth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePem(path string, blockType string, b []byte) error {
+	return ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: b}), 0600)
+}
+
+// mutual tls listener with the certificates of dir
+func ServerOpts(dir string) rpc.OptsTls {
+	return rpc.OptsTls{
+		Enabled:    true,
+		CertFile:   filepath.Join(dir, ServerCommonName+".pem"),
+		KeyFile:    filepath.Join(dir, ServerCommonName+".key"),
+		CaFile:     filepath.Join(dir, CaFile),
+		ClientAuth: rpc.ClientAuthRequireAndVerify,
+	}
+}
+
+// client with the certificate of common name from dir
+func ClientOpts(dir string, commonName string) rpc.OptsTls {
+	return rpc.OptsTls{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, commonName+".pem"),
+		KeyFile:  filepath.Join(dir, commonName+".key"),
+		CaFile:   filepath.Join(dir, CaFile),
+	}
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation name of all tsxdb spans
+const TracerName = "github.com/RobinUS2/tsxdb"
+
+// always w3c trace context, independent of the globally configured propagator
+var tracePropagator = propagation.TraceContext{}
+
+// tracer of the provider, a no-op tracer if tracing is not configured
+func Tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = trace.NewNoopTracerProvider()
+	}
+	return provider.Tracer(TracerName)
+}
+
+// store the span context of ctx in the request, so the server can continue the trace
+func InjectTraceContext(ctx context.Context, traceContext *types.TraceContext) {
+	tracePropagator.Inject(ctx, traceContext)
+}
+
+// context with the remote span context of the request (if any) as parent
+func ExtractTraceContext(ctx context.Context, traceContext *types.TraceContext) context.Context {
+	return tracePropagator.Extract(ctx, traceContext)
+}
+
+// end the span, marking it failed on error
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
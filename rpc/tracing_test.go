@@ -0,0 +1,32 @@
+package rpc_test
+
+import (
+	"context"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestTraceContext(t *testing.T) {
+	// nothing to propagate without a span
+	traceContext := types.TraceContext{}
+	rpc.InjectTraceContext(context.Background(), &traceContext)
+	if traceContext.TraceParent != "" {
+		t.Error(traceContext.TraceParent)
+	}
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	rpc.InjectTraceContext(trace.ContextWithSpanContext(context.Background(), spanContext), &traceContext)
+	if traceContext.TraceParent != "00-01020300000000000000000000000000-0405060000000000-01" {
+		t.Error(traceContext.TraceParent)
+	}
+	extracted := trace.SpanContextFromContext(rpc.ExtractTraceContext(context.Background(), &traceContext))
+	if !extracted.IsRemote() || extracted.TraceID() != spanContext.TraceID() || extracted.SpanID() != spanContext.SpanID() {
+		t.Errorf("%+v", extracted)
+	}
+}
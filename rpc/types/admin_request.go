@@ -0,0 +1,110 @@
+package types
+
+type AdminCommand string
+
+const AdminCommandNamespaces AdminCommand = "namespaces"   // namespaces that contain series
+const AdminCommandSeries AdminCommand = "series"           // series of a namespace, optionally filtered by name
+const AdminCommandDescribe AdminCommand = "describe"       // metadata and stored points of a series
+const AdminCommandDelete AdminCommand = "delete"           // remove a series and its data
+const AdminCommandStats AdminCommand = "stats"             // server statistics
+const AdminCommandSessions AdminCommand = "sessions"       // active sessions
+const AdminCommandConnections AdminCommand = "connections" // open rpc connections
+const AdminCommandKick AdminCommand = "kick"               // end a session, the client has to authenticate again
+const AdminCommandFlush AdminCommand = "flush"             // flush pending writes of all backends
+const AdminCommandCompact AdminCommand = "compact"         // remove data of deleted and expired series
+const AdminCommandSnapshot AdminCommand = "snapshot"       // write all metadata and data to an archive file on the server
+const AdminCommandRestore AdminCommand = "restore"         // add all series of an archive file on the server
+const AdminCommandAlerts AdminCommand = "alerts"           // state of the alert rules per series
+const AdminCommandCardinality AdminCommand = "cardinality" // series and label values of a namespace, by label, day and creator
+
+type AdminRequest struct {
+	SessionTicket
+	Command   AdminCommand
+	Namespace int
+	Series    uint64 // describe, delete
+	Name      string // series: part of the name, empty for all
+	Session   int    // kick
+	Path      string // snapshot, restore: archive file name in the snapshot directory of the server
+	Limit     int    // cardinality: top label keys and creators, 0 for all
+}
+
+type AdminResponse struct {
+	Error       *RpcError
+	Namespaces  []int
+	Series      []AdminSeries
+	Stats       map[string]uint64
+	Sessions    []AdminSession
+	Connections []string // remote addresses
+	Affected    int      // series deleted, compacted, snapshotted or restored, sessions kicked
+	Points      int      // points snapshotted or restored
+	Alerts      []AdminAlert
+	Cardinality *AdminCardinality
+}
+
+type AdminSeries struct {
+	SeriesIdentifier
+	Name      string
+	Tags      []string
+	Labels    map[string]string
+	TtlExpire uint64 // unix timestamp in seconds, 0 without ttl
+	ValueType ValueType
+	Enum      []string
+	Fields    []string
+	Created   uint64 // unix timestamp in seconds, 0 if unknown
+	// describe only
+	Points int
+	From   uint64
+	To     uint64
+}
+
+type AdminAlert struct {
+	Rule string
+	SeriesIdentifier
+	Name  string
+	State string  // pending or firing
+	Value float64 // aggregate of the latest evaluation
+	Since int64   // unix timestamp in seconds since the condition holds
+}
+
+type AdminCardinality struct {
+	Series   int
+	Labels   []AdminCardinalityCount   // label keys by distinct values, most first
+	Days     []AdminCardinalityCount   // series created per day (yyyy-mm-dd, utc), oldest first
+	Creators []AdminCreatorCardinality // series created per session, most first
+}
+
+type AdminCardinalityCount struct {
+	Key   string
+	Count int
+}
+
+type AdminCreatorCardinality struct {
+	User    string // empty for the shared auth token
+	Session int
+	Series  int
+}
+
+type AdminSession struct {
+	Id       int
+	User     string // empty for the shared auth token
+	Identity string // tls client certificate identity
+	Expires  int64  // unix timestamp in seconds
+}
+
+func (response AdminResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request AdminRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putString(string(request.Command))
+	payload.putInt(request.Namespace)
+	payload.putUint64(request.Series)
+	payload.putString(request.Name)
+	payload.putInt(request.Session)
+	payload.putString(request.Path)
+	payload.putInt(request.Limit)
+	return payload.Bytes()
+}
+
+var EndpointAdmin = Endpoint("Admin")
@@ -1,15 +1,31 @@
 package types
 
+// version of the auth handshake and session tickets, bumped on incompatible changes
+// 2: derived session secrets, monotonic nonces and full length request signatures
+const AuthVersion = 2
+
 type AuthRequest struct {
-	SessionTicket // this will be empty 1st request, 2nd request of the auth handshake it will validate
+	SessionTicket        // this will be empty 1st request, 2nd request of the auth handshake it will validate
+	Version       int    // AuthVersion of the client, older clients do not send this
+	User          string // empty for the shared auth token
 	Nonce         string
 	Signature     string
 }
 
 type AuthResponse struct {
-	Error         *RpcError
-	SessionId     int
-	SessionSecret string
+	Error       *RpcError
+	SessionId   int
+	ServerNonce string // the session secret is derived from the auth token and both nonces, it is never transmitted
+}
+
+func (response AuthResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request AuthRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putString(request.User)
+	return payload.Bytes()
 }
 
 var EndpointAuth = Endpoint("Auth")
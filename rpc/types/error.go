@@ -18,6 +18,22 @@ var RpcErrorSeriesNameWhitespace RpcError = "series name can not contain whitesp
 var RpcErrorNoDataFound RpcError = "no data found"
 var RpcErrorSeriesExpired RpcError = "series expired"
 var RpcErrorSeriesInitNoId RpcError = "series init no id"
+var RpcErrorPermissionDenied RpcError = "permission denied"
+var RpcErrorAuthVersion RpcError = "unsupported auth version, upgrade the client"
+var RpcErrorReplayedNonce RpcError = "replayed or expired session nonce"
+var RpcErrorSessionExpired RpcError = "session expired"
+var RpcErrorRateLimited RpcError = "rate limited" // retry later
+var RpcErrorQuotaExceeded RpcError = "quota exceeded"
+var RpcErrorCardinalityExceeded RpcError = "cardinality limit exceeded"
+var RpcErrorSubscriptionNotFound RpcError = "subscription not found"
+var RpcErrorSubscriptionSlowConsumer RpcError = "subscription closed, client too slow"
+var RpcErrorValueTypeMismatch RpcError = "value type mismatch"
+var RpcErrorInvalidValueType RpcError = "invalid value type"
+var RpcErrorSeriesNameExists RpcError = "series name already exists"
+var RpcErrorSeriesNotFound RpcError = "series not found"
+var RpcErrorInvalidLabel RpcError = "invalid label"
+var RpcErrorInvalidLabelMatcher RpcError = "invalid label matcher"
+var RpcErrorInvalidNameMatcher RpcError = "invalid name matcher"
 
 func (err RpcError) String() string {
 	return string(err)
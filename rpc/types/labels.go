@@ -0,0 +1,118 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type MatchType string
+
+const MatchEqual MatchType = "="
+const MatchNotEqual MatchType = "!="
+const MatchRegexp MatchType = "=~"    // fully anchored
+const MatchNotRegexp MatchType = "!~" // fully anchored
+
+// selects series by the value of a label, series without the label have an empty value
+type LabelMatcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+}
+
+func (matcher LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", matcher.Name, matcher.Type, matcher.Value)
+}
+
+// function that matches label values, an error for an unknown type or invalid regex
+func (matcher LabelMatcher) Compile() (func(value string) bool, error) {
+	if len(matcher.Name) < 1 {
+		return nil, fmt.Errorf("%s: missing label name", RpcErrorInvalidLabelMatcher)
+	}
+	switch matcher.Type {
+	case MatchEqual:
+		return func(value string) bool {
+			return value == matcher.Value
+		}, nil
+	case MatchNotEqual:
+		return func(value string) bool {
+			return value != matcher.Value
+		}, nil
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + matcher.Value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", RpcErrorInvalidLabelMatcher, err)
+		}
+		negate := matcher.Type == MatchNotRegexp
+		return func(value string) bool {
+			return re.MatchString(value) != negate
+		}, nil
+	}
+	return nil, fmt.Errorf("%s: unknown match type %s", RpcErrorInvalidLabelMatcher, matcher.Type)
+}
+
+// label names are non-empty without whitespace or =, values are non-empty
+func ValidateLabels(labels map[string]string) error {
+	for name, value := range labels {
+		if len(name) < 1 || strings.ContainsAny(name, " =") {
+			return fmt.Errorf("%s: invalid label name %q", RpcErrorInvalidLabel, name)
+		}
+		if len(value) < 1 {
+			return fmt.Errorf("%s: empty value of label %s", RpcErrorInvalidLabel, name)
+		}
+	}
+	return nil
+}
+
+// label names in order, maps have no fixed order
+func SortedLabelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (payload *signaturePayload) putLabels(labels map[string]string) {
+	payload.putInt(len(labels))
+	for _, name := range SortedLabelNames(labels) {
+		payload.putString(name)
+		payload.putString(labels[name])
+	}
+}
+
+func (payload *signaturePayload) putLabelMatchers(matchers []LabelMatcher) {
+	payload.putInt(len(matchers))
+	for _, matcher := range matchers {
+		payload.putString(matcher.Name)
+		payload.putString(string(matcher.Type))
+		payload.putString(matcher.Value)
+	}
+}
+
+// label names of a namespace, or the values of one label
+type LabelsRequest struct {
+	SessionTicket
+	Namespace int
+	Name      string // values of this label, empty for the label names
+}
+
+type LabelsResponse struct {
+	Error  *RpcError
+	Values []string // sorted
+}
+
+func (response LabelsResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request LabelsRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(request.Namespace)
+	payload.putString(request.Name)
+	return payload.Bytes()
+}
+
+var EndpointLabels = Endpoint("SeriesLabels")
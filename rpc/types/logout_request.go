@@ -0,0 +1,19 @@
+package types
+
+type LogoutRequest struct {
+	SessionTicket
+}
+
+type LogoutResponse struct {
+	Error *RpcError
+}
+
+func (response LogoutResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request LogoutRequest) SignaturePayload() []byte {
+	return nil
+}
+
+var EndpointLogout = Endpoint("Logout")
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type NameMatchType string
+
+const NameMatchExact NameMatchType = ""
+const NameMatchPrefix NameMatchType = "prefix"
+const NameMatchGlob NameMatchType = "glob"    // graphite style: * and ? within a path segment, [abc] and {a,b}
+const NameMatchRegexp NameMatchType = "regex" // fully anchored
+
+// selects series by their name
+type NameMatcher struct {
+	Type    NameMatchType
+	Pattern string
+}
+
+// function that matches names and the prefix all matching names share, an error for an unknown type or invalid pattern
+func (matcher NameMatcher) Compile() (match func(name string) bool, prefix string, err error) {
+	switch matcher.Type {
+	case NameMatchExact:
+		return func(name string) bool {
+			return name == matcher.Pattern
+		}, matcher.Pattern, nil
+	case NameMatchPrefix:
+		return func(name string) bool {
+			return strings.HasPrefix(name, matcher.Pattern)
+		}, matcher.Pattern, nil
+	case NameMatchGlob, NameMatchRegexp:
+		expr := matcher.Pattern
+		if matcher.Type == NameMatchGlob {
+			if expr, err = globToRegexp(matcher.Pattern); err != nil {
+				return nil, "", err
+			}
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %s", RpcErrorInvalidNameMatcher, err)
+		}
+		prefix, _ = re.LiteralPrefix()
+		return re.MatchString, prefix, nil
+	}
+	return nil, "", fmt.Errorf("%s: unknown match type %s", RpcErrorInvalidNameMatcher, matcher.Type)
+}
+
+func globToRegexp(glob string) (string, error) {
+	var expr strings.Builder
+	alternatives := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*':
+			expr.WriteString(`[^.]*`)
+		case c == '?':
+			expr.WriteString(`[^.]`)
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("%s: unclosed [ in %s", RpcErrorInvalidNameMatcher, glob)
+			}
+			expr.WriteString(glob[i : i+end+1])
+			i += end
+		case c == '{' && !alternatives:
+			alternatives = true
+			expr.WriteString(`(?:`)
+		case c == '}' && alternatives:
+			alternatives = false
+			expr.WriteString(`)`)
+		case c == ',' && alternatives:
+			expr.WriteString(`|`)
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if alternatives {
+		return "", fmt.Errorf("%s: unclosed { in %s", RpcErrorInvalidNameMatcher, glob)
+	}
+	return expr.String(), nil
+}
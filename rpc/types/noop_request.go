@@ -8,4 +8,12 @@ type NoOpResponse struct {
 	Error *RpcError
 }
 
+func (response NoOpResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request NoOpRequest) SignaturePayload() []byte {
+	return nil
+}
+
 var EndpointNoOp = Endpoint("NoOp")
@@ -2,18 +2,55 @@ package types
 
 type ReadRequest struct {
 	SessionTicket
+	TraceContext
 	Queries []ReadSeriesRequest
 }
 
 type ReadSeriesRequest struct {
-	From uint64
-	To   uint64
+	From         uint64
+	To           uint64
+	Limit        int      // if set the points are returned in pages of at most this many points in ReadResponse.Pages instead of Results
+	Continuation uint64   // token of the previous page, 0 for the first page
+	Fields       []string // selected fields of a fields series in this order, empty for all
 	SeriesIdentifier
 }
 
 type ReadResponse struct {
-	Error   *RpcError
-	Results map[uint64]map[uint64]float64 // map series id => timestamp => value
+	Error        *RpcError
+	Results      map[uint64]map[uint64]float64 // map series id => timestamp => value
+	TypedResults map[uint64]map[uint64]Value   // same as results, for series that are not float
+	ValueTypes   map[uint64]ValueType          // map series id => value type, for the typed results and pages
+	Fields       map[uint64][]string           // map series id => names of the values of fields series
+	Pages        map[uint64]ReadPage           // map series id => page, for queries with a limit
+}
+
+type ReadPage struct {
+	Times        []uint64 // sorted
+	Values       []float64
+	TypedValues  []Value  // instead of values for series that are not float
+	Fields       []string // names of the typed values of a fields series
+	Continuation uint64   // token to read the next page with, 0 if this is the last page
+}
+
+func (response ReadResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request ReadRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(len(request.Queries))
+	for _, query := range request.Queries {
+		payload.putUint64(query.From)
+		payload.putUint64(query.To)
+		payload.putInt(query.Limit)
+		payload.putUint64(query.Continuation)
+		payload.putInt(len(query.Fields))
+		for _, field := range query.Fields {
+			payload.putString(field)
+		}
+		payload.putSeriesIdentifier(query.SeriesIdentifier)
+	}
+	return payload.Bytes()
 }
 
 var EndpointReader = Endpoint("Reader")
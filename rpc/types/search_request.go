@@ -0,0 +1,43 @@
+package types
+
+type SearchRequest struct {
+	SessionTicket
+	Namespace int
+	Name      string        // exact name or pattern, empty for any
+	NameMatch NameMatchType // how the name is matched, exact by default
+	Tag       string        // series with this tag, empty for any (name, tag or matchers are required)
+	Matchers  []LabelMatcher
+	After     string // page after this name, the Next of the previous page
+	Limit     int    // series per page, 0 for all
+}
+
+type SearchResponse struct {
+	Error  *RpcError
+	Series []SearchResult // ordered by name
+	Next   string         // After of the next page, empty for the last page
+}
+
+type SearchResult struct {
+	Id     uint64
+	Name   string
+	Tags   []string
+	Labels map[string]string
+}
+
+func (response SearchResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request SearchRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(request.Namespace)
+	payload.putString(request.Name)
+	payload.putString(request.Tag)
+	payload.putLabelMatchers(request.Matchers)
+	payload.putString(string(request.NameMatch))
+	payload.putString(request.After)
+	payload.putInt(request.Limit)
+	return payload.Bytes()
+}
+
+var EndpointSearch = Endpoint("SeriesSearch")
@@ -5,6 +5,23 @@ type SeriesMetadata struct {
 	Name      string
 	Tags      []string
 	Ttl       uint // relative time in seconds
+	ValueType ValueType
+	Enum      []string // allowed values of a string series, empty allows all
+	Fields    []string // names of the values of a fields series
+	Labels    map[string]string
+}
+
+func (metadata SeriesMetadata) putSignaturePayload(payload *signaturePayload) {
+	payload.putString(string(metadata.ValueType))
+	payload.putInt(len(metadata.Enum))
+	for _, v := range metadata.Enum {
+		payload.putString(v)
+	}
+	payload.putInt(len(metadata.Fields))
+	for _, field := range metadata.Fields {
+		payload.putString(field)
+	}
+	payload.putLabels(metadata.Labels)
 }
 
 type SeriesCreateMetadata struct {
@@ -13,16 +30,36 @@ type SeriesCreateMetadata struct {
 }
 
 type SeriesMetadataRequest struct {
-	// @todo support multiple series at once, will increase performance of first flushes a lot (e.g. batch size of 1000 will have 1000 round trips over TCP, have seen easily 30 seconds for that)
+	// see SeriesMetadataBatchRequest to init many series in one round trip
 	SeriesCreateMetadata
 	SessionTicket
+	TraceContext
 }
 
 type SeriesMetadataResponse struct {
 	Id uint64
 	SeriesCreateIdentifier
-	Error *RpcError
-	New   bool
+	Error   *RpcError
+	New     bool
+	Changed bool // existing series of which the tags or ttl were updated to the requested ones
+}
+
+func (response SeriesMetadataResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request SeriesMetadataRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(request.Namespace)
+	payload.putString(request.Name)
+	payload.putInt(len(request.Tags))
+	for _, tag := range request.Tags {
+		payload.putString(tag)
+	}
+	payload.putUint64(uint64(request.Ttl))
+	request.SeriesMetadata.putSignaturePayload(payload)
+	payload.putUint64(uint64(request.SeriesCreateIdentifier))
+	return payload.Bytes()
 }
 
 type SeriesCreateIdentifier uint64 // xxhash64 of uuid bytes
@@ -0,0 +1,38 @@
+package types
+
+// metadata of many series in one round trip, e.g. first flushes of large batches or imports
+type SeriesMetadataBatchRequest struct {
+	Series []SeriesCreateMetadata
+	SessionTicket
+	TraceContext
+}
+
+type SeriesMetadataBatchResponse struct {
+	Error  *RpcError                // whole request failed
+	Series []SeriesMetadataResponse // same order as the request, each with its own error
+}
+
+const MaxSeriesMetadataBatchSize = 10000
+
+func (response SeriesMetadataBatchResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request SeriesMetadataBatchRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(len(request.Series))
+	for _, series := range request.Series {
+		payload.putInt(series.Namespace)
+		payload.putString(series.Name)
+		payload.putInt(len(series.Tags))
+		for _, tag := range series.Tags {
+			payload.putString(tag)
+		}
+		payload.putUint64(uint64(series.Ttl))
+		series.SeriesMetadata.putSignaturePayload(payload)
+		payload.putUint64(uint64(series.SeriesCreateIdentifier))
+	}
+	return payload.Bytes()
+}
+
+var EndpointSeriesMetadataBatch = Endpoint("SeriesCreateMetadataBatch")
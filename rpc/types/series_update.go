@@ -0,0 +1,57 @@
+package types
+
+// changes the metadata of an existing series
+type SeriesUpdateRequest struct {
+	SessionTicket
+	Namespace    int
+	Id           uint64
+	AddTags      []string
+	RemoveTags   []string
+	SetTtl       bool              // change the ttl to Ttl, 0 removes the ttl
+	Ttl          uint              // relative time in seconds
+	Rename       string            // new name, empty to keep the name
+	SetLabels    map[string]string // added or changed labels
+	RemoveLabels []string
+}
+
+type SeriesUpdateResponse struct {
+	Error   *RpcError
+	Changed bool // false if the series already had this metadata
+	Name    string
+	Tags    []string
+	Labels  map[string]string
+	Ttl     uint
+}
+
+func (response SeriesUpdateResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request SeriesUpdateRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(request.Namespace)
+	payload.putUint64(request.Id)
+	payload.putInt(len(request.AddTags))
+	for _, tag := range request.AddTags {
+		payload.putString(tag)
+	}
+	payload.putInt(len(request.RemoveTags))
+	for _, tag := range request.RemoveTags {
+		payload.putString(tag)
+	}
+	if request.SetTtl {
+		payload.putInt(1)
+	} else {
+		payload.putInt(0)
+	}
+	payload.putUint64(uint64(request.Ttl))
+	payload.putString(request.Rename)
+	payload.putLabels(request.SetLabels)
+	payload.putInt(len(request.RemoveLabels))
+	for _, name := range request.RemoveLabels {
+		payload.putString(name)
+	}
+	return payload.Bytes()
+}
+
+var EndpointSeriesUpdate = Endpoint("SeriesUpdateMetadata")
@@ -1,7 +1,43 @@
 package types
 
 type SessionTicket struct {
-	Id        int // this identifies the session
-	Nonce     int // randomly generated by client
-	Signature int // murmur hash truncated to int of the nonce with the secure session token
+	Id        int    // this identifies the session
+	Nonce     uint64 // increasing per request within a session, each nonce is accepted once
+	Signature []byte // hmac of the session secret over session id, nonce, endpoint and request payload
+
+	peerIdentity string // verified tls client certificate identity, set by the server codec and never transmitted
+}
+
+func (ticket SessionTicket) PeerIdentity() string {
+	return ticket.peerIdentity
+}
+
+func (ticket *SessionTicket) SetPeerIdentity(identity string) {
+	ticket.peerIdentity = identity
+}
+
+// replace the ticket embedded in a request with a freshly signed one
+func (ticket *SessionTicket) SetSessionTicket(signed SessionTicket) {
+	*ticket = signed
+}
+
+// requests embedding a SessionTicket implement this
+type PeerIdentityAware interface {
+	SetPeerIdentity(identity string)
+}
+
+// requests embedding a SessionTicket implement this, the payload (excluding the ticket) is covered by the ticket signature
+type SignedRequest interface {
+	SignaturePayload() []byte
+}
+
+// pointers to requests embedding a SessionTicket implement this
+type SessionRequest interface {
+	SignedRequest
+	SetSessionTicket(signed SessionTicket)
+}
+
+// responses of endpoints requiring a session implement this
+type SessionResponse interface {
+	ResponseError() *RpcError
 }
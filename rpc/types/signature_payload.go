@@ -0,0 +1,36 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// deterministic binary encoding of request fields for signing (gob output is not guaranteed to be stable)
+type signaturePayload struct {
+	bytes.Buffer
+}
+
+func (payload *signaturePayload) putUint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	_, _ = payload.Write(b[:])
+}
+
+func (payload *signaturePayload) putInt(v int) {
+	payload.putUint64(uint64(int64(v)))
+}
+
+func (payload *signaturePayload) putFloat64(v float64) {
+	payload.putUint64(math.Float64bits(v))
+}
+
+func (payload *signaturePayload) putString(v string) {
+	payload.putUint64(uint64(len(v)))
+	_, _ = payload.WriteString(v)
+}
+
+func (payload *signaturePayload) putSeriesIdentifier(v SeriesIdentifier) {
+	payload.putInt(v.Namespace)
+	payload.putUint64(v.Id)
+}
@@ -0,0 +1,87 @@
+package types
+
+// points written after the subscription starts are buffered on the server until polled
+// series are selected by ids, exact name or tag, series created later that match the name or tag are included
+type SubscribeRequest struct {
+	SessionTicket
+	Namespace int
+	Ids       []uint64
+	Name      string
+	Tag       string
+}
+
+type SubscribeResponse struct {
+	Error        *RpcError
+	Subscription uint64
+}
+
+// waits until points are available (or MaxWait passed) and returns all buffered points
+type SubscriptionPollRequest struct {
+	SessionTicket
+	Subscription uint64
+	MaxWait      int64 // milliseconds, limited by the server
+}
+
+type SubscriptionPollResponse struct {
+	Error   *RpcError // e.g. the subscription was closed since the client was too slow
+	Points  []SubscriptionPoint
+	Dropped uint64 // points dropped since the previous poll since the buffer was full
+}
+
+type SubscriptionPoint struct {
+	SeriesIdentifier
+	Time      uint64
+	Value     float64
+	Typed     *Value    // set instead of value for series that are not float
+	ValueType ValueType `json:",omitempty"` // of the series, set with typed values
+}
+
+type UnsubscribeRequest struct {
+	SessionTicket
+	Subscription uint64
+}
+
+type UnsubscribeResponse struct {
+	Error *RpcError
+}
+
+func (response SubscribeResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (response SubscriptionPollResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (response UnsubscribeResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request SubscribeRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(request.Namespace)
+	payload.putInt(len(request.Ids))
+	for _, id := range request.Ids {
+		payload.putUint64(id)
+	}
+	payload.putString(request.Name)
+	payload.putString(request.Tag)
+	return payload.Bytes()
+}
+
+func (request SubscriptionPollRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putUint64(request.Subscription)
+	payload.putUint64(uint64(request.MaxWait))
+	return payload.Bytes()
+}
+
+func (request UnsubscribeRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putUint64(request.Subscription)
+	return payload.Bytes()
+}
+
+var EndpointSubscribe = Endpoint("Subscribe")
+var EndpointSubscriptionPoll = Endpoint("SubscriptionPoll")
+var EndpointUnsubscribe = Endpoint("Unsubscribe")
@@ -0,0 +1,35 @@
+package types
+
+// w3c trace context of the caller, propagated next to the session ticket, empty without tracing
+// not covered by the request signature, it only links spans and carries no authority
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+}
+
+const traceParentHeader = "traceparent"
+const traceStateHeader = "tracestate"
+
+// text map carrier, used by the trace propagator
+func (traceContext *TraceContext) Get(key string) string {
+	switch key {
+	case traceParentHeader:
+		return traceContext.TraceParent
+	case traceStateHeader:
+		return traceContext.TraceState
+	}
+	return ""
+}
+
+func (traceContext *TraceContext) Set(key string, value string) {
+	switch key {
+	case traceParentHeader:
+		traceContext.TraceParent = value
+	case traceStateHeader:
+		traceContext.TraceState = value
+	}
+}
+
+func (traceContext *TraceContext) Keys() []string {
+	return []string{traceParentHeader, traceStateHeader}
+}
@@ -0,0 +1,259 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// value type of a series, float series use the float64 Values / Results of the requests
+// all other types use the TypedValues / TypedResults
+type ValueType string
+
+const ValueTypeFloat ValueType = "" // default
+const ValueTypeInt ValueType = "int"
+const ValueTypeBool ValueType = "bool"
+const ValueTypeString ValueType = "string" // optionally limited to the enum of the series
+const ValueTypeHistogram ValueType = "histogram"
+const ValueTypeFields ValueType = "fields" // multiple float values per timestamp, named by the fields of the series
+
+var ValueTypes = []ValueType{ValueTypeFloat, ValueTypeInt, ValueTypeBool, ValueTypeString, ValueTypeHistogram, ValueTypeFields}
+
+func (valueType ValueType) String() string {
+	if valueType == ValueTypeFloat {
+		return "float"
+	}
+	return string(valueType)
+}
+
+func (valueType ValueType) Valid() bool {
+	for _, t := range ValueTypes {
+		if t == valueType {
+			return true
+		}
+	}
+	return false
+}
+
+func (valueType ValueType) Typed() bool {
+	return valueType != ValueTypeFloat
+}
+
+// numeric types can be aggregated as float64
+func (valueType ValueType) Numeric() bool {
+	return valueType == ValueTypeFloat || valueType == ValueTypeInt || valueType == ValueTypeBool
+}
+
+// the value of a point of a typed series, only the field of the type of the series is set
+type Value struct {
+	Int       int64      `json:",omitempty"`
+	Bool      bool       `json:",omitempty"`
+	String    string     `json:",omitempty"`
+	Histogram *Histogram `json:",omitempty"`
+	Fields    []float64  `json:",omitempty"` // in the order of the fields of the series
+}
+
+func IntValue(v int64) Value {
+	return Value{Int: v}
+}
+
+func BoolValue(v bool) Value {
+	return Value{Bool: v}
+}
+
+func StringValue(v string) Value {
+	return Value{String: v}
+}
+
+func HistogramValue(v *Histogram) Value {
+	return Value{Histogram: v}
+}
+
+func FieldsValue(v ...float64) Value {
+	return Value{Fields: v}
+}
+
+// value of a numeric type as float64 (large ints lose precision)
+func (value Value) Float64(valueType ValueType) (float64, error) {
+	switch valueType {
+	case ValueTypeInt:
+		return float64(value.Int), nil
+	case ValueTypeBool:
+		if value.Bool {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%s values can not be converted to float", valueType)
+}
+
+// human readable value, the type is derived from the set field if the value type is not known (e.g. points of subscriptions)
+func (value Value) Format(valueType ValueType) string {
+	if !valueType.Typed() {
+		switch {
+		case value.Histogram != nil:
+			valueType = ValueTypeHistogram
+		case value.Fields != nil:
+			valueType = ValueTypeFields
+		case len(value.String) > 0:
+			valueType = ValueTypeString
+		case value.Bool:
+			valueType = ValueTypeBool
+		default:
+			valueType = ValueTypeInt
+		}
+	}
+	switch valueType {
+	case ValueTypeBool:
+		return strconv.FormatBool(value.Bool)
+	case ValueTypeString:
+		return value.String
+	case ValueTypeHistogram:
+		if value.Histogram == nil {
+			return ""
+		}
+		return fmt.Sprintf("%v %v sum=%v", value.Histogram.Bounds, value.Histogram.Counts, value.Histogram.Sum)
+	case ValueTypeFields:
+		return fmt.Sprintf("%v", value.Fields)
+	}
+	return strconv.FormatInt(value.Int, 10)
+}
+
+// checks the value can be written to a series of this type, with the enum and fields of the series
+func (valueType ValueType) Validate(value Value, enum []string, fields []string) error {
+	if !valueType.Valid() {
+		return fmt.Errorf("%s %s", RpcErrorInvalidValueType, valueType)
+	}
+	var set []string
+	if value.Int != 0 {
+		set = append(set, "int")
+	}
+	if value.Bool {
+		set = append(set, "bool")
+	}
+	if len(value.String) > 0 {
+		set = append(set, "string")
+	}
+	if value.Histogram != nil {
+		set = append(set, "histogram")
+	}
+	if value.Fields != nil {
+		set = append(set, "fields")
+	}
+	for _, field := range set {
+		if field != string(valueType) {
+			return fmt.Errorf("%s: %s value for a %s series", RpcErrorValueTypeMismatch, field, valueType)
+		}
+	}
+	switch valueType {
+	case ValueTypeFloat:
+		return fmt.Errorf("%s: typed value for a float series", RpcErrorValueTypeMismatch)
+	case ValueTypeString:
+		if len(enum) < 1 {
+			return nil
+		}
+		for _, v := range enum {
+			if v == value.String {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %q is not one of the enum values", RpcErrorValueTypeMismatch, value.String)
+	case ValueTypeHistogram:
+		if value.Histogram == nil {
+			return fmt.Errorf("%s: missing histogram", RpcErrorValueTypeMismatch)
+		}
+		return value.Histogram.Validate()
+	case ValueTypeFields:
+		if len(value.Fields) != len(fields) {
+			return fmt.Errorf("%s: %d fields for a series with %d fields", RpcErrorValueTypeMismatch, len(value.Fields), len(fields))
+		}
+	}
+	return nil
+}
+
+// field names of a series with the fields value type, unique and without whitespace
+func ValidateFields(fields []string) error {
+	if len(fields) < 1 {
+		return fmt.Errorf("%s: missing fields", RpcErrorInvalidValueType)
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if len(field) < 1 || strings.ContainsAny(field, " ,") {
+			return fmt.Errorf("%s: invalid field name %q", RpcErrorInvalidValueType, field)
+		}
+		if seen[field] {
+			return fmt.Errorf("%s: duplicate field %s", RpcErrorInvalidValueType, field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// native histogram with fixed buckets
+type Histogram struct {
+	Bounds []float64 // upper bounds (inclusive) of the buckets, ascending
+	Counts []uint64  // observations per bucket, one more than the bounds for the observations above the highest bound
+	Sum    float64   // sum of all observations
+}
+
+func NewHistogram(bounds ...float64) *Histogram {
+	return &Histogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (histogram *Histogram) Observe(v float64) {
+	histogram.Counts[sort.SearchFloat64s(histogram.Bounds, v)]++
+	histogram.Sum += v
+}
+
+// number of observations
+func (histogram *Histogram) Count() uint64 {
+	var count uint64
+	for _, c := range histogram.Counts {
+		count += c
+	}
+	return count
+}
+
+func (histogram *Histogram) Validate() error {
+	if len(histogram.Counts) != len(histogram.Bounds)+1 {
+		return fmt.Errorf("%s: histogram with %d bounds needs %d counts, got %d", RpcErrorValueTypeMismatch, len(histogram.Bounds), len(histogram.Bounds)+1, len(histogram.Counts))
+	}
+	for i, bound := range histogram.Bounds {
+		if math.IsNaN(bound) || (i > 0 && bound <= histogram.Bounds[i-1]) {
+			return fmt.Errorf("%s: histogram bounds must be ascending", RpcErrorValueTypeMismatch)
+		}
+	}
+	return nil
+}
+
+func (payload *signaturePayload) putValue(v Value) {
+	payload.putUint64(uint64(v.Int))
+	if v.Bool {
+		payload.putInt(1)
+	} else {
+		payload.putInt(0)
+	}
+	payload.putString(v.String)
+	payload.putInt(len(v.Fields))
+	for _, field := range v.Fields {
+		payload.putFloat64(field)
+	}
+	if v.Histogram == nil {
+		payload.putInt(-1)
+		return
+	}
+	payload.putInt(len(v.Histogram.Bounds))
+	for _, bound := range v.Histogram.Bounds {
+		payload.putFloat64(bound)
+	}
+	payload.putInt(len(v.Histogram.Counts))
+	for _, count := range v.Histogram.Counts {
+		payload.putUint64(count)
+	}
+	payload.putFloat64(v.Histogram.Sum)
+}
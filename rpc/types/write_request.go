@@ -2,13 +2,15 @@ package types
 
 type WriteRequest struct {
 	SessionTicket
+	TraceContext
 	Series []WriteSeriesRequest
 }
 
 type WriteSeriesRequest struct {
 	SeriesIdentifier
-	Times  []uint64
-	Values []float64
+	Times       []uint64
+	Values      []float64 // float series
+	TypedValues []Value   // series with another value type
 }
 
 type WriteResponse struct {
@@ -16,4 +18,29 @@ type WriteResponse struct {
 	Error *RpcError
 }
 
+func (response WriteResponse) ResponseError() *RpcError {
+	return response.Error
+}
+
+func (request WriteRequest) SignaturePayload() []byte {
+	payload := &signaturePayload{}
+	payload.putInt(len(request.Series))
+	for _, series := range request.Series {
+		payload.putSeriesIdentifier(series.SeriesIdentifier)
+		payload.putInt(len(series.Times))
+		for _, ts := range series.Times {
+			payload.putUint64(ts)
+		}
+		payload.putInt(len(series.Values))
+		for _, value := range series.Values {
+			payload.putFloat64(value)
+		}
+		payload.putInt(len(series.TypedValues))
+		for _, value := range series.TypedValues {
+			payload.putValue(value)
+		}
+	}
+	return payload.Bytes()
+}
+
 var EndpointWriter = Endpoint("Writer")
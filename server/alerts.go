@@ -0,0 +1,314 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/pkg/errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AlertState string
+
+const AlertStatePending AlertState = "pending" // condition holds, but not yet for the for duration
+const AlertStateFiring AlertState = "firing"
+const AlertStateResolved AlertState = "resolved" // only in notifications, the condition no longer holds
+
+const defaultAlertInterval = time.Minute
+const alertWebhookTimeout = 10 * time.Second
+
+var alertOperators = map[string]func(value float64, threshold float64) bool{
+	">":  func(value float64, threshold float64) bool { return value > threshold },
+	">=": func(value float64, threshold float64) bool { return value >= threshold },
+	"<":  func(value float64, threshold float64) bool { return value < threshold },
+	"<=": func(value float64, threshold float64) bool { return value <= threshold },
+	"==": func(value float64, threshold float64) bool { return value == threshold },
+	"!=": func(value float64, threshold float64) bool { return value != threshold },
+}
+
+// posted as json to the webhook of the rule
+type AlertNotification struct {
+	Rule      string     `json:"rule"`
+	Namespace int        `json:"namespace"`
+	Id        uint64     `json:"id"`
+	Series    string     `json:"series"`
+	State     AlertState `json:"state"` // firing or resolved
+	Value     float64    `json:"value"`
+	Operator  string     `json:"operator"`
+	Threshold float64    `json:"threshold"`
+	Time      int64      `json:"time"` // unix timestamp in milliseconds of the evaluation
+}
+
+// state of one series of a rule, series without a pending or firing alert have none
+type alert struct {
+	name  string
+	state AlertState
+	value float64
+	since time.Time // condition holds since
+}
+
+type alertRule struct {
+	opts      AlertRuleOpts
+	aggregate aggregation
+	compare   func(value float64, threshold float64) bool
+	alerts    map[uint64]*alert // series id => alert
+	alertsMux sync.RWMutex
+}
+
+type Alerting struct {
+	server  *Instance
+	rules   []*alertRule
+	tickers []*time.Ticker
+	client  *http.Client
+}
+
+func newAlertRule(opts AlertRuleOpts) (*alertRule, error) {
+	if len(opts.Name) < 1 {
+		return nil, errors.New("missing name")
+	}
+	if len(opts.Series) < 1 && len(opts.Tag) < 1 {
+		return nil, fmt.Errorf("rule %s: missing series or tag", opts.Name)
+	}
+	if len(opts.Aggregation) < 1 {
+		opts.Aggregation = "avg"
+	}
+	aggregate, found := aggregations[opts.Aggregation]
+	if !found {
+		return nil, fmt.Errorf("rule %s: unknown aggregation %s", opts.Name, opts.Aggregation)
+	}
+	compare, found := alertOperators[opts.Operator]
+	if !found {
+		return nil, fmt.Errorf("rule %s: unknown operator %s", opts.Name, opts.Operator)
+	}
+	if opts.Window <= 0 {
+		return nil, fmt.Errorf("rule %s: missing window", opts.Name)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultAlertInterval
+	}
+	if strings.Contains(opts.AlertSeries, " ") {
+		return nil, fmt.Errorf("rule %s: %s", opts.Name, types.RpcErrorSeriesNameWhitespace)
+	}
+	return &alertRule{
+		opts:      opts,
+		aggregate: aggregate,
+		compare:   compare,
+		alerts:    make(map[uint64]*alert),
+	}, nil
+}
+
+// rules of the configuration and the rule files
+func (instance *Instance) initAlerting() error {
+	rules := instance.opts.AlertRules
+	if len(instance.opts.AlertRulesPath) > 0 {
+		var file AlertRulesFile
+		if err := tools.ReadYamlFileInPath(instance.opts.AlertRulesPath, &file); err != nil {
+			return errors.Wrap(err, "failed to read alert rules")
+		}
+		rules = append(rules, file.AlertRules...)
+	}
+	alerting := &Alerting{
+		server: instance,
+		client: &http.Client{Timeout: alertWebhookTimeout},
+	}
+	names := make(map[string]bool)
+	for _, opts := range rules {
+		rule, err := newAlertRule(opts)
+		if err != nil {
+			return errors.Wrap(err, "invalid alert rule")
+		}
+		if names[opts.Name] {
+			return fmt.Errorf("invalid alert rule: duplicate name %s", opts.Name)
+		}
+		names[opts.Name] = true
+		alerting.rules = append(alerting.rules, rule)
+	}
+	instance.alerting = alerting
+	return nil
+}
+
+// evaluate every rule on its own interval
+func (alerting *Alerting) start() {
+	for _, rule := range alerting.rules {
+		rule := rule
+		alerting.tickers = append(alerting.tickers, alerting.server.every(rule.opts.Interval, "alert rule "+rule.opts.Name, func(now time.Time) error {
+			return alerting.evaluate(rule, now)
+		}))
+	}
+}
+
+func (alerting *Alerting) stop() {
+	for _, ticker := range alerting.tickers {
+		ticker.Stop()
+	}
+}
+
+// aggregate the window of every series of the rule and update the alert states
+func (alerting *Alerting) evaluate(rule *alertRule, now time.Time) error {
+	server := alerting.server
+	series, err := server.findSeries(rule.opts.Namespace, rule.opts.Series, rule.opts.Tag)
+	if err != nil {
+		return err
+	}
+	to := uint64(now.UnixNano() / int64(time.Millisecond))
+	from := to - uint64(rule.opts.Window/time.Millisecond)
+
+	notifications := make([]AlertNotification, 0)
+	notify := func(id uint64, a *alert, state AlertState) {
+		notifications = append(notifications, AlertNotification{
+			Rule:      rule.opts.Name,
+			Namespace: rule.opts.Namespace,
+			Id:        id,
+			Series:    a.name,
+			State:     state,
+			Value:     a.value,
+			Operator:  rule.opts.Operator,
+			Threshold: rule.opts.Threshold,
+			Time:      int64(to),
+		})
+	}
+
+	rule.alertsMux.Lock()
+	evaluated := make(map[uint64]bool)
+	for _, meta := range series {
+		if !meta.ValueType.Numeric() {
+			// e.g. string series with the tag of the rule
+			continue
+		}
+		id := uint64(meta.Id)
+		evaluated[id] = true
+		points, err := server.readSeries(rule.opts.Namespace, id, from, to)
+		if err != nil {
+			// the other series are still evaluated, the state of this one is kept until it can be read again
+			if logger := server.errorLogSampler.Sample(server.log, "alert rule "+rule.opts.Name); logger != nil {
+				logger.Warnf("alert rule %s: series %s: %s", rule.opts.Name, meta.Name, err)
+			}
+			continue
+		}
+
+		// without points the condition does not hold, except when counting
+		var value float64
+		var active bool
+		if len(points) > 0 || rule.opts.Aggregation == "count" {
+			value = rule.aggregate(sortedValues(points), rule.opts.Window)
+			active = rule.compare(value, rule.opts.Threshold)
+		}
+
+		a := rule.alerts[id]
+		if !active {
+			if a != nil && a.state == AlertStateFiring {
+				a.value = value
+				notify(id, a, AlertStateResolved)
+			}
+			delete(rule.alerts, id)
+			continue
+		}
+		if a == nil {
+			a = &alert{name: meta.Name, state: AlertStatePending, since: now}
+			rule.alerts[id] = a
+		}
+		a.value = value
+		if a.state == AlertStatePending && now.Sub(a.since) >= rule.opts.For {
+			a.state = AlertStateFiring
+			notify(id, a, AlertStateFiring)
+		}
+	}
+
+	// series that no longer exist
+	for id, a := range rule.alerts {
+		if evaluated[id] {
+			continue
+		}
+		if a.state == AlertStateFiring {
+			notify(id, a, AlertStateResolved)
+		}
+		delete(rule.alerts, id)
+	}
+	firing := 0
+	for _, a := range rule.alerts {
+		if a.state == AlertStateFiring {
+			firing++
+		}
+	}
+	rule.alertsMux.Unlock()
+
+	for _, notification := range notifications {
+		server.log.Infof("alert %s %s for series %s (%v %s %v)", notification.Rule, notification.State, notification.Series, notification.Value, notification.Operator, notification.Threshold)
+	}
+	if len(rule.opts.AlertSeries) > 0 {
+		if err := server.writeSeries(rule.opts.Namespace, rule.opts.AlertSeries, nil, []uint64{to}, []float64{float64(firing)}); err != nil {
+			return errors.Wrap(err, "failed to write alert series")
+		}
+	}
+	var postErr error
+	if len(rule.opts.Webhook) > 0 {
+		for _, notification := range notifications {
+			if err := alerting.post(rule.opts.Webhook, notification); err != nil {
+				postErr = errors.Wrap(err, "failed to notify webhook")
+			}
+		}
+	}
+	return postErr
+}
+
+func (alerting *Alerting) post(url string, notification AlertNotification) error {
+	b, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	resp, err := alerting.client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pending and firing alerts of all rules
+func (alerting *Alerting) alerts() []types.AdminAlert {
+	alerts := make([]types.AdminAlert, 0)
+	for _, rule := range alerting.rules {
+		rule.alertsMux.RLock()
+		for id, a := range rule.alerts {
+			alerts = append(alerts, types.AdminAlert{
+				Rule:             rule.opts.Name,
+				SeriesIdentifier: types.SeriesIdentifier{Namespace: rule.opts.Namespace, Id: id},
+				Name:             a.name,
+				State:            string(a.state),
+				Value:            a.value,
+				Since:            a.since.Unix(),
+			})
+		}
+		rule.alertsMux.RUnlock()
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+		return alerts[i].Id < alerts[j].Id
+	})
+	return alerts
+}
+
+func (alerting *Alerting) firing() int {
+	firing := 0
+	for _, rule := range alerting.rules {
+		rule.alertsMux.RLock()
+		for _, a := range rule.alerts {
+			if a.state == AlertStateFiring {
+				firing++
+			}
+		}
+		rule.alertsMux.RUnlock()
+	}
+	return firing
+}
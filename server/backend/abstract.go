@@ -1,16 +1,35 @@
 package backend
 
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/sirupsen/logrus"
+)
+
 type IAbstractBackend interface {
 	Type() TypeBackend
 	Write(context ContextWrite, timestamps []uint64, values []float64) error
+	WriteValues(context ContextWrite, timestamps []uint64, values []types.Value) error // series that are not float
 	FlushPendingWrites(requestId RequestId) error
 	Read(context ContextRead) ReadResult
 	Init() error // should be called before first usage
 	SetReverseApi(IReverseApi)
+	SetLogger(logrus.FieldLogger)
 }
 
 type AbstractBackend struct {
 	reverseApi IReverseApi
+	logger     logrus.FieldLogger
+}
+
+func (a *AbstractBackend) Logger() logrus.FieldLogger {
+	if a.logger == nil {
+		return logrus.StandardLogger()
+	}
+	return a.logger
+}
+
+func (a *AbstractBackend) SetLogger(logger logrus.FieldLogger) {
+	a.logger = logger
 }
 
 func (a *AbstractBackend) ReverseApi() IReverseApi {
@@ -0,0 +1,22 @@
+package backend
+
+// optional operations of a backend, used for administration (e.g. the tsxdb-admin command)
+type IAdminBackend interface {
+	DescribeSeries(context Context) DescribeSeriesResult // stored points of a series
+	ReadSeries(context Context) ReadResult               // all points of a series, e.g. for snapshots
+	DeleteSeriesData(context Context) error              // remove all points of a series
+	FlushAll() error                                     // flush pending writes of all requests
+	Compact() CompactResult                              // remove data of deleted and expired series
+}
+
+type DescribeSeriesResult struct {
+	Points int
+	From   uint64 // first timestamp, 0 without points
+	To     uint64 // last timestamp, 0 without points
+	Error  error
+}
+
+type CompactResult struct {
+	Removed int // series of which metadata and/or data was removed
+	Error   error
+}
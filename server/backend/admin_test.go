@@ -0,0 +1,122 @@
+package backend_test
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryBackendAdmin(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	b.SetReverseApi(b) // we implement this interface
+
+	create := b.CreateOrUpdateSeries(&backend.CreateSeries{
+		Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+			1: {SeriesMetadata: types.SeriesMetadata{Name: "kept", Namespace: 1}, SeriesCreateIdentifier: 1},
+			2: {SeriesMetadata: types.SeriesMetadata{Name: "deleted", Namespace: 2}, SeriesCreateIdentifier: 2},
+		},
+	})
+	if create.Error != nil {
+		t.Fatal(create.Error)
+	}
+	kept := backend.Context{Namespace: 1, Series: create.Results[1].Id}
+	deleted := backend.Context{Namespace: 2, Series: create.Results[2].Id}
+	for _, c := range []backend.Context{kept, deleted} {
+		if err := b.Write(backend.ContextWrite{Context: c}, []uint64{1000, 3000, 2000}, []float64{1, 3, 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// listing
+	namespaces := b.ListNamespaces()
+	if namespaces.Error != nil || len(namespaces.Namespaces) != 2 || namespaces.Namespaces[0] != 1 {
+		t.Errorf("unexpected namespaces %+v", namespaces)
+	}
+	list := b.ListSeries(&backend.ListSeries{Namespace: 1})
+	if list.Error != nil || len(list.Series) != 1 || list.Series[0].Name != "kept" {
+		t.Errorf("unexpected series %+v", list)
+	}
+	if list := b.ListSeries(&backend.ListSeries{Namespace: 1, Ids: []uint64{deleted.Series}}); list.Error == nil {
+		t.Error("expected series of other namespace not to be found")
+	}
+
+	// describe
+	described := b.DescribeSeries(kept)
+	if described.Error != nil || described.Points != 3 || described.From != 1000 || described.To != 3000 {
+		t.Errorf("unexpected describe %+v", described)
+	}
+
+	// metadata removed with its data
+	if res := b.DeleteSeries(&backend.DeleteSeries{Series: []types.SeriesIdentifier{{Namespace: 2, Id: deleted.Series}}}); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if described := b.DescribeSeries(deleted); described.Points != 0 {
+		t.Errorf("expected data removed %+v", described)
+	}
+	compacted := b.Compact()
+	if compacted.Error != nil || compacted.Removed != 0 {
+		t.Errorf("unexpected compaction %+v", compacted)
+	}
+	if described := b.DescribeSeries(kept); described.Points != 3 {
+		t.Errorf("expected data kept %+v", described)
+	}
+
+	// delete data
+	if err := b.DeleteSeriesData(kept); err != nil {
+		t.Fatal(err)
+	}
+	if described := b.DescribeSeries(kept); described.Points != 0 {
+		t.Errorf("expected data removed %+v", described)
+	}
+}
+
+func TestMemoryBackendCompactConcurrent(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	b.SetReverseApi(b)
+
+	// writes, compaction and clear take the series and data locks in the same order
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				fn(i)
+			}
+		}()
+	}
+	run(func(i int) {
+		series := make(map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata)
+		for j := 1; j <= 100; j++ {
+			series[types.SeriesCreateIdentifier(j)] = types.SeriesCreateMetadata{SeriesMetadata: types.SeriesMetadata{Name: fmt.Sprintf("series%d_%d", i, j), Namespace: 1}, SeriesCreateIdentifier: types.SeriesCreateIdentifier(j)}
+		}
+		create := b.CreateOrUpdateSeries(&backend.CreateSeries{Series: series})
+		if create.Error != nil {
+			return
+		}
+		for _, result := range create.Results {
+			_ = b.Write(backend.ContextWrite{Context: backend.Context{Namespace: 1, Series: result.Id}}, []uint64{1000}, []float64{1})
+		}
+	})
+	run(func(i int) {
+		if i%10 == 0 {
+			_ = b.Clear()
+		}
+	})
+	run(func(i int) {
+		_ = b.Compact()
+	})
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("compaction deadlocked")
+	}
+}
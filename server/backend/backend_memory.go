@@ -2,8 +2,10 @@ package backend
 
 import (
 	"errors"
+	"fmt"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"math/rand"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,14 +18,23 @@ const MemoryType = TypeBackend("memory")
 
 type MemoryBackend struct {
 	// data
-	data    map[Namespace]map[Series]map[Timestamp]float64
-	dataMux sync.RWMutex
+	data      map[Namespace]map[Series]map[Timestamp]float64
+	typedData map[Namespace]map[Series]map[Timestamp]types.Value // series that are not float
+	dataMux   sync.RWMutex
 
 	// metadata
 	seriesIdCounter uint64
 	series          map[Series]*SeriesMetadata
+	byName          map[Namespace]map[string]Series                     // name => series
+	names           map[Namespace]*sortedNames                          // sorted names of the series
+	tags            map[Namespace]map[string]map[Series]bool            // tag => series
+	labels          map[Namespace]map[string]map[string]map[Series]bool // label name => value => series
 	seriesMux       sync.RWMutex
 
+	// series with a ttl, locked after the series
+	expiry    expiryQueue
+	expiryMux sync.Mutex
+
 	AbstractBackend
 }
 
@@ -43,6 +54,11 @@ func (instance *MemoryBackend) Write(context ContextWrite, timestamps []uint64,
 
 	namespace := Namespace(context.Namespace)
 	seriesId := Series(context.Series)
+	if meta := instance.GetSeriesMeta(seriesId); meta != nil {
+		if err := meta.validateFloats(); err != nil {
+			return err
+		}
+	}
 
 	// obtain write lock
 	instance.dataMux.Lock()
@@ -71,6 +87,40 @@ func (instance *MemoryBackend) Write(context ContextWrite, timestamps []uint64,
 	return nil
 }
 
+func (instance *MemoryBackend) WriteValues(context ContextWrite, timestamps []uint64, values []types.Value) error {
+	if len(timestamps) != len(values) {
+		return errors.New("mismatch pairs")
+	}
+
+	namespace := Namespace(context.Namespace)
+	seriesId := Series(context.Series)
+	meta := instance.GetSeriesMeta(seriesId)
+	if meta == nil {
+		return types.RpcErrorBackendMetadataNotFound.Error()
+	}
+	if err := meta.validateValues(values); err != nil {
+		return err
+	}
+
+	instance.dataMux.Lock()
+	defer instance.dataMux.Unlock()
+	if _, err := instance.__notLockedInitMaps(context.Context, true); err != nil {
+		return err
+	}
+	if _, found := instance.typedData[namespace]; !found {
+		instance.typedData[namespace] = make(map[Series]map[Timestamp]types.Value)
+	}
+	if _, found := instance.typedData[namespace][seriesId]; !found {
+		instance.typedData[namespace][seriesId] = make(map[Timestamp]types.Value)
+	}
+	for idx, timestamp := range timestamps {
+		// padded like the float values
+		tsWithRand := float64(timestamp) + (rand.Float64() * maxPaddingSize)
+		instance.typedData[namespace][seriesId][Timestamp(tsWithRand)] = values[idx]
+	}
+	return nil
+}
+
 func (instance *MemoryBackend) GetSeriesMeta(s Series) *SeriesMetadata {
 	instance.seriesMux.RLock()
 	v := instance.series[s]
@@ -102,25 +152,9 @@ func (instance *MemoryBackend) __notLockedInitMaps(context Context, autoCreate b
 		return false, types.RpcErrorBackendMetadataNotFound.Error()
 	}
 
-	// ttl of series
-	if meta.TtlExpire > 0 {
-		nowSeconds := nowSeconds()
-		if meta.TtlExpire < nowSeconds {
-			// expired, remove it
-			res := instance.ReverseApi().DeleteSeries(&DeleteSeries{
-				Series: []types.SeriesIdentifier{
-					{
-						Namespace: context.Namespace,
-						Id:        context.Series,
-					},
-				},
-			})
-			if res.Error != nil {
-				// @todo deal with in other way ?
-				return false, res.Error
-			}
-			return false, nil
-		}
+	// ttl of series, expired series are deleted by the expiry queue
+	if meta.TtlExpire > 0 && meta.TtlExpire < nowSeconds() {
+		return false, nil
 	}
 
 	return true, nil
@@ -146,10 +180,19 @@ func (instance *MemoryBackend) Read(context ContextRead) (res ReadResult) {
 		instance.dataMux.RUnlock()
 		return
 	}
+	if meta := instance.GetSeriesMeta(seriesId); meta != nil && meta.ValueType.Typed() {
+		res = instance.__notLockedReadValues(context, meta)
+		instance.dataMux.RUnlock()
+		return
+	}
 	series := instance.data[namespace][seriesId]
 
 	// prune
 	var pruned map[uint64]float64
+	var page *readPage
+	if context.Limit > 0 {
+		page = newReadPage(context.Limit)
+	}
 	fromFloat := float64(context.From)
 	toFloat := float64(context.To) + maxPaddingSize // add a bit here since that's the maximum value of the padding
 	for tsF, value := range series {
@@ -157,6 +200,10 @@ func (instance *MemoryBackend) Read(context ContextRead) (res ReadResult) {
 		if ts < fromFloat || ts > toFloat {
 			continue
 		}
+		if page != nil {
+			page.add(uint64(ts), value)
+			continue
+		}
 		if pruned == nil {
 			// lazy init map, since it could be very well that we have no data
 			pruned = make(map[uint64]float64)
@@ -168,21 +215,42 @@ func (instance *MemoryBackend) Read(context ContextRead) (res ReadResult) {
 	// unlock series data
 	instance.dataMux.RUnlock()
 
+	if page != nil {
+		return page.result()
+	}
 	res.Results = pruned
 
 	return
 }
 
-func (instance *MemoryBackend) __notLockedGetSeriesByNameSpaceAndName(namespace Namespace, name string) *SeriesMetadata {
-	for _, serie := range instance.series {
-		if serie.Namespace != namespace {
+// same as the float read, for series that are not float
+func (instance *MemoryBackend) __notLockedReadValues(context ContextRead, meta *SeriesMetadata) (res ReadResult) {
+	var page *readPage
+	if context.Limit > 0 {
+		page = newReadPage(context.Limit)
+	}
+	fromFloat := float64(context.From)
+	toFloat := float64(context.To) + maxPaddingSize
+	for tsF, value := range instance.typedData[Namespace(context.Namespace)][Series(context.Series)] {
+		ts := float64(tsF)
+		if ts < fromFloat || ts > toFloat {
+			continue
+		}
+		if page != nil {
+			page.addTyped(uint64(ts), value)
 			continue
 		}
-		if serie.Name == name {
-			return serie
+		if res.TypedResults == nil {
+			res.TypedResults = make(map[uint64]types.Value)
 		}
+		res.TypedResults[uint64(ts)] = value
 	}
-	return nil
+	if page != nil {
+		res = page.result()
+	}
+	res.ValueType = meta.ValueType
+	res.Fields = meta.Fields
+	return
 }
 
 func (instance *MemoryBackend) CreateOrUpdateSeries(create *CreateSeries) (result *CreateSeriesResult) {
@@ -191,18 +259,21 @@ func (instance *MemoryBackend) CreateOrUpdateSeries(create *CreateSeries) (resul
 	}
 
 	var newSeries []types.SeriesCreateMetadata
+	var updates []identifiedUpdate
 	instance.seriesMux.RLock()
 	for _, serie := range create.Series {
 		existing := instance.__notLockedGetSeriesByNameSpaceAndName(Namespace(serie.Namespace), serie.Name)
 		if existing != nil {
-			// @todo support changes (e.g. adding tags)
-			// return existing metadata
+			// return existing metadata, updated below if the tags or ttl differ
 			result.Results[serie.SeriesCreateIdentifier] = types.SeriesMetadataResponse{
 				Id:                     uint64(existing.Id),
-				Error:                  nil,
+				Error:                  existing.validateCreate(serie),
 				SeriesCreateIdentifier: serie.SeriesCreateIdentifier,
 				New:                    false,
 			}
+			if update := existing.updateFromCreate(serie); update != nil && result.Results[serie.SeriesCreateIdentifier].Error == nil {
+				updates = append(updates, identifiedUpdate{identifier: serie.SeriesCreateIdentifier, update: update})
+			}
 			continue
 		}
 		if newSeries == nil {
@@ -220,6 +291,12 @@ func (instance *MemoryBackend) CreateOrUpdateSeries(create *CreateSeries) (resul
 			// check existing again, now with write barrier globally
 			existing := instance.__notLockedGetSeriesByNameSpaceAndName(Namespace(serie.Namespace), serie.Name)
 			if existing != nil {
+				// created concurrently or earlier in this batch
+				result.Results[serie.SeriesCreateIdentifier] = types.SeriesMetadataResponse{
+					Id:                     uint64(existing.Id),
+					Error:                  existing.validateCreate(serie),
+					SeriesCreateIdentifier: serie.SeriesCreateIdentifier,
+				}
 				continue
 			}
 
@@ -227,17 +304,22 @@ func (instance *MemoryBackend) CreateOrUpdateSeries(create *CreateSeries) (resul
 			id := atomic.AddUint64(&instance.seriesIdCounter, 1)
 
 			// add to memory
-			var ttlExpire uint64
-			if serie.Ttl > 0 {
-				ttlExpire = nowSeconds() + uint64(serie.Ttl)
-			}
-			instance.series[Series(id)] = &SeriesMetadata{
+			meta := &SeriesMetadata{
 				Namespace: Namespace(serie.Namespace),
 				Name:      serie.Name,
 				Id:        Series(id),
 				Tags:      serie.Tags,
-				TtlExpire: ttlExpire,
+				TtlExpire: ttlExpire(serie.Ttl),
+				Ttl:       serie.Ttl,
+				ValueType: serie.ValueType,
+				Enum:      serie.Enum,
+				Fields:    serie.Fields,
+				Labels:    serie.Labels,
+				Created:   create.created(),
+				Creator:   create.Creator,
 			}
+			instance.series[Series(id)] = meta
+			instance.__notLockedIndexSeries(meta)
 
 			// result
 			result.Results[serie.SeriesCreateIdentifier] = types.SeriesMetadataResponse{
@@ -250,6 +332,44 @@ func (instance *MemoryBackend) CreateOrUpdateSeries(create *CreateSeries) (resul
 		instance.seriesMux.Unlock()
 	}
 
+	// changed metadata of existing series
+	for _, u := range updates {
+		res := instance.UpdateSeries(u.update)
+		if res.Error != nil {
+			result.Error = res.Error
+			return
+		}
+		current := result.Results[u.identifier]
+		current.Changed = res.Changed
+		result.Results[u.identifier] = current
+	}
+
+	return
+}
+
+func (instance *MemoryBackend) UpdateSeries(update *UpdateSeries) (result *UpdateSeriesResult) {
+	result = &UpdateSeriesResult{}
+	instance.seriesMux.Lock()
+	defer instance.seriesMux.Unlock()
+	existing := instance.series[Series(update.Id)]
+	if existing == nil || existing.Namespace != Namespace(update.Namespace) {
+		result.Error = types.RpcErrorSeriesNotFound.Error()
+		return
+	}
+	updated, changed := existing.applyUpdate(update)
+	if updated.Name != existing.Name {
+		if other := instance.__notLockedGetSeriesByNameSpaceAndName(updated.Namespace, updated.Name); other != nil {
+			result.Error = types.RpcErrorSeriesNameExists.Error()
+			return
+		}
+	}
+	if changed {
+		// replaced instead of modified, readers may hold the previous metadata
+		instance.series[Series(update.Id)] = &updated
+		instance.__notLockedReindexSeries(existing, &updated)
+	}
+	result.Metadata = updated
+	result.Changed = changed
 	return
 }
 
@@ -269,19 +389,35 @@ func (instance *MemoryBackend) SearchSeries(search *SearchSeries) (result *Searc
 		result.Error = errors.New("only EQUALS support")
 		return
 	}
-	if search.Tag != "" {
-		result.Error = errors.New("not tag support yet")
+	if search.NameMatch != types.NameMatchExact {
+		instance.seriesMux.RLock()
+		result.Series, result.Next, result.Error = searchNames(instance, search)
+		instance.seriesMux.RUnlock()
+		return
+	}
+	if search.Name == "" && search.Tag == "" && len(search.Matchers) < 1 {
+		result.Error = errors.New("missing name, tag or matchers")
 		return
 	}
 
 	// search
 	instance.seriesMux.RLock()
-	for _, serie := range instance.series {
-		if serie.Namespace != Namespace(search.Namespace) {
-			continue
+	if len(search.Matchers) > 0 {
+		result.Series, result.Error = searchLabels(instance, search.SearchSeriesElement)
+		instance.seriesMux.RUnlock()
+		return
+	}
+	var candidates []Series
+	if search.Name != "" {
+		if serie := instance.__notLockedGetSeriesByNameSpaceAndName(Namespace(search.Namespace), search.Name); serie != nil {
+			candidates = []Series{serie.Id}
 		}
-		if serie.Name == search.Name {
-			// @todo support tags, etc
+	} else {
+		candidates = instance.__notLockedSeriesIds(Namespace(search.Namespace), search.Tag)
+	}
+	for _, id := range candidates {
+		serie := instance.series[id]
+		if search.Tag == "" || hasTag(serie.Tags, search.Tag) {
 			// match
 
 			// init result set
@@ -299,7 +435,31 @@ func (instance *MemoryBackend) SearchSeries(search *SearchSeries) (result *Searc
 	return
 }
 
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 func (instance *MemoryBackend) DeleteSeries(ops *DeleteSeries) (result *DeleteSeriesResult) {
+	deleted, result := instance.deleteSeriesMetadata(ops)
+
+	// data of the series, after releasing the series lock (reads and writes take the data lock first)
+	if len(deleted) > 0 {
+		instance.dataMux.Lock()
+		for _, identifier := range deleted {
+			delete(instance.data[Namespace(identifier.Namespace)], Series(identifier.Id))
+			delete(instance.typedData[Namespace(identifier.Namespace)], Series(identifier.Id))
+		}
+		instance.dataMux.Unlock()
+	}
+	return
+}
+
+func (instance *MemoryBackend) deleteSeriesMetadata(ops *DeleteSeries) (deleted []types.SeriesIdentifier, result *DeleteSeriesResult) {
 	result = &DeleteSeriesResult{}
 	instance.seriesMux.Lock()
 	defer instance.seriesMux.Unlock()
@@ -311,24 +471,196 @@ func (instance *MemoryBackend) DeleteSeries(ops *DeleteSeries) (result *DeleteSe
 				result.Error = errors.New("invalid namespace")
 				return
 			}
+			instance.__notLockedUnindexSeries(val)
 		} else {
 			// not found
 			result.Error = errors.New("not found")
 			return
 		}
 		delete(instance.series, key)
+		deleted = append(deleted, deleteOperation)
+	}
+	return
+}
+
+func (instance *MemoryBackend) CountSeries(count *CountSeries) (result *CountSeriesResult) {
+	result = &CountSeriesResult{}
+	instance.seriesMux.RLock()
+	result.Count = len(instance.byName[Namespace(count.Namespace)])
+	instance.seriesMux.RUnlock()
+	return
+}
+
+func (instance *MemoryBackend) ListNamespaces() (result *ListNamespacesResult) {
+	result = &ListNamespacesResult{}
+	instance.seriesMux.RLock()
+	for namespace := range instance.byName {
+		result.Namespaces = append(result.Namespaces, namespace.Int())
+	}
+	instance.seriesMux.RUnlock()
+	sort.Ints(result.Namespaces)
+	return
+}
+
+func (instance *MemoryBackend) ListSeries(list *ListSeries) (result *ListSeriesResult) {
+	result = &ListSeriesResult{}
+	instance.seriesMux.RLock()
+	defer instance.seriesMux.RUnlock()
+	if len(list.Ids) > 0 {
+		for _, id := range list.Ids {
+			serie, found := instance.series[Series(id)]
+			if !found || serie.Namespace != Namespace(list.Namespace) {
+				result.Error = fmt.Errorf("series %d not found", id)
+				return
+			}
+			result.Series = append(result.Series, *serie)
+		}
+		return
+	}
+	for _, id := range instance.__notLockedSeriesIds(Namespace(list.Namespace), "") {
+		result.Series = append(result.Series, *instance.series[id])
+	}
+	return
+}
+
+func (instance *MemoryBackend) DescribeSeries(context Context) (result DescribeSeriesResult) {
+	instance.dataMux.RLock()
+	defer instance.dataMux.RUnlock()
+	describe := func(tsF Timestamp) {
+		// truncate timestamp to get rid of the padded decimals
+		ts := uint64(tsF)
+		if result.Points == 0 || ts < result.From {
+			result.From = ts
+		}
+		if ts > result.To {
+			result.To = ts
+		}
+		result.Points++
+	}
+	for tsF := range instance.data[Namespace(context.Namespace)][Series(context.Series)] {
+		describe(tsF)
+	}
+	for tsF := range instance.typedData[Namespace(context.Namespace)][Series(context.Series)] {
+		describe(tsF)
+	}
+	return
+}
+
+func (instance *MemoryBackend) ReadSeries(context Context) (res ReadResult) {
+	instance.dataMux.RLock()
+	defer instance.dataMux.RUnlock()
+	if meta := instance.GetSeriesMeta(Series(context.Series)); meta != nil && meta.ValueType.Typed() {
+		typedSeries := instance.typedData[Namespace(context.Namespace)][Series(context.Series)]
+		res.ValueType = meta.ValueType
+		res.Fields = meta.Fields
+		res.TypedResults = make(map[uint64]types.Value, len(typedSeries))
+		for tsF, value := range typedSeries {
+			res.TypedResults[uint64(tsF)] = value
+		}
+		return
+	}
+	series := instance.data[Namespace(context.Namespace)][Series(context.Series)]
+	res.Results = make(map[uint64]float64, len(series))
+	for tsF, value := range series {
+		// truncate timestamp to get rid of the padded decimals
+		res.Results[uint64(tsF)] = value
+	}
+	return
+}
+
+func (instance *MemoryBackend) DeleteSeriesData(context Context) error {
+	instance.dataMux.Lock()
+	delete(instance.data[Namespace(context.Namespace)], Series(context.Series))
+	delete(instance.typedData[Namespace(context.Namespace)], Series(context.Series))
+	instance.dataMux.Unlock()
+	return nil
+}
+
+func (instance *MemoryBackend) FlushAll() error {
+	// not relevant for in-memory, all be done directly
+	return nil
+}
+
+func (instance *MemoryBackend) Compact() (result CompactResult) {
+	// expired and live series, copied before locking the data (reads and writes take the data lock first)
+	var expired []types.SeriesIdentifier
+	expiredIds := make(map[Series]bool)
+	liveIds := make(map[Series]bool)
+	nowSeconds := nowSeconds()
+	instance.seriesMux.RLock()
+	for id, meta := range instance.series {
+		if meta.TtlExpire > 0 && meta.TtlExpire < nowSeconds {
+			expired = append(expired, types.SeriesIdentifier{
+				Namespace: meta.Namespace.Int(),
+				Id:        uint64(id),
+			})
+			expiredIds[id] = true
+		} else {
+			liveIds[id] = true
+		}
+	}
+	// series created from now on are not in the copy, their data is kept
+	maxId := Series(atomic.LoadUint64(&instance.seriesIdCounter))
+	instance.seriesMux.RUnlock()
+	result.Removed = len(expired)
+	isDeleted := func(id Series) bool {
+		return id <= maxId && !liveIds[id]
+	}
+
+	// data of expired and deleted series
+	instance.dataMux.Lock()
+	for namespace, series := range instance.data {
+		for id := range series {
+			if expiredIds[id] {
+				delete(series, id)
+			} else if isDeleted(id) {
+				delete(series, id)
+				result.Removed++
+			}
+		}
+		if len(series) == 0 {
+			delete(instance.data, namespace)
+		}
+	}
+	for namespace, series := range instance.typedData {
+		for id := range series {
+			if expiredIds[id] || isDeleted(id) {
+				// counted with the float data
+				delete(series, id)
+			}
+		}
+		if len(series) == 0 {
+			delete(instance.typedData, namespace)
+		}
+	}
+	instance.dataMux.Unlock()
+
+	// metadata
+	if len(expired) > 0 {
+		if res := instance.ReverseApi().DeleteSeries(&DeleteSeries{Series: expired}); res.Error != nil {
+			result.Error = res.Error
+		}
 	}
 	return
 }
 
 func (instance *MemoryBackend) Clear() error {
-	instance.seriesMux.Lock()
+	// data first, like reads and writes
 	instance.dataMux.Lock()
+	instance.seriesMux.Lock()
 	instance.data = map[Namespace]map[Series]map[Timestamp]float64{}
+	instance.typedData = map[Namespace]map[Series]map[Timestamp]types.Value{}
 	instance.series = map[Series]*SeriesMetadata{}
+	instance.byName = map[Namespace]map[string]Series{}
+	instance.names = map[Namespace]*sortedNames{}
+	instance.tags = map[Namespace]map[string]map[Series]bool{}
+	instance.labels = map[Namespace]map[string]map[string]map[Series]bool{}
 	instance.seriesIdCounter = 0
-	instance.dataMux.Unlock()
+	instance.expiryMux.Lock()
+	instance.expiry = nil
+	instance.expiryMux.Unlock()
 	instance.seriesMux.Unlock()
+	instance.dataMux.Unlock()
 	return nil
 }
 
@@ -338,8 +670,9 @@ func (instance *MemoryBackend) Init() error {
 
 func NewMemoryBackend() *MemoryBackend {
 	m := &MemoryBackend{
-		data:   make(map[Namespace]map[Series]map[Timestamp]float64),
-		series: make(map[Series]*SeriesMetadata),
+		data:      make(map[Namespace]map[Series]map[Timestamp]float64),
+		typedData: make(map[Namespace]map[Series]map[Timestamp]types.Value),
+		series:    make(map[Series]*SeriesMetadata),
 	}
 	if err := m.Clear(); err != nil {
 		// clear should always work for in-memory
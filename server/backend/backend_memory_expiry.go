@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"container/heap"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+)
+
+type expiryEntry struct {
+	expire uint64
+	id     Series
+}
+
+// min heap by expiry time, entries of series that were deleted or got another ttl are skipped when they are due
+type expiryQueue []expiryEntry
+
+func (queue expiryQueue) Len() int {
+	return len(queue)
+}
+
+func (queue expiryQueue) Less(i, j int) bool {
+	return queue[i].expire < queue[j].expire
+}
+
+func (queue expiryQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+}
+
+func (queue *expiryQueue) Push(x interface{}) {
+	*queue = append(*queue, x.(expiryEntry))
+}
+
+func (queue *expiryQueue) Pop() interface{} {
+	old := *queue
+	entry := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return entry
+}
+
+func (instance *MemoryBackend) queueExpiry(meta *SeriesMetadata) {
+	if meta.TtlExpire < 1 {
+		return
+	}
+	instance.expiryMux.Lock()
+	heap.Push(&instance.expiry, expiryEntry{expire: meta.TtlExpire, id: meta.Id})
+	instance.expiryMux.Unlock()
+}
+
+func (instance *MemoryBackend) ExpireSeries(expire *ExpireSeries) (result *ExpireSeriesResult) {
+	result = &ExpireSeriesResult{}
+
+	// due entries
+	var due []expiryEntry
+	instance.expiryMux.Lock()
+	for instance.expiry.Len() > 0 && instance.expiry[0].expire < expire.Now && (expire.Max < 1 || len(due) < expire.Max) {
+		due = append(due, heap.Pop(&instance.expiry).(expiryEntry))
+	}
+	instance.expiryMux.Unlock()
+
+	// that still expire at that time
+	instance.seriesMux.RLock()
+	for _, entry := range due {
+		if meta := instance.series[entry.id]; meta != nil && meta.TtlExpire == entry.expire {
+			result.Series = append(result.Series, types.SeriesIdentifier{
+				Namespace: meta.Namespace.Int(),
+				Id:        uint64(meta.Id),
+			})
+		}
+	}
+	instance.seriesMux.RUnlock()
+	if len(result.Series) < 1 {
+		return
+	}
+
+	// metadata and data, failures are left to compaction
+	if res := instance.ReverseApi().DeleteSeries(&DeleteSeries{Series: result.Series}); res.Error != nil {
+		result.Error = res.Error
+	}
+	return
+}
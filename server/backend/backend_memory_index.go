@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"sort"
+)
+
+// indexes of the series metadata, not locked: callers hold the series lock
+
+func (instance *MemoryBackend) __notLockedIndexSeries(meta *SeriesMetadata) {
+	instance.__notLockedIndexByName(meta.Namespace, meta.Id, meta.Name)
+	instance.__notLockedIndexName(meta.Namespace, meta.Id, meta.Name)
+	instance.__notLockedIndexTags(meta.Namespace, meta.Id, meta.Tags)
+	instance.__notLockedIndexLabels(meta.Namespace, meta.Id, meta.Labels)
+	instance.queueExpiry(meta)
+}
+
+func (instance *MemoryBackend) __notLockedUnindexSeries(meta *SeriesMetadata) {
+	instance.__notLockedUnindexByName(meta.Namespace, meta.Id, meta.Name)
+	instance.__notLockedUnindexName(meta.Namespace, meta.Id, meta.Name)
+	instance.__notLockedUnindexTags(meta.Namespace, meta.Id, meta.Tags)
+	instance.__notLockedUnindexLabels(meta.Namespace, meta.Id, meta.Labels)
+}
+
+// only what changed, the sorted names are expensive to change
+func (instance *MemoryBackend) __notLockedReindexSeries(existing *SeriesMetadata, updated *SeriesMetadata) {
+	if updated.Name != existing.Name {
+		instance.__notLockedUnindexByName(existing.Namespace, existing.Id, existing.Name)
+		instance.__notLockedIndexByName(updated.Namespace, updated.Id, updated.Name)
+		instance.__notLockedUnindexName(existing.Namespace, existing.Id, existing.Name)
+		instance.__notLockedIndexName(updated.Namespace, updated.Id, updated.Name)
+	}
+	var removedTags, addedTags []string
+	for _, tag := range existing.Tags {
+		if !hasTag(updated.Tags, tag) {
+			removedTags = append(removedTags, tag)
+		}
+	}
+	for _, tag := range updated.Tags {
+		if !hasTag(existing.Tags, tag) {
+			addedTags = append(addedTags, tag)
+		}
+	}
+	instance.__notLockedUnindexTags(existing.Namespace, existing.Id, removedTags)
+	instance.__notLockedIndexTags(updated.Namespace, updated.Id, addedTags)
+	removedLabels, addedLabels := labelChanges(existing.Labels, updated.Labels)
+	instance.__notLockedUnindexLabels(existing.Namespace, existing.Id, removedLabels)
+	instance.__notLockedIndexLabels(updated.Namespace, updated.Id, addedLabels)
+	if updated.TtlExpire != existing.TtlExpire {
+		instance.queueExpiry(updated)
+	}
+}
+
+func (instance *MemoryBackend) __notLockedIndexByName(namespace Namespace, id Series, name string) {
+	if instance.byName[namespace] == nil {
+		instance.byName[namespace] = make(map[string]Series)
+	}
+	instance.byName[namespace][name] = id
+}
+
+func (instance *MemoryBackend) __notLockedUnindexByName(namespace Namespace, id Series, name string) {
+	if instance.byName[namespace][name] != id {
+		return
+	}
+	delete(instance.byName[namespace], name)
+	if len(instance.byName[namespace]) < 1 {
+		delete(instance.byName, namespace)
+	}
+}
+
+func (instance *MemoryBackend) __notLockedIndexTags(namespace Namespace, id Series, tags []string) {
+	if len(tags) < 1 {
+		return
+	}
+	if instance.tags[namespace] == nil {
+		instance.tags[namespace] = make(map[string]map[Series]bool)
+	}
+	for _, tag := range tags {
+		if instance.tags[namespace][tag] == nil {
+			instance.tags[namespace][tag] = make(map[Series]bool)
+		}
+		instance.tags[namespace][tag][id] = true
+	}
+}
+
+func (instance *MemoryBackend) __notLockedUnindexTags(namespace Namespace, id Series, tags []string) {
+	for _, tag := range tags {
+		postings := instance.tags[namespace][tag]
+		if postings == nil {
+			continue
+		}
+		delete(postings, id)
+		if len(postings) > 0 {
+			continue
+		}
+		delete(instance.tags[namespace], tag)
+		if len(instance.tags[namespace]) < 1 {
+			delete(instance.tags, namespace)
+		}
+	}
+}
+
+func (instance *MemoryBackend) __notLockedGetSeriesByNameSpaceAndName(namespace Namespace, name string) *SeriesMetadata {
+	id, found := instance.byName[namespace][name]
+	if !found {
+		return nil
+	}
+	return instance.series[id]
+}
+
+// ids of the series of a namespace, optionally only those with a tag, sorted
+func (instance *MemoryBackend) __notLockedSeriesIds(namespace Namespace, tag string) []Series {
+	var ids []Series
+	if len(tag) > 0 {
+		ids = make([]Series, 0, len(instance.tags[namespace][tag]))
+		for id := range instance.tags[namespace][tag] {
+			ids = append(ids, id)
+		}
+	} else {
+		ids = make([]Series, 0, len(instance.byName[namespace]))
+		for _, id := range instance.byName[namespace] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"sort"
+)
+
+// postings of the label pairs, not locked: callers hold the series lock
+
+func (instance *MemoryBackend) __notLockedIndexLabels(namespace Namespace, id Series, labels map[string]string) {
+	if len(labels) < 1 {
+		return
+	}
+	if instance.labels[namespace] == nil {
+		instance.labels[namespace] = make(map[string]map[string]map[Series]bool)
+	}
+	for name, value := range labels {
+		if instance.labels[namespace][name] == nil {
+			instance.labels[namespace][name] = make(map[string]map[Series]bool)
+		}
+		if instance.labels[namespace][name][value] == nil {
+			instance.labels[namespace][name][value] = make(map[Series]bool)
+		}
+		instance.labels[namespace][name][value][id] = true
+	}
+}
+
+func (instance *MemoryBackend) __notLockedUnindexLabels(namespace Namespace, id Series, labels map[string]string) {
+	for name, value := range labels {
+		postings := instance.labels[namespace][name][value]
+		if postings == nil {
+			continue
+		}
+		delete(postings, id)
+		if len(postings) > 0 {
+			continue
+		}
+		delete(instance.labels[namespace][name], value)
+		if len(instance.labels[namespace][name]) > 0 {
+			continue
+		}
+		delete(instance.labels[namespace], name)
+		if len(instance.labels[namespace]) < 1 {
+			delete(instance.labels, namespace)
+		}
+	}
+}
+
+func (instance *MemoryBackend) labelValues(namespace Namespace, name string) ([]string, error) {
+	values := make([]string, 0, len(instance.labels[namespace][name]))
+	for value := range instance.labels[namespace][name] {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func (instance *MemoryBackend) labelPostings(namespace Namespace, name string, value string) ([]uint64, error) {
+	postings := instance.labels[namespace][name][value]
+	ids := make([]uint64, 0, len(postings))
+	for id := range postings {
+		ids = append(ids, uint64(id))
+	}
+	return ids, nil
+}
+
+func (instance *MemoryBackend) namespaceSeries(namespace Namespace) ([]uint64, error) {
+	ids := make([]uint64, 0, len(instance.byName[namespace]))
+	for _, id := range instance.byName[namespace] {
+		ids = append(ids, uint64(id))
+	}
+	return ids, nil
+}
+
+func (instance *MemoryBackend) seriesMetadata(namespace Namespace, id uint64) (*SeriesMetadata, error) {
+	serie := instance.series[Series(id)]
+	if serie == nil || serie.Namespace != namespace {
+		return nil, nil
+	}
+	return serie, nil
+}
+
+func (instance *MemoryBackend) ListLabels(list *ListLabels) (result *ListLabelsResult) {
+	result = &ListLabelsResult{}
+	instance.seriesMux.RLock()
+	defer instance.seriesMux.RUnlock()
+	if len(list.Name) > 0 {
+		result.Values, result.Error = instance.labelValues(Namespace(list.Namespace), list.Name)
+		return
+	}
+	result.Values = make([]string, 0, len(instance.labels[Namespace(list.Namespace)]))
+	for name := range instance.labels[Namespace(list.Namespace)] {
+		result.Values = append(result.Values, name)
+	}
+	sort.Strings(result.Values)
+	return
+}
+
+func (instance *MemoryBackend) CountLabelValues(count *CountLabelValues) (result *CountLabelValuesResult) {
+	result = &CountLabelValuesResult{}
+	instance.seriesMux.RLock()
+	values := instance.labels[Namespace(count.Namespace)][count.Name]
+	result.Count = len(values)
+	result.Exists = values[count.Value] != nil
+	instance.seriesMux.RUnlock()
+	return
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// names of the series of one namespace in a skip list, so adding, removing and seeking a name are logarithmic
+// it has its own lock since lookups happen while holding the read lock of the series
+type sortedNames struct {
+	head   nameNode
+	level  int // levels in use
+	length int
+	mux    sync.Mutex
+}
+
+const sortedNamesMaxLevel = 32
+
+type nameNode struct {
+	entry nameEntry
+	next  []*nameNode // per level
+}
+
+func newSortedNames() *sortedNames {
+	return &sortedNames{
+		head:  nameNode{next: make([]*nameNode, sortedNamesMaxLevel)},
+		level: 1,
+	}
+}
+
+func (names *sortedNames) add(name string, id Series) {
+	names.mux.Lock()
+	defer names.mux.Unlock()
+	update := make([]*nameNode, sortedNamesMaxLevel)
+	if node := names.__notLockedSearch(name, true, update); node != nil && node.entry.name == name {
+		node.entry.id = uint64(id)
+		return
+	}
+	level := randomNameLevel()
+	if level > names.level {
+		for i := names.level; i < level; i++ {
+			update[i] = &names.head
+		}
+		names.level = level
+	}
+	node := &nameNode{entry: nameEntry{name: name, id: uint64(id)}, next: make([]*nameNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	names.length++
+}
+
+func (names *sortedNames) remove(name string, id Series) {
+	names.mux.Lock()
+	defer names.mux.Unlock()
+	update := make([]*nameNode, sortedNamesMaxLevel)
+	node := names.__notLockedSearch(name, true, update)
+	if node == nil || node.entry.name != name || node.entry.id != uint64(id) {
+		return
+	}
+	for i := range node.next {
+		update[i].next[i] = node.next[i]
+	}
+	for names.level > 1 && names.head.next[names.level-1] == nil {
+		names.level--
+	}
+	names.length--
+}
+
+func (names *sortedNames) from(from string, inclusive bool, count int) []nameEntry {
+	names.mux.Lock()
+	defer names.mux.Unlock()
+	entries := make([]nameEntry, 0, count)
+	for node := names.__notLockedSearch(from, inclusive, nil); node != nil && len(entries) < count; node = node.next[0] {
+		entries = append(entries, node.entry)
+	}
+	return entries
+}
+
+func (names *sortedNames) len() int {
+	names.mux.Lock()
+	n := names.length
+	names.mux.Unlock()
+	return n
+}
+
+// first node after from, or equal to it if inclusive, update is filled with its predecessors per level if set
+func (names *sortedNames) __notLockedSearch(from string, inclusive bool, update []*nameNode) *nameNode {
+	node := &names.head
+	for i := names.level - 1; i >= 0; i-- {
+		for next := node.next[i]; next != nil && (next.entry.name < from || (!inclusive && next.entry.name == from)); next = node.next[i] {
+			node = next
+		}
+		if update != nil {
+			update[i] = node
+		}
+	}
+	return node.next[0]
+}
+
+// each level holds a quarter of the nodes of the level below
+func randomNameLevel() int {
+	level := 1
+	for level < sortedNamesMaxLevel && rand.Int63()&3 == 0 {
+		level++
+	}
+	return level
+}
+
+// name index, not locked: callers hold the series lock
+
+func (instance *MemoryBackend) __notLockedIndexName(namespace Namespace, id Series, name string) {
+	if instance.names[namespace] == nil {
+		instance.names[namespace] = newSortedNames()
+	}
+	instance.names[namespace].add(name, id)
+}
+
+func (instance *MemoryBackend) __notLockedUnindexName(namespace Namespace, id Series, name string) {
+	names := instance.names[namespace]
+	if names == nil {
+		return
+	}
+	names.remove(name, id)
+	if names.len() < 1 {
+		delete(instance.names, namespace)
+	}
+}
+
+func (instance *MemoryBackend) namesFrom(namespace Namespace, from string, inclusive bool, count int) ([]nameEntry, string, error) {
+	names := instance.names[namespace]
+	if names == nil {
+		return nil, "", nil
+	}
+	entries := names.from(from, inclusive, count)
+	var last string
+	if len(entries) == count {
+		last = entries[len(entries)-1].name
+	}
+	return entries, last, nil
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSortedNames(t *testing.T) {
+	// random adds and removes, compared with a sorted copy
+	names := newSortedNames()
+	expected := make(map[string]Series)
+	for i := 0; i < 5000; i++ {
+		name := fmt.Sprintf("series.%d", rand.Intn(1000))
+		if id, found := expected[name]; found && rand.Intn(2) == 0 {
+			names.remove(name, id+1) // other id, ignored
+			names.remove(name, id)
+			delete(expected, name)
+			continue
+		}
+		names.add(name, Series(i))
+		expected[name] = Series(i)
+	}
+	if names.len() != len(expected) {
+		t.Fatalf("expected %d names, found %d", len(expected), names.len())
+	}
+	sorted := make([]nameEntry, 0, len(expected))
+	for name, id := range expected {
+		sorted = append(sorted, nameEntry{name: name, id: uint64(id)})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	if all := names.from("", true, len(sorted)+1); !reflect.DeepEqual(all, sorted) {
+		t.Error("unexpected order")
+	}
+
+	// seeking
+	mid := sorted[len(sorted)/2]
+	if entries := names.from(mid.name, true, 2); !reflect.DeepEqual(entries, sorted[len(sorted)/2:len(sorted)/2+2]) {
+		t.Errorf("inclusive: %v", entries)
+	}
+	if entries := names.from(mid.name, false, 2); !reflect.DeepEqual(entries, sorted[len(sorted)/2+1:len(sorted)/2+3]) {
+		t.Errorf("exclusive: %v", entries)
+	}
+	if entries := names.from("series.", true, 1); !reflect.DeepEqual(entries, sorted[:1]) {
+		t.Errorf("prefix: %v", entries)
+	}
+	if entries := names.from("z", true, 10); len(entries) != 0 {
+		t.Errorf("after the last name: %v", entries)
+	}
+}
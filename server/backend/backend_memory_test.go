@@ -1,9 +1,11 @@
 package backend_test
 
 import (
+	"fmt"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/server/backend"
 	"math/rand"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -63,6 +65,14 @@ func TestMemoryBackend(t *testing.T) {
 		}
 	}
 
+	// count
+	if res := b.CountSeries(&backend.CountSeries{Namespace: 1}); res.Error != nil || res.Count != 1 {
+		t.Error(res)
+	}
+	if res := b.CountSeries(&backend.CountSeries{Namespace: 2}); res.Error != nil || res.Count != 0 {
+		t.Error(res)
+	}
+
 	// simple write
 	const seriesId = 1
 	now := uint64(time.Now().Unix() * 1000)
@@ -345,3 +355,111 @@ func TestMemoryBackend(t *testing.T) {
 	}
 	// end TTL test
 }
+
+func TestMemoryBackendIndexes(t *testing.T) {
+	b := backend.NewMemoryBackend()
+	b.SetReverseApi(b)
+	search := func(namespace int, name string, tag string) []types.SeriesIdentifier {
+		s := &backend.SearchSeries{}
+		s.Namespace = namespace
+		s.Name = name
+		s.Tag = tag
+		s.Comparator = backend.SearchSeriesComparatorEquals
+		res := b.SearchSeries(s)
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		return res.Series
+	}
+
+	// bulk creation, the same names in two namespaces
+	const n = 50000
+	create := &backend.CreateSeries{Series: make(map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata, 2*n)}
+	for namespace := 1; namespace <= 2; namespace++ {
+		for i := 0; i < n; i++ {
+			identifier := types.SeriesCreateIdentifier(namespace*n + i)
+			tag := "even"
+			if i%2 == 1 {
+				tag = "odd"
+			}
+			create.Series[identifier] = types.SeriesCreateMetadata{
+				SeriesMetadata:         types.SeriesMetadata{Namespace: namespace, Name: fmt.Sprintf("series.%d", i), Tags: []string{tag}},
+				SeriesCreateIdentifier: identifier,
+			}
+		}
+	}
+	res := b.CreateOrUpdateSeries(create)
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if count := b.CountSeries(&backend.CountSeries{Namespace: 2}); count.Count != n {
+		t.Errorf("expected %d series, was %d", n, count.Count)
+	}
+	if namespaces := b.ListNamespaces(); !reflect.DeepEqual(namespaces.Namespaces, []int{1, 2}) {
+		t.Errorf("unexpected namespaces %v", namespaces.Namespaces)
+	}
+	id := res.Results[types.SeriesCreateIdentifier(2*n+7)].Id
+	if found := search(2, "series.7", ""); len(found) != 1 || found[0].Id != id {
+		t.Errorf("expected series %d, found %v", id, found)
+	}
+	if found := search(2, "series.7", "even"); len(found) != 0 {
+		t.Errorf("expected no series, found %v", found)
+	}
+	if found := search(1, "", "odd"); len(found) != n/2 {
+		t.Errorf("expected %d series, found %d", n/2, len(found))
+	}
+
+	// deletes
+	var deletes []types.SeriesIdentifier
+	for i := 0; i < n; i += 2 {
+		deletes = append(deletes, types.SeriesIdentifier{Namespace: 1, Id: res.Results[types.SeriesCreateIdentifier(n+i)].Id})
+	}
+	if res := b.DeleteSeries(&backend.DeleteSeries{Series: deletes}); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if count := b.CountSeries(&backend.CountSeries{Namespace: 1}); count.Count != n/2 {
+		t.Errorf("expected %d series, was %d", n/2, count.Count)
+	}
+	if found := search(1, "", "even"); len(found) != 0 {
+		t.Errorf("expected no series, found %d", len(found))
+	}
+	if found := search(1, "series.2", ""); len(found) != 0 {
+		t.Errorf("expected no series, found %v", found)
+	}
+
+	// updates
+	if res := b.UpdateSeries(&backend.UpdateSeries{Namespace: 2, Id: id, Rename: "renamed", AddTags: []string{"renamed"}, RemoveTags: []string{"odd"}}); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if found := search(2, "series.7", ""); len(found) != 0 {
+		t.Errorf("expected no series, found %v", found)
+	}
+	if found := search(2, "renamed", "renamed"); len(found) != 1 || found[0].Id != id {
+		t.Errorf("expected series %d, found %v", id, found)
+	}
+	if found := search(2, "", "odd"); len(found) != n/2-1 {
+		t.Errorf("expected %d series, found %d", n/2-1, len(found))
+	}
+
+	// a deleted name can be created again
+	again := b.CreateOrUpdateSeries(&backend.CreateSeries{Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+		1: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: "series.2"}, SeriesCreateIdentifier: 1},
+	}})
+	if again.Error != nil || !again.Results[1].New {
+		t.Error(again.Error, again.Results)
+	}
+}
+
+func BenchmarkMemoryBackendCreateSeries(b *testing.B) {
+	m := backend.NewMemoryBackend()
+	m.SetReverseApi(m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res := m.CreateOrUpdateSeries(&backend.CreateSeries{Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+			1: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: fmt.Sprintf("series.%d", i), Tags: []string{"benchmark"}}, SeriesCreateIdentifier: 1},
+		}})
+		if res.Error != nil {
+			b.Fatal(res.Error)
+		}
+	}
+}
@@ -5,14 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
 	"github.com/alicebob/miniredis/v2"
 	lock "github.com/bsm/redislock"
 	"github.com/go-redis/redis/v8"
-	"github.com/jinzhu/now"
 	"github.com/karlseguin/ccache/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/pkg/errors"
-	"log"
+	"github.com/sirupsen/logrus"
 	"math"
 	"math/rand"
 	"regexp"
@@ -47,9 +47,16 @@ type RedisBackend struct {
 
 	metadataCache *ccache.Cache
 
+	pipelineObserver func(commands int) // optional, called with the size of every executed pipeline
+
 	ctx context.Context
 }
 
+// observe the number of commands of each executed write pipeline, e.g. for metrics
+func (instance *RedisBackend) SetPipelineObserver(observer func(commands int)) {
+	instance.pipelineObserver = observer
+}
+
 func (instance *RedisBackend) Type() TypeBackend {
 	return RedisType
 }
@@ -60,6 +67,11 @@ func (instance *RedisBackend) getDataKey(ctx Context, timestamp uint64) (string,
 }
 
 func (instance *RedisBackend) getKeyScoreAndMember(context ContextWrite, timestamp uint64, value float64) (key string, score float64, member string) {
+	return instance.getKeyScoreAndEncodedMember(context, timestamp, FloatToString(value))
+}
+
+// member of an encoded value, see encodeValue for the values of series that are not float
+func (instance *RedisBackend) getKeyScoreAndEncodedMember(context ContextWrite, timestamp uint64, value string) (key string, score float64, member string) {
 	// this function basically creates a unique "member" for the redis set so that it's not overwritten, it uses the timestamp prefix with a random value
 	// the timestamp prefix will be truncated to last digits (most significant, seconds etc versus the month bit).
 	var tsBucket uint64
@@ -73,7 +85,7 @@ func (instance *RedisBackend) getKeyScoreAndMember(context ContextWrite, timesta
 		tsBucketPrefix = tsBucketPrefix[0:finalChars]
 	}
 	tsPaddedStr := strings.TrimPrefix(fmt.Sprintf("%f", score), tsBucketPrefix)
-	member = FloatToString(value) + fmt.Sprintf(":%s", tsPaddedStr) // must be string and unique, that's why we take the timestamp with random value
+	member = value + fmt.Sprintf(":%s", tsPaddedStr) // must be string and unique, that's why we take the timestamp with random value
 	return key, score, member
 }
 
@@ -97,6 +109,9 @@ func (instance *RedisBackend) FlushPendingWrites(requestId RequestId) error {
 	}()
 
 	// execute buffer
+	if instance.pipelineObserver != nil {
+		instance.pipelineObserver(v.Len())
+	}
 	if _, err := v.Exec(instance.ctx); err != nil {
 		return errors.Wrap(err, "redis flush pending writes failed")
 	}
@@ -135,6 +150,53 @@ func (instance *RedisBackend) Write(context ContextWrite, timestamps []uint64, v
 		return fmt.Errorf("empty request id %s", context.RequestId)
 	}
 
+	// meta
+	meta, err := instance.getMetadata(Namespace(context.Namespace), context.Series, false)
+	if err != nil {
+		if strings.Contains(err.Error(), types.RpcErrorSeriesExpired.String()) {
+			// series expired, not a real problem
+			return nil
+		}
+	} else if err := meta.validateFloats(); err != nil {
+		return err
+	}
+
+	encoded := make([]string, len(values))
+	for idx, value := range values {
+		encoded[idx] = FloatToString(value)
+	}
+	return instance.write(context, meta, timestamps, encoded)
+}
+
+func (instance *RedisBackend) WriteValues(context ContextWrite, timestamps []uint64, values []types.Value) error {
+	if IsEmptyRequestId(context.RequestId) {
+		return fmt.Errorf("empty request id %s", context.RequestId)
+	}
+
+	// meta, the value type is required to encode
+	meta, err := instance.getMetadata(Namespace(context.Namespace), context.Series, false)
+	if err != nil {
+		if strings.Contains(err.Error(), types.RpcErrorSeriesExpired.String()) {
+			// series expired, not a real problem
+			return nil
+		}
+		return err
+	}
+	if err := meta.validateValues(values); err != nil {
+		return err
+	}
+
+	encoded := make([]string, len(values))
+	for idx, value := range values {
+		if encoded[idx], err = encodeValue(meta.ValueType, value); err != nil {
+			return err
+		}
+	}
+	return instance.write(context, meta, timestamps, encoded)
+}
+
+// adds the encoded values to the pipeline of the request
+func (instance *RedisBackend) write(context ContextWrite, meta SeriesMetadata, timestamps []uint64, values []string) error {
 	keyValues := make(map[string][]*redis.Z)
 	for idx, timestamp := range timestamps {
 
@@ -142,7 +204,7 @@ func (instance *RedisBackend) Write(context ContextWrite, timestamps []uint64, v
 		value := values[idx]
 
 		// determine key
-		key, score, setMember := instance.getKeyScoreAndMember(context, timestamp, value)
+		key, score, setMember := instance.getKeyScoreAndEncodedMember(context, timestamp, value)
 
 		// init key
 		if keyValues[key] == nil {
@@ -159,15 +221,6 @@ func (instance *RedisBackend) Write(context ContextWrite, timestamps []uint64, v
 		keyValues[key] = append(keyValues[key], &member)
 	}
 
-	// meta
-	meta, err := instance.getMetadata(Namespace(context.Namespace), context.Series, false)
-	if err != nil {
-		if strings.Contains(err.Error(), types.RpcErrorSeriesExpired.String()) {
-			// series expired, not a real problem
-			return nil
-		}
-	}
-
 	// get redis pipeline
 	pipeline, err := instance.getPipeline(context)
 	if err != nil {
@@ -175,12 +228,7 @@ func (instance *RedisBackend) Write(context ContextWrite, timestamps []uint64, v
 	}
 
 	// when to expire?
-	var expireTime time.Time
-	if meta.TtlExpire == 0 {
-		expireTime = now.EndOfDay()
-	} else {
-		expireTime = time.Unix(int64(meta.TtlExpire), 0)
-	}
+	expireTime := dataExpireTime(meta)
 
 	// add commands to redis pipeline
 	for key, members := range keyValues {
@@ -220,7 +268,9 @@ func FloatToString(val float64) string {
 func (instance *RedisBackend) getKeysInRange(ctx ContextRead) ([]string, []uint64) {
 	keys := make([]string, 0)
 	tsBuckets := make([]uint64, 0)
-	for ts := ctx.From; ts < ctx.To; ts += timestampBucketSize {
+	// every bucket from the one of the first to the one of the last timestamp
+	first := ctx.From - (ctx.From % timestampBucketSize)
+	for ts := first; ts <= ctx.To && ts >= first; ts += timestampBucketSize { // stop on overflow
 		key, tsBucket := instance.getDataKey(ctx.Context, ts)
 		keys = append(keys, key)
 		tsBuckets = append(tsBuckets, tsBucket)
@@ -243,7 +293,10 @@ func (instance *RedisBackend) Read(context ContextRead) (res ReadResult) {
 
 	// read
 	keys, _ := instance.getKeysInRange(context)
-	var resultMap map[uint64]float64
+	if context.Limit > 0 {
+		return instance.readPage(conn, keys, context, meta)
+	}
+	points := newRedisReadPoints(meta)
 	for _, key := range keys {
 		read := conn.ZRangeByScoreWithScores(instance.ctx, key, &redis.ZRangeBy{
 			Min: FloatToString(float64(context.From - 1)), // pad 1 ms to make sure the scores are available due to float rounding
@@ -255,37 +308,90 @@ func (instance *RedisBackend) Read(context ContextRead) (res ReadResult) {
 		}
 		values := read.Val()
 		for _, value := range values {
-			member := value.Member.(string)
-			memberSplit := strings.Split(member, ":")
-			if len(memberSplit) != 2 {
-				panic("should always be 2 parts")
+			if err := points.add(uint64(value.Score), value.Member.(string)); err != nil {
+				res.Error = fmt.Errorf("parse value err %s,%v: %s", key, value, err)
+				return
 			}
-			var floatValue float64
-			var err error
-			if memberSplit[0] == "." {
-				floatValue = 0.0
-			} else {
-				floatValue, err = strconv.ParseFloat(memberSplit[0], 64)
-				if err != nil {
-					res.Error = fmt.Errorf("parse float err %s,%v: %s", key, value, err)
+		}
+	}
+	// no data?
+	if points.len() < 1 {
+		res.Error = types.RpcErrorNoDataFound.Error()
+		return
+	}
+	return points.result()
+}
+
+const readPageBatchSize = 1000 // members per request while reading a page
+
+// first points of the range in time order, the buckets are read in order and only up to the first point after the page
+func (instance *RedisBackend) readPage(conn redis.UniversalClient, keys []string, context ContextRead, meta SeriesMetadata) (res ReadResult) {
+	maxScore := "(" + FloatToString(float64(context.To+1)) // padding is always less than 1 ms
+	if context.To == math.MaxUint64 {
+		maxScore = "+inf"
+	}
+	points := newRedisReadPoints(meta)
+	var lastTs uint64
+	for _, key := range keys {
+		for offset := int64(0); ; offset += readPageBatchSize {
+			read := conn.ZRangeByScoreWithScores(instance.ctx, key, &redis.ZRangeBy{
+				Min:    FloatToString(float64(context.From)),
+				Max:    maxScore,
+				Offset: offset,
+				Count:  readPageBatchSize,
+			})
+			if filterNilErr(read.Err()) != nil {
+				res.Error = read.Err()
+				return
+			}
+			values := read.Val()
+			for _, value := range values {
+				ts := uint64(value.Score)
+				if points.len() >= context.Limit && ts != lastTs {
+					// first point of the next page
+					res = points.result()
+					res.Next = lastTs + 1
 					return
 				}
+				if err := points.add(ts, value.Member.(string)); err != nil {
+					res.Error = fmt.Errorf("parse value err %s,%v: %s", key, value, err)
+					return
+				}
+				lastTs = ts
 			}
-
-			if resultMap == nil {
-				resultMap = make(map[uint64]float64)
+			if len(values) < readPageBatchSize {
+				break
 			}
-			resultMap[uint64(value.Score)] = floatValue
 		}
 	}
 	// no data?
-	if resultMap == nil {
+	if points.len() < 1 {
 		res.Error = types.RpcErrorNoDataFound.Error()
 		return
 	}
-	res.Results = resultMap
+	return points.result()
+}
 
-	return
+// value of a member created by getKeyScoreAndMember
+func parseMemberValue(member string) (float64, error) {
+	return parseFloatString(memberValue(member))
+}
+
+// float formatted by FloatToString
+func parseFloatString(value string) (float64, error) {
+	if value == "." {
+		return 0.0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// encoded value of a member, string values can contain the separator so split on the last one
+func memberValue(member string) string {
+	idx := strings.LastIndex(member, ":")
+	if idx < 0 {
+		panic("should always be 2 parts")
+	}
+	return member[:idx]
 }
 
 func (instance *RedisBackend) getSeriesByNameKey(namespace Namespace, name string) string {
@@ -296,7 +402,12 @@ func (instance *RedisBackend) getSeriesMetaKey(namespace Namespace, id uint64) s
 	return fmt.Sprintf("series_%d_%d_meta", namespace, id) // always prefix with namespace
 }
 
-func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreateIdentifier, series types.SeriesCreateMetadata) (result types.SeriesMetadataResponse, err error) {
+// matches the metadata keys of all namespaces, and name keys of series names ending in _meta
+func (instance *RedisBackend) getSeriesMetaKeyPattern() string {
+	return "series_*_meta"
+}
+
+func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreateIdentifier, series types.SeriesCreateMetadata, create *CreateSeries) (result types.SeriesMetadataResponse, err error) {
 	// get right client
 	conn := instance.GetConnection(Namespace(series.Namespace))
 
@@ -339,10 +450,15 @@ func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreate
 			}
 			newId := uint64(idRes.Val())
 
-			// write to redis
-			writeRes := conn.Set(instance.ctx, seriesKey, fmt.Sprintf("%d", newId), 0)
-			if writeRes.Err() != nil {
-				return result, writeRes.Err()
+			// write to redis, the name key and the name index change together
+			_, err = conn.TxPipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(instance.ctx, seriesKey, fmt.Sprintf("%d", newId), 0)
+				// membership of namespace, used for counting
+				pipe.SAdd(instance.ctx, instance.getSeriesIdsKey(Namespace(series.Namespace)), newId)
+				return instance.indexName(pipe, Namespace(series.Namespace), series.Name)
+			})
+			if err != nil {
+				return result, err
 			}
 
 			// result vars
@@ -357,6 +473,22 @@ func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreate
 		}
 		result.New = false
 		result.Id = id
+
+		// an explicit value type must match the existing series, differing tags, labels or ttl are updated
+		if series.ValueType.Typed() || len(series.Tags) > 0 || len(series.Labels) > 0 || series.Ttl > 0 {
+			meta, err := instance.getMetadata(Namespace(series.Namespace), id, true)
+			if err != nil {
+				return result, err
+			}
+			result.Error = meta.validateCreate(series)
+			if update := meta.updateFromCreate(series); update != nil && result.Error == nil {
+				res := instance.UpdateSeries(update)
+				if res.Error != nil {
+					return result, res.Error
+				}
+				result.Changed = res.Changed
+			}
+		}
 	}
 
 	// only store metadata during creation for now
@@ -366,16 +498,19 @@ func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreate
 			metaKey := instance.getSeriesMetaKey(Namespace(series.Namespace), result.Id)
 
 			// convert to server version
-			var ttlExpire uint64
-			if series.Ttl > 0 {
-				ttlExpire = nowSeconds() + uint64(series.Ttl)
-			}
 			data := SeriesMetadata{
 				Name:      series.Name,
 				Namespace: Namespace(series.Namespace),
 				Tags:      series.Tags,
 				Id:        Series(result.Id),
-				TtlExpire: ttlExpire,
+				TtlExpire: ttlExpire(series.Ttl),
+				Ttl:       series.Ttl,
+				ValueType: series.ValueType,
+				Enum:      series.Enum,
+				Fields:    series.Fields,
+				Labels:    series.Labels,
+				Created:   create.created(),
+				Creator:   create.Creator,
 			}
 			j, err := json.Marshal(data)
 			if err != nil {
@@ -385,6 +520,11 @@ func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreate
 				return result, res.Err()
 			}
 			instance.metadataCache.DeletePrefix(metaKey) // wipe metadata cache
+			if data.TtlExpire > 0 {
+				if err := instance.queueExpiry(conn, data); err != nil {
+					return result, err
+				}
+			}
 		}
 
 		// persist tags
@@ -396,11 +536,20 @@ func (instance *RedisBackend) createOrUpdateSeries(identifier types.SeriesCreate
 				}
 			}
 		}
+
+		// persist labels
+		if err := instance.indexLabels(conn, Namespace(series.Namespace), result.Id, series.Labels); err != nil {
+			return result, err
+		}
 	}
 
 	return
 }
 
+func (instance *RedisBackend) getSeriesIdsKey(namespace Namespace) string {
+	return fmt.Sprintf("series_ids_%d", namespace) // always prefix with namespace
+}
+
 func (instance *RedisBackend) getTagKey(namespace Namespace, tag string) string {
 	return fmt.Sprintf("tag_%d_%s", namespace, tag) // always prefix with namespace
 }
@@ -416,7 +565,7 @@ func idStrToIdUint64(in string) (uint64, error) {
 func (instance *RedisBackend) CreateOrUpdateSeries(create *CreateSeries) (result *CreateSeriesResult) {
 	result = &CreateSeriesResult{}
 	for identifier, series := range create.Series {
-		subRes, err := instance.createOrUpdateSeries(identifier, series)
+		subRes, err := instance.createOrUpdateSeries(identifier, series, create)
 		if err != nil {
 			result.Error = err
 			return
@@ -426,7 +575,10 @@ func (instance *RedisBackend) CreateOrUpdateSeries(create *CreateSeries) (result
 		}
 		result.Results[identifier] = subRes
 
-		log.Printf("creating/updating series: %+v result: %+v", series, subRes)
+		instance.Logger().WithFields(logrus.Fields{
+			tools.LogFieldNamespace: series.Namespace,
+			tools.LogFieldSeries:    subRes.Id,
+		}).Debugf("creating/updating series: %+v result: %+v", series, subRes)
 	}
 	return
 }
@@ -454,14 +606,49 @@ func (instance *RedisBackend) SearchSeries(search *SearchSeries) (result *Search
 		result.Error = errors.New("only EQUALS support")
 		return
 	}
+	if search.NameMatch != types.NameMatchExact {
+		result.Series, result.Next, result.Error = searchNames(instance, search)
+		return
+	}
+	if len(search.Matchers) > 0 {
+		result.Series, result.Error = searchLabels(instance, search.SearchSeriesElement)
+		return
+	}
+	conn := instance.GetConnection(Namespace(search.Namespace))
+
+	// by tag, optionally narrowed down by name
 	if search.Tag != "" {
-		result.Error = errors.New("not tag support yet")
+		res := conn.SMembers(instance.ctx, instance.getTagKey(Namespace(search.Namespace), search.Tag))
+		if filterNilErr(res.Err()) != nil {
+			result.Error = res.Err()
+			return
+		}
+		for _, idStr := range res.Val() {
+			id, err := idStrToIdUint64(idStr)
+			if err != nil {
+				result.Error = err
+				return
+			}
+			if search.Name != "" {
+				meta, err := instance.getMetadata(Namespace(search.Namespace), id, true)
+				if err != nil {
+					result.Error = err
+					return
+				}
+				if meta.Name != search.Name {
+					continue
+				}
+			}
+			result.Series = append(result.Series, types.SeriesIdentifier{
+				Namespace: search.Namespace,
+				Id:        id,
+			})
+		}
 		return
 	}
 
 	// by name
 	if search.Name != "" {
-		conn := instance.GetConnection(Namespace(search.Namespace))
 		seriesKey := instance.getSeriesByNameKey(Namespace(search.Namespace), search.Name)
 		res := conn.Get(instance.ctx, seriesKey)
 		if filterNilErr(res.Err()) != nil {
@@ -487,7 +674,8 @@ func (instance *RedisBackend) SearchSeries(search *SearchSeries) (result *Search
 		return
 	}
 
-	return nil
+	result.Error = errors.New("missing name, tag or matchers")
+	return
 }
 
 func (instance *RedisBackend) getMetadata(namespace Namespace, id uint64, ignoreExpiry bool) (result SeriesMetadata, err error) {
@@ -515,26 +703,10 @@ func (instance *RedisBackend) getMetadataFromStorage(namespace Namespace, id uin
 		return result, err
 	}
 
-	// ttl of series
-	if !ignoreExpiry && data.TtlExpire > 0 {
-		nowSeconds := nowSeconds()
-		if data.TtlExpire < nowSeconds {
-			// expired, remove it
-			res := instance.ReverseApi().DeleteSeries(&DeleteSeries{
-				Series: []types.SeriesIdentifier{
-					{
-						Namespace: namespace.Int(),
-						Id:        id,
-					},
-				},
-			})
-			if res.Error != nil {
-				// @todo deal with in other way
-				panic(res.Error)
-			}
-			err = errors.Wrapf(types.RpcErrorNoDataFound.Error(), fmt.Sprintf("%s (id=%d)", types.RpcErrorSeriesExpired.String(), id))
-			return
-		}
+	// ttl of series, expired series are deleted by the expiry queue
+	if !ignoreExpiry && data.TtlExpire > 0 && data.TtlExpire < nowSeconds() {
+		err = errors.Wrapf(types.RpcErrorNoDataFound.Error(), fmt.Sprintf("%s (id=%d)", types.RpcErrorSeriesExpired.String(), id))
+		return
 	}
 	result = data
 
@@ -556,9 +728,12 @@ func (instance *RedisBackend) DeleteSeries(ops *DeleteSeries) (result *DeleteSer
 		// id
 		idStr := fmt.Sprintf("%d", meta.Id)
 
-		// key
-		if res := conn.Del(instance.ctx, instance.getSeriesByNameKey(Namespace(op.Namespace), meta.Name)); res.Err() != nil {
-			result.Error = res.Err()
+		// key, the name index changes together with it
+		if _, err := conn.TxPipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(instance.ctx, instance.getSeriesByNameKey(Namespace(op.Namespace), meta.Name))
+			return instance.unindexName(pipe, Namespace(op.Namespace), meta.Name)
+		}); err != nil {
+			result.Error = err
 			return
 		}
 
@@ -572,21 +747,50 @@ func (instance *RedisBackend) DeleteSeries(ops *DeleteSeries) (result *DeleteSer
 			}
 		}
 
+		// label postings
+		if err := instance.unindexLabels(conn, Namespace(op.Namespace), uint64(meta.Id), meta.Labels); err != nil {
+			result.Error = err
+			return
+		}
+
+		// namespace membership
+		if res := conn.SRem(instance.ctx, instance.getSeriesIdsKey(Namespace(op.Namespace)), idStr); res.Err() != nil {
+			result.Error = res.Err()
+			return
+		}
+
 		// meta key
 		if res := conn.Del(instance.ctx, instance.getSeriesMetaKey(Namespace(op.Namespace), uint64(meta.Id))); res.Err() != nil {
 			result.Error = res.Err()
 			return
 		}
+		if res := conn.ZRem(instance.ctx, instance.getExpiryKey(Namespace(op.Namespace)), idStr); res.Err() != nil {
+			result.Error = res.Err()
+			return
+		}
 
 		// data keys are deleted using ttl expire
 	}
 	return
 }
 
+func (instance *RedisBackend) CountSeries(count *CountSeries) (result *CountSeriesResult) {
+	result = &CountSeriesResult{}
+	conn := instance.GetConnection(Namespace(count.Namespace))
+	res := conn.SCard(instance.ctx, instance.getSeriesIdsKey(Namespace(count.Namespace)))
+	if filterNilErr(res.Err()) != nil {
+		result.Error = res.Err()
+		return
+	}
+	result.Count = int(res.Val())
+	return
+}
+
 func (instance *RedisBackend) GetConnection(namespace Namespace) redis.UniversalClient {
-	val := instance.connections[namespace]
-	if val != nil {
-		return val
+	if namespace >= 0 && int(namespace) < len(instance.connections) {
+		if val := instance.connections[namespace]; val != nil {
+			return val
+		}
 	}
 	// fallback to default connection
 	return instance.connections[RedisDefaultConnectionNamespace]
@@ -638,7 +842,7 @@ func (instance *RedisBackend) Init() error {
 					took := time.Since(startTime)
 					lastCompleted = time.Now()
 					if took > warnDuration {
-						log.Printf("WARN mini redis fast forward took %s (this simulates expiry of testing redis)", took)
+						instance.Logger().Warnf("mini redis fast forward took %s (this simulates expiry of testing redis)", took)
 					}
 				}
 			}()
@@ -669,7 +873,7 @@ func (instance *RedisBackend) Init() error {
 		instance.connections[k] = v
 	}
 
-	return nil
+	return instance.rebuildIndexes()
 }
 
 func (instance *RedisBackend) Clear() error {
@@ -0,0 +1,279 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/go-redis/redis/v8"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const redisScanCount = 1000
+
+// distinct connections, namespaces without a connection share the default one
+func (instance *RedisBackend) distinctConnections() []redis.UniversalClient {
+	seen := make(map[redis.UniversalClient]bool)
+	conns := make([]redis.UniversalClient, 0)
+	for _, conn := range instance.connections {
+		if conn == nil || seen[conn] {
+			continue
+		}
+		seen[conn] = true
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// keys matching the pattern, scans all masters of a cluster
+func (instance *RedisBackend) scanKeys(conn redis.UniversalClient, pattern string) ([]string, error) {
+	scan := func(ctx context.Context, client redis.Cmdable) ([]string, error) {
+		keys := make([]string, 0)
+		iter := client.Scan(ctx, 0, pattern, redisScanCount).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		return keys, iter.Err()
+	}
+	cluster, ok := conn.(*redis.ClusterClient)
+	if !ok {
+		return scan(instance.ctx, conn)
+	}
+	keys := make([]string, 0)
+	var keysMux sync.Mutex
+	err := cluster.ForEachMaster(instance.ctx, func(ctx context.Context, client *redis.Client) error {
+		nodeKeys, err := scan(ctx, client)
+		if err != nil {
+			return err
+		}
+		keysMux.Lock()
+		keys = append(keys, nodeKeys...)
+		keysMux.Unlock()
+		return nil
+	})
+	return keys, err
+}
+
+func (instance *RedisBackend) ListNamespaces() (result *ListNamespacesResult) {
+	result = &ListNamespacesResult{}
+	prefix := strings.TrimSuffix(instance.getSeriesIdsKey(Namespace(0)), "0")
+	seen := make(map[int]bool)
+	for _, conn := range instance.distinctConnections() {
+		keys, err := instance.scanKeys(conn, prefix+"*")
+		if err != nil {
+			result.Error = err
+			return
+		}
+		for _, key := range keys {
+			namespace, err := strconv.Atoi(strings.TrimPrefix(key, prefix))
+			if err != nil || seen[namespace] {
+				continue
+			}
+			seen[namespace] = true
+			result.Namespaces = append(result.Namespaces, namespace)
+		}
+	}
+	sort.Ints(result.Namespaces)
+	return
+}
+
+func (instance *RedisBackend) ListSeries(list *ListSeries) (result *ListSeriesResult) {
+	result = &ListSeriesResult{}
+	namespace := Namespace(list.Namespace)
+	ids := list.Ids
+	if len(ids) == 0 {
+		res := instance.GetConnection(namespace).SMembers(instance.ctx, instance.getSeriesIdsKey(namespace))
+		if filterNilErr(res.Err()) != nil {
+			result.Error = res.Err()
+			return
+		}
+		for _, idStr := range res.Val() {
+			id, err := idStrToIdUint64(idStr)
+			if err != nil {
+				result.Error = err
+				return
+			}
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+	}
+	for _, id := range ids {
+		meta, err := instance.getMetadataFromStorage(namespace, id, true)
+		if err != nil {
+			result.Error = err
+			return
+		}
+		result.Series = append(result.Series, meta)
+	}
+	return
+}
+
+// pattern of all data keys (buckets) of a series
+func (instance *RedisBackend) getDataKeysPattern(ctx Context) string {
+	return fmt.Sprintf("data_%d-%d-*", ctx.Namespace, ctx.Series)
+}
+
+func (instance *RedisBackend) DescribeSeries(context Context) (result DescribeSeriesResult) {
+	conn := instance.GetConnection(Namespace(context.Namespace))
+	keys, err := instance.scanKeys(conn, instance.getDataKeysPattern(context))
+	if err != nil {
+		result.Error = err
+		return
+	}
+	for _, key := range keys {
+		count := conn.ZCard(instance.ctx, key)
+		if count.Err() != nil {
+			result.Error = count.Err()
+			return
+		}
+		if count.Val() < 1 {
+			continue
+		}
+		first := conn.ZRangeWithScores(instance.ctx, key, 0, 0)
+		last := conn.ZRangeWithScores(instance.ctx, key, -1, -1)
+		if first.Err() != nil || last.Err() != nil || len(first.Val()) != 1 || len(last.Val()) != 1 {
+			result.Error = fmt.Errorf("failed to read range of %s", key)
+			return
+		}
+		// truncate scores to get rid of the padded decimals
+		from := uint64(first.Val()[0].Score)
+		to := uint64(last.Val()[0].Score)
+		if result.Points == 0 || from < result.From {
+			result.From = from
+		}
+		if to > result.To {
+			result.To = to
+		}
+		result.Points += int(count.Val())
+	}
+	return
+}
+
+func (instance *RedisBackend) ReadSeries(context Context) (res ReadResult) {
+	conn := instance.GetConnection(Namespace(context.Namespace))
+	keys, err := instance.scanKeys(conn, instance.getDataKeysPattern(context))
+	if err != nil {
+		res.Error = err
+		return
+	}
+	meta, err := instance.getMetadata(Namespace(context.Namespace), context.Series, true)
+	if err != nil {
+		res.Error = err
+		return
+	}
+	points := newRedisReadPoints(meta)
+	for _, key := range keys {
+		read := conn.ZRangeWithScores(instance.ctx, key, 0, -1)
+		if filterNilErr(read.Err()) != nil {
+			res.Error = read.Err()
+			return
+		}
+		for _, value := range read.Val() {
+			if err := points.add(uint64(value.Score), value.Member.(string)); err != nil {
+				res.Error = fmt.Errorf("parse value err %s,%v: %s", key, value, err)
+				return
+			}
+		}
+	}
+	return points.result()
+}
+
+func (instance *RedisBackend) DeleteSeriesData(context Context) error {
+	conn := instance.GetConnection(Namespace(context.Namespace))
+	keys, err := instance.scanKeys(conn, instance.getDataKeysPattern(context))
+	if err != nil {
+		return err
+	}
+	// one by one, buckets can be in different cluster slots
+	for _, key := range keys {
+		if res := conn.Del(instance.ctx, key); res.Err() != nil {
+			return res.Err()
+		}
+	}
+	return nil
+}
+
+func (instance *RedisBackend) FlushAll() error {
+	instance.pipelinesMux.RLock()
+	pipelines := make([]redis.Pipeliner, 0, len(instance.pipelines))
+	for _, pipeline := range instance.pipelines {
+		pipelines = append(pipelines, pipeline)
+	}
+	instance.pipelinesMux.RUnlock()
+
+	// pipelines stay registered, the request they belong to can keep writing to them
+	for _, pipeline := range pipelines {
+		if instance.pipelineObserver != nil {
+			instance.pipelineObserver(pipeline.Len())
+		}
+		if _, err := pipeline.Exec(instance.ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (instance *RedisBackend) Compact() (result CompactResult) {
+	removed := make(map[types.SeriesIdentifier]bool)
+
+	// expired series
+	namespaces := instance.ListNamespaces()
+	if namespaces.Error != nil {
+		result.Error = namespaces.Error
+		return
+	}
+	nowSeconds := nowSeconds()
+	for _, namespace := range namespaces.Namespaces {
+		series := instance.ListSeries(&ListSeries{Namespace: namespace})
+		if series.Error != nil {
+			result.Error = series.Error
+			return
+		}
+		for _, meta := range series.Series {
+			if meta.TtlExpire == 0 || meta.TtlExpire >= nowSeconds {
+				continue
+			}
+			identifier := types.SeriesIdentifier{Namespace: namespace, Id: uint64(meta.Id)}
+			if res := instance.ReverseApi().DeleteSeries(&DeleteSeries{Series: []types.SeriesIdentifier{identifier}}); res.Error != nil {
+				result.Error = res.Error
+				return
+			}
+			removed[identifier] = true
+		}
+	}
+
+	// data of series without metadata
+	for _, conn := range instance.distinctConnections() {
+		keys, err := instance.scanKeys(conn, "data_*")
+		if err != nil {
+			result.Error = err
+			return
+		}
+		for _, key := range keys {
+			var namespace int
+			var id, bucket uint64
+			if _, err := fmt.Sscanf(key, "data_%d-%d-%d", &namespace, &id, &bucket); err != nil {
+				continue
+			}
+			exists := instance.GetConnection(Namespace(namespace)).Exists(instance.ctx, instance.getSeriesMetaKey(Namespace(namespace), id))
+			if exists.Err() != nil {
+				result.Error = exists.Err()
+				return
+			}
+			if exists.Val() > 0 {
+				continue
+			}
+			if res := conn.Del(instance.ctx, key); res.Err() != nil {
+				result.Error = res.Err()
+				return
+			}
+			removed[types.SeriesIdentifier{Namespace: namespace, Id: id}] = true
+		}
+	}
+	result.Removed = len(removed)
+	return
+}
@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+// the series with a ttl of a namespace are a sorted set scored by their expiry time
+
+func (instance *RedisBackend) getExpiryKey(namespace Namespace) string {
+	return fmt.Sprintf("expiry_%d", namespace) // always prefix with namespace
+}
+
+// adds or moves the series in the queue, or removes it without ttl
+func (instance *RedisBackend) queueExpiry(conn redis.Cmdable, meta SeriesMetadata) error {
+	if meta.TtlExpire < 1 {
+		return conn.ZRem(instance.ctx, instance.getExpiryKey(meta.Namespace), uint64(meta.Id)).Err()
+	}
+	return conn.ZAdd(instance.ctx, instance.getExpiryKey(meta.Namespace), &redis.Z{Score: float64(meta.TtlExpire), Member: uint64(meta.Id)}).Err()
+}
+
+func (instance *RedisBackend) ExpireSeries(expire *ExpireSeries) (result *ExpireSeriesResult) {
+	result = &ExpireSeriesResult{}
+	namespaces := instance.ListNamespaces()
+	if namespaces.Error != nil {
+		result.Error = namespaces.Error
+		return
+	}
+	for _, namespace := range namespaces.Namespaces {
+		count := int64(0)
+		if expire.Max > 0 {
+			count = int64(expire.Max - len(result.Series))
+			if count < 1 {
+				return
+			}
+		}
+		conn := instance.GetConnection(Namespace(namespace))
+		key := instance.getExpiryKey(Namespace(namespace))
+		res := conn.ZRangeByScore(instance.ctx, key, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   "(" + strconv.FormatUint(expire.Now, 10),
+			Count: count,
+		})
+		if filterNilErr(res.Err()) != nil {
+			result.Error = res.Err()
+			return
+		}
+		for _, idStr := range res.Val() {
+			id, err := idStrToIdUint64(idStr)
+			if err != nil {
+				result.Error = err
+				return
+			}
+
+			// metadata and data
+			identifier := types.SeriesIdentifier{Namespace: namespace, Id: id}
+			deleted := instance.ReverseApi().DeleteSeries(&DeleteSeries{Series: []types.SeriesIdentifier{identifier}})
+			if errors.Cause(deleted.Error) == redis.Nil {
+				// deleted in the meantime
+				if res := conn.ZRem(instance.ctx, key, idStr); res.Err() != nil {
+					result.Error = res.Err()
+					return
+				}
+				continue
+			} else if deleted.Error != nil {
+				result.Error = deleted.Error
+				return
+			}
+			result.Series = append(result.Series, identifier)
+		}
+	}
+	return
+}
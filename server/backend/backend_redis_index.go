@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// indexes that were added after series were stored are rebuilt once from the metadata of the series on init,
+// bump the version when indexSeries indexes something new
+const redisIndexVersion = 3
+
+const redisIndexVersionKey = "index_version"
+
+// indexes of one series that can be derived from its metadata, adding a series that is already indexed is a no-op
+func (instance *RedisBackend) indexSeries(conn redis.Cmdable, meta SeriesMetadata) error {
+	// membership of namespace, used for counting
+	if err := conn.SAdd(instance.ctx, instance.getSeriesIdsKey(meta.Namespace), uint64(meta.Id)).Err(); err != nil {
+		return err
+	}
+	if err := instance.indexName(conn, meta.Namespace, meta.Name); err != nil {
+		return err
+	}
+	// series with a ttl that were stored before the expiry queue are removed by the reaper as well
+	return instance.queueExpiry(conn, meta)
+}
+
+func (instance *RedisBackend) rebuildIndexes() error {
+	for _, conn := range instance.distinctConnections() {
+		version, err := conn.Get(instance.ctx, redisIndexVersionKey).Int()
+		if filterNilErr(err) != nil {
+			return err
+		}
+		if version >= redisIndexVersion {
+			continue
+		}
+		count, err := instance.rebuildConnectionIndexes(conn)
+		if err != nil {
+			return errors.Wrap(err, "failed to rebuild indexes")
+		}
+		if res := conn.Set(instance.ctx, redisIndexVersionKey, redisIndexVersion, 0); res.Err() != nil {
+			return res.Err()
+		}
+		instance.Logger().Infof("rebuilt indexes of %d series to version %d", count, redisIndexVersion)
+	}
+	return nil
+}
+
+func (instance *RedisBackend) rebuildConnectionIndexes(conn redis.UniversalClient) (int, error) {
+	keys, err := instance.scanKeys(conn, instance.getSeriesMetaKeyPattern())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for start := 0; start < len(keys); start += redisScanCount {
+		end := start + redisScanCount
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		values := make([]*redis.StringCmd, len(batch))
+		if _, err := conn.Pipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range batch {
+				values[i] = pipe.Get(instance.ctx, key)
+			}
+			return nil
+		}); filterNilErr(err) != nil {
+			return count, err
+		}
+		if _, err := conn.Pipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range batch {
+				if values[i].Err() != nil {
+					// deleted in the meantime
+					continue
+				}
+				var meta SeriesMetadata
+				if err := json.Unmarshal([]byte(values[i].Val()), &meta); err != nil || instance.getSeriesMetaKey(meta.Namespace, uint64(meta.Id)) != key {
+					// the name key of a series whose name ends like a metadata key
+					continue
+				}
+				if err := instance.indexSeries(pipe, meta); err != nil {
+					return err
+				}
+				count++
+			}
+			return nil
+		}); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,75 @@
+package backend_test
+
+import (
+	"encoding/json"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/alicebob/miniredis/v2"
+	"testing"
+	"time"
+)
+
+// series stored before an index existed are indexed when the backend starts
+func TestRedisRebuildIndexes(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	stored := backend.SeriesMetadata{Namespace: 1, Id: 7, Name: "cpu.idle", Ttl: 60, TtlExpire: uint64(time.Now().Unix()) - 1}
+	j, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{
+		"series_1_cpu.idle":  "7",
+		"series_1_7_meta":    string(j),
+		"series_1_more_meta": "8", // name key of a series named more_meta without metadata
+	} {
+		if err := server.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := func() *backend.RedisBackend {
+		b := backend.NewRedisBackend(&backend.RedisOpts{
+			ConnectionDetails: map[backend.Namespace]backend.RedisConnectionDetails{
+				backend.RedisDefaultConnectionNamespace: {
+					Type: backend.RedisServer,
+					Addr: server.Host(),
+					Port: server.Server().Addr().Port,
+				},
+			},
+		})
+		if err := b.Init(); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	b := start()
+	if res := b.CountSeries(&backend.CountSeries{Namespace: 1}); res.Error != nil || res.Count != 1 {
+		t.Errorf("expected 1 series, %+v", res)
+	}
+	if res := b.ListNamespaces(); res.Error != nil || len(res.Namespaces) != 1 || res.Namespaces[0] != 1 {
+		t.Errorf("expected namespace 1, %+v", res)
+	}
+	search := &backend.SearchSeries{}
+	search.Namespace = 1
+	search.Name = "cpu."
+	search.NameMatch = types.NameMatchPrefix
+	search.Comparator = backend.SearchSeriesComparatorEquals
+	if res := b.SearchSeries(search); res.Error != nil || len(res.Series) != 1 || res.Series[0].Id != 7 {
+		t.Errorf("expected the series by prefix, %+v", res)
+	}
+	b.SetReverseApi(b)
+	if res := b.ExpireSeries(&backend.ExpireSeries{Now: uint64(time.Now().Unix())}); res.Error != nil || len(res.Series) != 1 || res.Series[0].Id != 7 {
+		t.Errorf("expected the expired series, %+v", res)
+	}
+
+	// only once
+	if err := server.Set("series_1_7_meta", string(j)); err != nil {
+		t.Fatal(err)
+	}
+	if res := start().CountSeries(&backend.CountSeries{Namespace: 1}); res.Error != nil || res.Count != 0 {
+		t.Errorf("expected no rebuild, %+v", res)
+	}
+}
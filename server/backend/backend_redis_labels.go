@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// label postings are sets of series ids, the values of a label and the label names are sorted sets scored by their number of series
+
+func (instance *RedisBackend) getLabelKey(namespace Namespace, name string, value string) string {
+	return fmt.Sprintf("label_%d_%s=%s", namespace, name, value) // always prefix with namespace
+}
+
+func (instance *RedisBackend) getLabelValuesKey(namespace Namespace, name string) string {
+	return fmt.Sprintf("labelvalues_%d_%s", namespace, name) // always prefix with namespace
+}
+
+func (instance *RedisBackend) getLabelNamesKey(namespace Namespace) string {
+	return fmt.Sprintf("labelnames_%d", namespace) // always prefix with namespace
+}
+
+func (instance *RedisBackend) indexLabels(conn redis.Cmdable, namespace Namespace, id uint64, labels map[string]string) error {
+	for name, value := range labels {
+		// posting first, values without series are removed
+		if res := conn.SAdd(instance.ctx, instance.getLabelKey(namespace, name, value), id); res.Err() != nil {
+			return res.Err()
+		}
+		if res := conn.ZIncrBy(instance.ctx, instance.getLabelValuesKey(namespace, name), 1, value); res.Err() != nil {
+			return res.Err()
+		}
+		if res := conn.ZIncrBy(instance.ctx, instance.getLabelNamesKey(namespace), 1, name); res.Err() != nil {
+			return res.Err()
+		}
+	}
+	return nil
+}
+
+func (instance *RedisBackend) unindexLabels(conn redis.Cmdable, namespace Namespace, id uint64, labels map[string]string) error {
+	for name, value := range labels {
+		if res := conn.SRem(instance.ctx, instance.getLabelKey(namespace, name, value), id); res.Err() != nil {
+			return res.Err()
+		}
+		// counts are only changed atomically, so only values and names without series are removed
+		for key, member := range map[string]string{instance.getLabelValuesKey(namespace, name): value, instance.getLabelNamesKey(namespace): name} {
+			if res := conn.ZIncrBy(instance.ctx, key, -1, member); res.Err() != nil {
+				return res.Err()
+			}
+			if res := conn.ZRemRangeByScore(instance.ctx, key, "-inf", "0"); res.Err() != nil {
+				return res.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// members of a sorted set of label values or names that have series, sorted
+func (instance *RedisBackend) labelMembers(namespace Namespace, key string) ([]string, error) {
+	res := instance.GetConnection(namespace).ZRangeByScore(instance.ctx, key, &redis.ZRangeBy{
+		Min: "(0",
+		Max: "+inf",
+	})
+	if filterNilErr(res.Err()) != nil {
+		return nil, res.Err()
+	}
+	values := res.Val()
+	sort.Strings(values)
+	return values, nil
+}
+
+func (instance *RedisBackend) labelValues(namespace Namespace, name string) ([]string, error) {
+	return instance.labelMembers(namespace, instance.getLabelValuesKey(namespace, name))
+}
+
+func (instance *RedisBackend) labelPostings(namespace Namespace, name string, value string) ([]uint64, error) {
+	return instance.setIds(namespace, instance.getLabelKey(namespace, name, value))
+}
+
+func (instance *RedisBackend) namespaceSeries(namespace Namespace) ([]uint64, error) {
+	return instance.setIds(namespace, instance.getSeriesIdsKey(namespace))
+}
+
+// series ids of a set
+func (instance *RedisBackend) setIds(namespace Namespace, key string) ([]uint64, error) {
+	res := instance.GetConnection(namespace).SMembers(instance.ctx, key)
+	if filterNilErr(res.Err()) != nil {
+		return nil, res.Err()
+	}
+	ids := make([]uint64, 0, len(res.Val()))
+	for _, idStr := range res.Val() {
+		id, err := idStrToIdUint64(idStr)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (instance *RedisBackend) seriesMetadata(namespace Namespace, id uint64) (*SeriesMetadata, error) {
+	meta, err := instance.getMetadata(namespace, id, true)
+	if errors.Cause(err) == redis.Nil {
+		// deleted in the meantime
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (instance *RedisBackend) ListLabels(list *ListLabels) (result *ListLabelsResult) {
+	result = &ListLabelsResult{}
+	namespace := Namespace(list.Namespace)
+	if len(list.Name) > 0 {
+		result.Values, result.Error = instance.labelValues(namespace, list.Name)
+		return
+	}
+	result.Values, result.Error = instance.labelMembers(namespace, instance.getLabelNamesKey(namespace))
+	return
+}
+
+func (instance *RedisBackend) CountLabelValues(count *CountLabelValues) (result *CountLabelValuesResult) {
+	result = &CountLabelValuesResult{}
+	namespace := Namespace(count.Namespace)
+	key := instance.getLabelValuesKey(namespace, count.Name)
+	// values without series are removed right after their count drops, skip them like labelMembers
+	var countCmd *redis.IntCmd
+	var scoreCmd *redis.FloatCmd
+	_, err := instance.GetConnection(namespace).Pipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+		countCmd = pipe.ZCount(instance.ctx, key, "(0", "+inf")
+		scoreCmd = pipe.ZScore(instance.ctx, key, count.Value)
+		return nil
+	})
+	if filterNilErr(err) != nil {
+		result.Error = err
+		return
+	}
+	if countCmd.Err() != nil {
+		result.Error = countCmd.Err()
+		return
+	}
+	result.Count = int(countCmd.Val())
+	result.Exists = scoreCmd.Val() > 0
+	return
+}
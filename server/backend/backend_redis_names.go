@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v8"
+)
+
+// the names of the series of a namespace are a sorted set with equal scores, so they are ordered lexicographically
+
+func (instance *RedisBackend) getNamesKey(namespace Namespace) string {
+	return fmt.Sprintf("names_%d", namespace) // always prefix with namespace
+}
+
+func (instance *RedisBackend) indexName(conn redis.Cmdable, namespace Namespace, name string) error {
+	return conn.ZAdd(instance.ctx, instance.getNamesKey(namespace), &redis.Z{Member: name}).Err()
+}
+
+func (instance *RedisBackend) unindexName(conn redis.Cmdable, namespace Namespace, name string) error {
+	return conn.ZRem(instance.ctx, instance.getNamesKey(namespace), name).Err()
+}
+
+func (instance *RedisBackend) namesFrom(namespace Namespace, from string, inclusive bool, count int) ([]nameEntry, string, error) {
+	conn := instance.GetConnection(namespace)
+	min := "(" + from
+	if inclusive {
+		min = "[" + from
+	}
+	res := conn.ZRangeByLex(instance.ctx, instance.getNamesKey(namespace), &redis.ZRangeBy{
+		Min:   min,
+		Max:   "+",
+		Count: int64(count),
+	})
+	if filterNilErr(res.Err()) != nil {
+		return nil, "", res.Err()
+	}
+	names := res.Val()
+	if len(names) < 1 {
+		return nil, "", nil
+	}
+	// names without a key are skipped below, so whether there are more depends on what was read
+	var last string
+	if len(names) == count {
+		last = names[len(names)-1]
+	}
+
+	// ids of the names
+	ids := make([]*redis.StringCmd, len(names))
+	_, err := conn.Pipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+		for i, name := range names {
+			ids[i] = pipe.Get(instance.ctx, instance.getSeriesByNameKey(namespace, name))
+		}
+		return nil
+	})
+	if filterNilErr(err) != nil {
+		return nil, "", err
+	}
+	entries := make([]nameEntry, 0, len(names))
+	for i, name := range names {
+		if ids[i].Err() == redis.Nil {
+			// deleted in the meantime
+			continue
+		}
+		id, err := idStrToIdUint64(ids[i].Val())
+		if err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, nameEntry{name: name, id: id})
+	}
+	return entries, last, nil
+}
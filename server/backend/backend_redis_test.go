@@ -239,6 +239,11 @@ func TestNewRedisBackendMultiConnection(t *testing.T) {
 		}
 	}
 
+	// count
+	if res := b.CountSeries(&backend.CountSeries{Namespace: 1}); res.Error != nil || res.Count != 1 {
+		t.Error(res)
+	}
+
 	// simple write
 	now := uint64(time.Now().Unix() * 1000)
 	writeVal := rand.Float64()
@@ -538,6 +543,14 @@ func TestNewRedisBackendMultiConnection(t *testing.T) {
 			}
 		}
 
+		// removed from the expiry queue
+		{
+			res := b.ExpireSeries(&backend.ExpireSeries{Now: uint64(time.Now().Unix())})
+			if res.Error != nil || len(res.Series) != 1 || res.Series[0].Id != firstResult.Id {
+				t.Error(res.Error, res.Series)
+			}
+		}
+
 		// check really removed
 		{
 			conn := b.GetConnection(1)
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	lock "github.com/bsm/redislock"
+	"github.com/go-redis/redis/v8"
+	"github.com/jinzhu/now"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func (instance *RedisBackend) UpdateSeries(update *UpdateSeries) (result *UpdateSeriesResult) {
+	result = &UpdateSeriesResult{}
+	namespace := Namespace(update.Namespace)
+	conn := instance.GetConnection(namespace)
+	metaKey := instance.getSeriesMetaKey(namespace, update.Id)
+
+	// one update of a series at a time
+	updateLock, err := lock.Obtain(instance.ctx, conn, "lock_"+metaKey, defaultExpiryTime, nil)
+	if err != nil || updateLock == nil {
+		result.Error = fmt.Errorf("failed to obtain metadata lock %v", err)
+		return
+	}
+	defer func() {
+		_ = updateLock.Release(instance.ctx)
+	}()
+
+	// not cached, the update is based on the latest metadata
+	existing, err := instance.getMetadataFromStorage(namespace, update.Id, true)
+	if errors.Cause(err) == redis.Nil {
+		result.Error = types.RpcErrorSeriesNotFound.Error()
+		return
+	} else if err != nil {
+		result.Error = err
+		return
+	}
+	updated, changed := existing.applyUpdate(update)
+	result.Metadata = updated
+	if !changed {
+		return
+	}
+
+	// claim the new name, in the lock of series creation with that name
+	renamed := updated.Name != existing.Name
+	newNameKey := instance.getSeriesByNameKey(namespace, updated.Name)
+	if renamed {
+		nameLock, err := lock.Obtain(instance.ctx, conn, "lock_"+newNameKey, defaultExpiryTime, nil)
+		if err != nil || nameLock == nil {
+			result.Error = fmt.Errorf("failed to obtain metadata lock %v", err)
+			return
+		}
+		defer func() {
+			_ = nameLock.Release(instance.ctx)
+		}()
+		claim := conn.SetNX(instance.ctx, newNameKey, fmt.Sprintf("%d", update.Id), 0)
+		if claim.Err() != nil {
+			result.Error = claim.Err()
+			return
+		}
+		if !claim.Val() {
+			result.Error = types.RpcErrorSeriesNameExists.Error()
+			return
+		}
+	}
+
+	// metadata, name index and tag sets in one transaction
+	j, err := json.Marshal(updated)
+	if err != nil {
+		panic(err)
+	}
+	idStr := fmt.Sprintf("%d", update.Id)
+	_, err = conn.TxPipelined(instance.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(instance.ctx, metaKey, string(j), 0)
+		if renamed {
+			pipe.Del(instance.ctx, instance.getSeriesByNameKey(namespace, existing.Name))
+			if err := instance.unindexName(pipe, namespace, existing.Name); err != nil {
+				return err
+			}
+			if err := instance.indexName(pipe, namespace, updated.Name); err != nil {
+				return err
+			}
+		}
+		for _, tag := range existing.Tags {
+			if !hasTag(updated.Tags, tag) {
+				pipe.SRem(instance.ctx, instance.getTagKey(namespace, tag), idStr)
+			}
+		}
+		for _, tag := range updated.Tags {
+			if !hasTag(existing.Tags, tag) {
+				pipe.SAdd(instance.ctx, instance.getTagKey(namespace, tag), idStr)
+			}
+		}
+		if updated.TtlExpire != existing.TtlExpire {
+			if err := instance.queueExpiry(pipe, updated); err != nil {
+				return err
+			}
+		}
+		removed, added := labelChanges(existing.Labels, updated.Labels)
+		if err := instance.unindexLabels(pipe, namespace, update.Id, removed); err != nil {
+			return err
+		}
+		return instance.indexLabels(pipe, namespace, update.Id, added)
+	})
+	if err != nil {
+		if renamed {
+			// release the claimed name
+			conn.Del(instance.ctx, newNameKey)
+		}
+		result.Error = err
+		return
+	}
+	instance.metadataCache.DeletePrefix(metaKey) // wipe metadata cache
+
+	// data keys expire with the series
+	if updated.TtlExpire != existing.TtlExpire {
+		if err := instance.expireData(conn, updated); err != nil {
+			result.Error = err
+			return
+		}
+	}
+	result.Changed = true
+	return
+}
+
+// expiry of the data keys of a series
+func dataExpireTime(meta SeriesMetadata) time.Time {
+	if meta.TtlExpire == 0 {
+		return now.EndOfDay()
+	}
+	return time.Unix(int64(meta.TtlExpire), 0)
+}
+
+// rewrites the expiry of all data keys of the series
+func (instance *RedisBackend) expireData(conn redis.UniversalClient, meta SeriesMetadata) error {
+	keys, err := instance.scanKeys(conn, instance.getDataKeysPattern(Context{Namespace: int(meta.Namespace), Series: uint64(meta.Id)}))
+	if err != nil {
+		return err
+	}
+	expireTime := dataExpireTime(meta)
+	for _, key := range keys {
+		if res := conn.ExpireAt(instance.ctx, key, expireTime); res.Err() != nil {
+			return res.Err()
+		}
+	}
+	// next write sets the expiry again
+	instance.expireWrittenCache.Delete(fmt.Sprintf("%d", meta.Id))
+	return nil
+}
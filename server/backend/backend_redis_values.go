@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"strconv"
+	"strings"
+)
+
+// member value of a series that is not float
+func encodeValue(valueType types.ValueType, value types.Value) (string, error) {
+	switch valueType {
+	case types.ValueTypeInt:
+		return strconv.FormatInt(value.Int, 10), nil
+	case types.ValueTypeBool:
+		if value.Bool {
+			return "1", nil
+		}
+		return "0", nil
+	case types.ValueTypeString:
+		return value.String, nil
+	case types.ValueTypeHistogram:
+		b, err := json.Marshal(value.Histogram)
+		return string(b), err
+	case types.ValueTypeFields:
+		// one member for all fields
+		fields := make([]string, len(value.Fields))
+		for i, field := range value.Fields {
+			fields[i] = FloatToString(field)
+		}
+		return strings.Join(fields, ","), nil
+	}
+	return "", fmt.Errorf("%s: can not encode %s values", types.RpcErrorValueTypeMismatch, valueType)
+}
+
+func decodeValue(valueType types.ValueType, encoded string) (value types.Value, err error) {
+	switch valueType {
+	case types.ValueTypeInt:
+		value.Int, err = strconv.ParseInt(encoded, 10, 64)
+	case types.ValueTypeBool:
+		value.Bool = encoded == "1"
+	case types.ValueTypeString:
+		value.String = encoded
+	case types.ValueTypeHistogram:
+		value.Histogram = &types.Histogram{}
+		err = json.Unmarshal([]byte(encoded), value.Histogram)
+	case types.ValueTypeFields:
+		fields := strings.Split(encoded, ",")
+		value.Fields = make([]float64, len(fields))
+		for i, field := range fields {
+			if value.Fields[i], err = parseFloatString(field); err != nil {
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("%s: can not decode %s values", types.RpcErrorValueTypeMismatch, valueType)
+	}
+	return
+}
+
+// points of a read, decoded by the value type of the series
+type redisReadPoints struct {
+	valueType    types.ValueType
+	fields       []string
+	results      map[uint64]float64
+	typedResults map[uint64]types.Value
+}
+
+func newRedisReadPoints(meta SeriesMetadata) *redisReadPoints {
+	return &redisReadPoints{
+		valueType: meta.ValueType,
+		fields:    meta.Fields,
+	}
+}
+
+func (points *redisReadPoints) add(ts uint64, member string) error {
+	if !points.valueType.Typed() {
+		value, err := parseMemberValue(member)
+		if err != nil {
+			return err
+		}
+		if points.results == nil {
+			points.results = make(map[uint64]float64)
+		}
+		points.results[ts] = value
+		return nil
+	}
+	value, err := decodeValue(points.valueType, memberValue(member))
+	if err != nil {
+		return err
+	}
+	if points.typedResults == nil {
+		points.typedResults = make(map[uint64]types.Value)
+	}
+	points.typedResults[ts] = value
+	return nil
+}
+
+func (points *redisReadPoints) len() int {
+	return len(points.results) + len(points.typedResults)
+}
+
+func (points *redisReadPoints) result() ReadResult {
+	return ReadResult{
+		Results:      points.results,
+		TypedResults: points.typedResults,
+		ValueType:    points.valueType,
+		Fields:       points.fields,
+	}
+}
@@ -17,7 +17,8 @@ type ContextWrite struct {
 
 type ContextRead struct {
 	Context
-	From uint64
-	To   uint64
+	From  uint64
+	To    uint64
+	Limit int // max points, the first points of the range are returned, 0 for all
 	// @todo rollup and such
 }
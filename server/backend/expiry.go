@@ -0,0 +1,18 @@
+package backend
+
+import "github.com/RobinUS2/tsxdb/rpc/types"
+
+// optional operation of a metadata backend, it keeps the series with a ttl in a queue ordered by their expiry time
+type IExpiringBackend interface {
+	ExpireSeries(expire *ExpireSeries) *ExpireSeriesResult // deletes expired series through the reverse api, which deletes their data as well
+}
+
+type ExpireSeries struct {
+	Now uint64 // seconds, series that expired before are deleted
+	Max int    // series per call, 0 for all
+}
+
+type ExpireSeriesResult struct {
+	Series []types.SeriesIdentifier // expired, deleted unless there is an error
+	Error  error
+}
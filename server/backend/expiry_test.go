@@ -0,0 +1,117 @@
+package backend_test
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"testing"
+	"time"
+)
+
+// deletes data and metadata, like the server does
+type expiryReverseApi struct {
+	backend interface {
+		backend.IMetadata
+		backend.IAdminBackend
+	}
+}
+
+func (api expiryReverseApi) DeleteSeries(ops *backend.DeleteSeries) *backend.DeleteSeriesResult {
+	for _, series := range ops.Series {
+		if err := api.backend.DeleteSeriesData(backend.Context{Namespace: series.Namespace, Series: series.Id}); err != nil {
+			return &backend.DeleteSeriesResult{Error: err}
+		}
+	}
+	return api.backend.DeleteSeries(ops)
+}
+
+func TestExpireSeries(t *testing.T) {
+	for name, b := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			expiring := b.(backend.IExpiringBackend)
+			admin := b.(backend.IAdminBackend)
+			b.SetReverseApi(expiryReverseApi{backend: struct {
+				backend.IMetadata
+				backend.IAdminBackend
+			}{b, admin}})
+			exists := func(series types.SeriesIdentifier) bool {
+				return b.ListSeries(&backend.ListSeries{Namespace: series.Namespace, Ids: []uint64{series.Id}}).Error == nil
+			}
+			ids := make(map[string]uint64)
+			for _, series := range []types.SeriesMetadata{
+				{Namespace: 1, Name: "short", Ttl: 60},
+				{Namespace: 1, Name: "long", Ttl: 3600},
+				{Namespace: 1, Name: "forever"},
+				{Namespace: 2, Name: "other", Ttl: 60},
+				{Namespace: 1, Name: "changed", Ttl: 60},
+			} {
+				res := b.CreateOrUpdateSeries(&backend.CreateSeries{
+					Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+						1: {SeriesMetadata: series, SeriesCreateIdentifier: 1},
+					},
+				})
+				if res.Error != nil || res.Results[1].Error != nil {
+					t.Fatal(res.Error, res.Results[1].Error)
+				}
+				ids[series.Name] = res.Results[1].Id
+				requestId := backend.NewRequestId()
+				if err := b.Write(backend.ContextWrite{Context: backend.Context{Namespace: series.Namespace, Series: res.Results[1].Id, RequestId: requestId}}, []uint64{1000}, []float64{1}); err != nil {
+					t.Fatal(err)
+				}
+				if err := b.FlushPendingWrites(requestId); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// a stale queue entry, the series lives longer now
+			if res := b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: ids["changed"], SetTtl: true, Ttl: 7200}); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			now := uint64(time.Now().Unix())
+
+			// nothing expired yet
+			if res := expiring.ExpireSeries(&backend.ExpireSeries{Now: now}); res.Error != nil || len(res.Series) != 0 {
+				t.Errorf("unexpected expiry %+v", res)
+			}
+
+			// limited per call
+			res := expiring.ExpireSeries(&backend.ExpireSeries{Now: now + 120, Max: 1})
+			if res.Error != nil || len(res.Series) != 1 {
+				t.Errorf("unexpected expiry %+v", res)
+			}
+			expired := len(res.Series)
+			res = expiring.ExpireSeries(&backend.ExpireSeries{Now: now + 120})
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			expired += len(res.Series)
+			if expired != 2 {
+				t.Errorf("expected short and other to expire, was %d", expired)
+			}
+			for _, series := range []types.SeriesIdentifier{{Namespace: 1, Id: ids["short"]}, {Namespace: 2, Id: ids["other"]}} {
+				if exists(series) {
+					t.Errorf("expected %+v to be deleted", series)
+				}
+				if described := admin.DescribeSeries(backend.Context{Namespace: series.Namespace, Series: series.Id}); described.Points != 0 {
+					t.Errorf("expected data of %+v to be deleted %+v", series, described)
+				}
+			}
+			for _, name := range []string{"long", "forever", "changed"} {
+				series := types.SeriesIdentifier{Namespace: 1, Id: ids[name]}
+				if !exists(series) {
+					t.Errorf("expected %s to be kept", name)
+				}
+				if described := admin.DescribeSeries(backend.Context{Namespace: 1, Series: series.Id}); described.Points != 1 {
+					t.Errorf("expected data of %s to be kept %+v", name, described)
+				}
+			}
+
+			// the updated ttl applies
+			res = expiring.ExpireSeries(&backend.ExpireSeries{Now: now + 7201})
+			if res.Error != nil || len(res.Series) != 2 {
+				t.Errorf("expected long and changed to expire %+v", res)
+			}
+			if !exists(types.SeriesIdentifier{Namespace: 1, Id: ids["forever"]}) {
+				t.Error("expected series without ttl to be kept")
+			}
+		})
+	}
+}
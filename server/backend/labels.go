@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"sort"
+)
+
+// label index of a backend, used to resolve label matchers
+type labelIndex interface {
+	labelValues(namespace Namespace, name string) ([]string, error)
+	labelPostings(namespace Namespace, name string, value string) ([]uint64, error)
+	namespaceSeries(namespace Namespace) ([]uint64, error)
+	seriesMetadata(namespace Namespace, id uint64) (*SeriesMetadata, error) // nil if it does not exist
+}
+
+// series of the namespace matching all label matchers, and the name and tag of the search if set
+func searchLabels(index labelIndex, search SearchSeriesElement) ([]types.SeriesIdentifier, error) {
+	namespace := Namespace(search.Namespace)
+	matches, err := compileMatchers(search.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	// candidates from the postings of the matchers that require the label, the others match series without it
+	var candidates map[uint64]bool
+	for i, matcher := range search.Matchers {
+		if matches[i]("") {
+			continue
+		}
+		values := []string{matcher.Value}
+		if matcher.Type != types.MatchEqual {
+			var err error
+			if values, err = index.labelValues(namespace, matcher.Name); err != nil {
+				return nil, err
+			}
+		}
+		ids := make(map[uint64]bool)
+		for _, value := range values {
+			if !matches[i](value) {
+				continue
+			}
+			postings, err := index.labelPostings(namespace, matcher.Name, value)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range postings {
+				if candidates == nil || candidates[id] {
+					ids[id] = true
+				}
+			}
+		}
+		if len(ids) < 1 {
+			return nil, nil
+		}
+		candidates = ids
+	}
+	var ids []uint64
+	if candidates == nil {
+		var err error
+		if ids, err = index.namespaceSeries(namespace); err != nil {
+			return nil, err
+		}
+	} else {
+		ids = make([]uint64, 0, len(candidates))
+		for id := range candidates {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	// all matchers on the labels of the candidates
+	var result []types.SeriesIdentifier
+	for _, id := range ids {
+		meta, err := index.seriesMetadata(namespace, id)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil || (search.Name != "" && meta.Name != search.Name) || (search.Tag != "" && !hasTag(meta.Tags, search.Tag)) {
+			continue
+		}
+		if labelsMatch(search.Matchers, matches, meta.Labels) {
+			result = append(result, types.SeriesIdentifier{
+				Namespace: search.Namespace,
+				Id:        id,
+			})
+		}
+	}
+	return result, nil
+}
+
+func compileMatchers(matchers []types.LabelMatcher) ([]func(string) bool, error) {
+	matches := make([]func(string) bool, len(matchers))
+	for i, matcher := range matchers {
+		match, err := matcher.Compile()
+		if err != nil {
+			return nil, err
+		}
+		matches[i] = match
+	}
+	return matches, nil
+}
+
+// labels of a series match all compiled matchers
+func labelsMatch(matchers []types.LabelMatcher, matches []func(string) bool, labels map[string]string) bool {
+	for i, matcher := range matchers {
+		if !matches[i](labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
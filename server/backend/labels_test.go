@@ -0,0 +1,130 @@
+package backend_test
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"reflect"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	for name, b := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.SetReverseApi(b)
+			ids := make(map[string]uint64)
+			for name, labels := range map[string]map[string]string{
+				"cpu.web1": {"host": "web-1", "env": "prod"},
+				"cpu.web2": {"host": "web-2", "env": "staging"},
+				"cpu.db1":  {"host": "db-1", "env": "prod"},
+				"uptime":   nil,
+			} {
+				res := b.CreateOrUpdateSeries(&backend.CreateSeries{
+					Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+						1: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: name, Labels: labels}, SeriesCreateIdentifier: 1},
+					},
+				})
+				if res.Error != nil || res.Results[1].Error != nil {
+					t.Fatal(res.Error, res.Results[1].Error)
+				}
+				ids[name] = res.Results[1].Id
+			}
+			search := func(matchers ...types.LabelMatcher) []uint64 {
+				s := &backend.SearchSeries{}
+				s.Namespace = 1
+				s.Matchers = matchers
+				s.Comparator = backend.SearchSeriesComparatorEquals
+				res := b.SearchSeries(s)
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				found := make([]uint64, 0)
+				for _, series := range res.Series {
+					found = append(found, series.Id)
+				}
+				return found
+			}
+			for _, test := range []struct {
+				matchers []types.LabelMatcher
+				expected []string
+			}{
+				{[]types.LabelMatcher{{Name: "env", Type: types.MatchEqual, Value: "prod"}}, []string{"cpu.web1", "cpu.db1"}},
+				{[]types.LabelMatcher{{Name: "env", Type: types.MatchNotEqual, Value: "prod"}}, []string{"cpu.web2", "uptime"}},
+				{[]types.LabelMatcher{{Name: "host", Type: types.MatchRegexp, Value: "web-.*"}}, []string{"cpu.web1", "cpu.web2"}},
+				{[]types.LabelMatcher{{Name: "host", Type: types.MatchNotRegexp, Value: "web-.*"}}, []string{"cpu.db1", "uptime"}},
+				{[]types.LabelMatcher{{Name: "host", Type: types.MatchRegexp, Value: ".+"}, {Name: "env", Type: types.MatchEqual, Value: "prod"}, {Name: "host", Type: types.MatchNotEqual, Value: "db-1"}}, []string{"cpu.web1"}},
+				{[]types.LabelMatcher{{Name: "host", Type: types.MatchEqual, Value: ""}}, []string{"uptime"}},
+				{[]types.LabelMatcher{{Name: "host", Type: types.MatchEqual, Value: "web"}}, []string{}},
+			} {
+				expected := make([]uint64, 0)
+				for _, name := range test.expected {
+					expected = append(expected, ids[name])
+				}
+				if found := search(test.matchers...); !sameIds(found, expected) {
+					t.Errorf("%v: expected %v, found %v", test.matchers, expected, found)
+				}
+			}
+			s := &backend.SearchSeries{}
+			s.Namespace = 1
+			s.Matchers = []types.LabelMatcher{{Name: "host", Type: types.MatchRegexp, Value: "("}}
+			s.Comparator = backend.SearchSeriesComparatorEquals
+			if res := b.SearchSeries(s); res.Error == nil {
+				t.Error("expected invalid regex error")
+			}
+
+			// enumeration follows updates and deletes
+			list := func(name string) []string {
+				res := b.ListLabels(&backend.ListLabels{Namespace: 1, Name: name})
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				return res.Values
+			}
+			if names := list(""); !reflect.DeepEqual(names, []string{"env", "host"}) {
+				t.Errorf("unexpected label names %v", names)
+			}
+			if values := list("env"); !reflect.DeepEqual(values, []string{"prod", "staging"}) {
+				t.Errorf("unexpected label values %v", values)
+			}
+			if res := b.CountLabelValues(&backend.CountLabelValues{Namespace: 1, Name: "env", Value: "staging"}); res.Error != nil || res.Count != 2 || !res.Exists {
+				t.Errorf("unexpected label value count %+v", res)
+			}
+			if res := b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: ids["cpu.web2"], SetLabels: map[string]string{"env": "prod", "rack": "a"}}); res.Error != nil || !res.Changed {
+				t.Fatalf("unexpected update %+v", res)
+			}
+			if values := list("env"); !reflect.DeepEqual(values, []string{"prod"}) {
+				t.Errorf("unexpected label values after update %v", values)
+			}
+			if res := b.CountLabelValues(&backend.CountLabelValues{Namespace: 1, Name: "env", Value: "staging"}); res.Error != nil || res.Count != 1 || res.Exists {
+				t.Errorf("unexpected label value count after update %+v", res)
+			}
+			if found := search(types.LabelMatcher{Name: "env", Type: types.MatchEqual, Value: "prod"}); len(found) != 3 {
+				t.Errorf("expected 3 prod series, found %v", found)
+			}
+			if res := b.DeleteSeries(&backend.DeleteSeries{Series: []types.SeriesIdentifier{{Namespace: 1, Id: ids["cpu.web2"]}}}); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			if names := list(""); !reflect.DeepEqual(names, []string{"env", "host"}) {
+				t.Errorf("unexpected label names after delete %v", names)
+			}
+			if values := list("host"); !reflect.DeepEqual(values, []string{"db-1", "web-1"}) {
+				t.Errorf("unexpected label values after delete %v", values)
+			}
+		})
+	}
+}
+
+func sameIds(a []uint64, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uint64]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -8,6 +8,10 @@ func (meta *Metadata) CreateOrUpdateSeries(create *CreateSeries) *CreateSeriesRe
 	return meta.backend.CreateOrUpdateSeries(create)
 }
 
+func (meta *Metadata) UpdateSeries(update *UpdateSeries) *UpdateSeriesResult {
+	return meta.backend.UpdateSeries(update)
+}
+
 func (meta *Metadata) SearchSeries(search *SearchSeries) *SearchSeriesResult {
 	return meta.backend.SearchSeries(search)
 }
@@ -16,6 +20,34 @@ func (meta *Metadata) DeleteSeries(delete *DeleteSeries) *DeleteSeriesResult {
 	return meta.backend.DeleteSeries(delete)
 }
 
+func (meta *Metadata) CountSeries(count *CountSeries) *CountSeriesResult {
+	return meta.backend.CountSeries(count)
+}
+
+func (meta *Metadata) ListNamespaces() *ListNamespacesResult {
+	return meta.backend.ListNamespaces()
+}
+
+func (meta *Metadata) ListSeries(list *ListSeries) *ListSeriesResult {
+	return meta.backend.ListSeries(list)
+}
+
+func (meta *Metadata) ListLabels(list *ListLabels) *ListLabelsResult {
+	return meta.backend.ListLabels(list)
+}
+
+func (meta *Metadata) CountLabelValues(count *CountLabelValues) *CountLabelValuesResult {
+	return meta.backend.CountLabelValues(count)
+}
+
+// nothing expires if the backend has no expiry queue
+func (meta *Metadata) ExpireSeries(expire *ExpireSeries) *ExpireSeriesResult {
+	if expiring, ok := meta.backend.(IExpiringBackend); ok {
+		return expiring.ExpireSeries(expire)
+	}
+	return &ExpireSeriesResult{}
+}
+
 func (meta *Metadata) Clear() error {
 	return meta.backend.Clear()
 }
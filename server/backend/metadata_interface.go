@@ -5,14 +5,35 @@ import (
 )
 
 type IMetadata interface {
-	CreateOrUpdateSeries(*CreateSeries) *CreateSeriesResult // create/update new series (batch)
-	SearchSeries(*SearchSeries) *SearchSeriesResult         // search one or multiple series by tags
-	DeleteSeries(*DeleteSeries) *DeleteSeriesResult         // remove series (batch)
-	Clear() error                                           // clear all data, mainly used for testing
+	CreateOrUpdateSeries(*CreateSeries) *CreateSeriesResult     // create/update new series (batch)
+	UpdateSeries(*UpdateSeries) *UpdateSeriesResult             // change tags, ttl or name of an existing series
+	SearchSeries(*SearchSeries) *SearchSeriesResult             // search one or multiple series by tags
+	DeleteSeries(*DeleteSeries) *DeleteSeriesResult             // remove series (batch)
+	CountSeries(*CountSeries) *CountSeriesResult                // number of series in a namespace
+	ListNamespaces() *ListNamespacesResult                      // namespaces that contain series
+	ListSeries(*ListSeries) *ListSeriesResult                   // metadata of (selected) series in a namespace
+	ListLabels(*ListLabels) *ListLabelsResult                   // label names of a namespace or the values of one label
+	CountLabelValues(*CountLabelValues) *CountLabelValuesResult // number of values of one label and whether a value exists
+	Clear() error                                               // clear all data, mainly used for testing
 }
 
 type CreateSeries struct {
-	Series map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata
+	Series  map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata
+	Creator *SeriesCreator // optional, recorded on series that are created
+	Created uint64         // optional, creation time in seconds of series that are created (e.g. restored), now if 0
+}
+
+func (create *CreateSeries) created() uint64 {
+	if create.Created > 0 {
+		return create.Created
+	}
+	return nowSeconds()
+}
+
+// who created a series, used for cardinality reports
+type SeriesCreator struct {
+	User    string
+	Session int
 }
 
 type CreateSeriesResult struct {
@@ -20,12 +41,39 @@ type CreateSeriesResult struct {
 	Error   error
 }
 
+type UpdateSeries struct {
+	Namespace    int
+	Id           uint64
+	AddTags      []string
+	RemoveTags   []string
+	SetTtl       bool              // change the ttl to Ttl, 0 removes the ttl
+	Ttl          uint              // relative time in seconds
+	Rename       string            // new name, empty to keep the name
+	SetLabels    map[string]string // added or changed labels
+	RemoveLabels []string
+}
+
+// update of an existing series of a create request
+type identifiedUpdate struct {
+	identifier types.SeriesCreateIdentifier
+	update     *UpdateSeries
+}
+
+type UpdateSeriesResult struct {
+	Metadata SeriesMetadata // after the update
+	Changed  bool           // false if the series already had this metadata
+	Error    error
+}
+
 type SearchSeries struct {
 	SearchSeriesElement
+	After string // name patterns: page after this name
+	Limit int    // name patterns: series per page, 0 for all
 }
 
 type SearchSeriesResult struct {
-	Series []types.SeriesIdentifier
+	Series []types.SeriesIdentifier // name patterns: in name order
+	Next   string                   // name patterns: After of the next page, empty for the last page
 	Error  error
 }
 
@@ -37,10 +85,58 @@ type DeleteSeriesResult struct {
 	Error error
 }
 
+type CountSeries struct {
+	Namespace int
+}
+
+type CountSeriesResult struct {
+	Count int
+	Error error
+}
+
+type ListNamespacesResult struct {
+	Namespaces []int
+	Error      error
+}
+
+type ListSeries struct {
+	Namespace int
+	Ids       []uint64 // optional, all series of the namespace if empty
+}
+
+type ListSeriesResult struct {
+	Series []SeriesMetadata
+	Error  error
+}
+
+type ListLabels struct {
+	Namespace int
+	Name      string // values of this label, empty for the label names
+}
+
+type ListLabelsResult struct {
+	Values []string // sorted
+	Error  error
+}
+
+type CountLabelValues struct {
+	Namespace int
+	Name      string
+	Value     string
+}
+
+type CountLabelValuesResult struct {
+	Count  int  // distinct values of the label that have series
+	Exists bool // the value has series
+	Error  error
+}
+
 type SearchSeriesElement struct {
 	Namespace  int
 	Name       string
+	NameMatch  types.NameMatchType // exact by default, patterns use the name index
 	Tag        string
+	Matchers   []types.LabelMatcher // all have to match
 	Comparator SearchSeriesComparator
 	And        []SearchSeriesElement
 	Or         []SearchSeriesElement
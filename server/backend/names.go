@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"strings"
+)
+
+// names read from the index at once while searching
+const namesBatchSize = 1000
+
+// sorted name index of a backend, used to resolve name patterns
+type nameIndex interface {
+	namesFrom(namespace Namespace, from string, inclusive bool, count int) (entries []nameEntry, lastRead string, err error) // in lexicographic order, lastRead is the last name read if there may be more, empty at the end of the index
+	seriesMetadata(namespace Namespace, id uint64) (*SeriesMetadata, error)                                                  // nil if it does not exist
+}
+
+type nameEntry struct {
+	name string
+	id   uint64
+}
+
+// series of the namespace with a name matching the pattern of the search, and the tag and label matchers if set
+// in name order, a page of at most the limit of the search after its After name, next is set if there are more
+func searchNames(index nameIndex, search *SearchSeries) (series []types.SeriesIdentifier, next string, err error) {
+	namespace := Namespace(search.Namespace)
+	matchName, prefix, err := types.NameMatcher{Type: search.NameMatch, Pattern: search.Name}.Compile()
+	if err != nil {
+		return nil, "", err
+	}
+	matches, err := compileMatchers(search.Matchers)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// all matching names share the prefix, so only that range of the index is read
+	from, inclusive := prefix, true
+	if len(search.After) > 0 && search.After >= prefix {
+		from, inclusive = search.After, false
+	}
+	var last string
+	for {
+		entries, lastRead, err := index.namesFrom(namespace, from, inclusive, namesBatchSize)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.name, prefix) {
+				return series, "", nil
+			}
+			if !matchName(entry.name) {
+				continue
+			}
+			if search.Tag != "" || len(search.Matchers) > 0 {
+				meta, err := index.seriesMetadata(namespace, entry.id)
+				if err != nil {
+					return nil, "", err
+				}
+				if meta == nil || (search.Tag != "" && !hasTag(meta.Tags, search.Tag)) || !labelsMatch(search.Matchers, matches, meta.Labels) {
+					continue
+				}
+			}
+			if search.Limit > 0 && len(series) == search.Limit {
+				// another match, so there is a next page
+				return series, last, nil
+			}
+			series = append(series, types.SeriesIdentifier{
+				Namespace: search.Namespace,
+				Id:        entry.id,
+			})
+			last = entry.name
+		}
+		if len(lastRead) < 1 {
+			return series, "", nil
+		}
+		from, inclusive = lastRead, false
+	}
+}
@@ -0,0 +1,177 @@
+package backend_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/go-redis/redis/v8"
+	"reflect"
+	"testing"
+)
+
+func TestSearchNames(t *testing.T) {
+	for name, b := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.SetReverseApi(b)
+			names := make(map[types.SeriesIdentifier]string) // ids are unique per namespace only
+			ids := make(map[string]uint64)
+			for _, series := range []types.SeriesMetadata{
+				{Namespace: 1, Name: "cpu.web1.idle", Tags: []string{"hot"}},
+				{Namespace: 1, Name: "cpu.web1.user"},
+				{Namespace: 1, Name: "cpu.web2.idle"},
+				{Namespace: 1, Name: "cpu.db1.idle", Labels: map[string]string{"role": "db"}},
+				{Namespace: 1, Name: "mem.web1.free"},
+				{Namespace: 1, Name: "cpuload"},
+				{Namespace: 2, Name: "cpu.other.idle"},
+			} {
+				res := b.CreateOrUpdateSeries(&backend.CreateSeries{
+					Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+						1: {SeriesMetadata: series, SeriesCreateIdentifier: 1},
+					},
+				})
+				if res.Error != nil || res.Results[1].Error != nil {
+					t.Fatal(res.Error, res.Results[1].Error)
+				}
+				names[types.SeriesIdentifier{Namespace: series.Namespace, Id: res.Results[1].Id}] = series.Name
+				ids[series.Name] = res.Results[1].Id
+			}
+			search := func(s *backend.SearchSeries) (found []string, next string) {
+				s.Namespace = 1
+				s.Comparator = backend.SearchSeriesComparatorEquals
+				res := b.SearchSeries(s)
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				found = make([]string, 0)
+				for _, series := range res.Series {
+					found = append(found, names[series])
+				}
+				return found, res.Next
+			}
+			pattern := func(match types.NameMatchType, pattern string) *backend.SearchSeries {
+				s := &backend.SearchSeries{}
+				s.Name = pattern
+				s.NameMatch = match
+				return s
+			}
+
+			// patterns, in name order
+			for _, test := range []struct {
+				search   *backend.SearchSeries
+				expected []string
+			}{
+				{pattern(types.NameMatchGlob, "cpu.*.idle"), []string{"cpu.db1.idle", "cpu.web1.idle", "cpu.web2.idle"}},
+				{pattern(types.NameMatchGlob, "cpu.web?.idle"), []string{"cpu.web1.idle", "cpu.web2.idle"}},
+				{pattern(types.NameMatchGlob, "cpu.{web1,db1}.idle"), []string{"cpu.db1.idle", "cpu.web1.idle"}},
+				{pattern(types.NameMatchGlob, "cpu.web[2-9].*"), []string{"cpu.web2.idle"}},
+				{pattern(types.NameMatchGlob, "*"), []string{"cpuload"}},
+				{pattern(types.NameMatchPrefix, "cpu."), []string{"cpu.db1.idle", "cpu.web1.idle", "cpu.web1.user", "cpu.web2.idle"}},
+				{pattern(types.NameMatchRegexp, `(cpu|mem)\.web1\..*`), []string{"cpu.web1.idle", "cpu.web1.user", "mem.web1.free"}},
+				{pattern(types.NameMatchRegexp, `cpu`), []string{}},
+				{pattern(types.NameMatchPrefix, "disk"), []string{}},
+			} {
+				if found, next := search(test.search); !reflect.DeepEqual(found, test.expected) || next != "" {
+					t.Errorf("%s %s: expected %v, found %v next %q", test.search.NameMatch, test.search.Name, test.expected, found, next)
+				}
+			}
+
+			// narrowed down by tag and labels
+			tagged := pattern(types.NameMatchPrefix, "cpu")
+			tagged.Tag = "hot"
+			if found, _ := search(tagged); !reflect.DeepEqual(found, []string{"cpu.web1.idle"}) {
+				t.Errorf("tag: %v", found)
+			}
+			labelled := pattern(types.NameMatchGlob, "cpu.*.idle")
+			labelled.Matchers = []types.LabelMatcher{{Name: "role", Type: types.MatchEqual, Value: "db"}}
+			if found, _ := search(labelled); !reflect.DeepEqual(found, []string{"cpu.db1.idle"}) {
+				t.Errorf("labels: %v", found)
+			}
+
+			// pages
+			var pages [][]string
+			after := ""
+			for {
+				page := pattern(types.NameMatchPrefix, "")
+				page.After = after
+				page.Limit = 4
+				found, next := search(page)
+				pages = append(pages, found)
+				if next == "" {
+					break
+				}
+				after = next
+			}
+			if !reflect.DeepEqual(pages, [][]string{{"cpu.db1.idle", "cpu.web1.idle", "cpu.web1.user", "cpu.web2.idle"}, {"cpuload", "mem.web1.free"}}) {
+				t.Errorf("pages: %v", pages)
+			}
+			page := pattern(types.NameMatchPrefix, "cpu.")
+			page.After = "cpu.web1.idle"
+			page.Limit = 2
+			if found, next := search(page); !reflect.DeepEqual(found, []string{"cpu.web1.user", "cpu.web2.idle"}) || next != "" {
+				t.Errorf("page after: %v next %q", found, next)
+			}
+
+			// renames and deletes are reflected in the index
+			if res := b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: ids["cpuload"], Rename: "load"}); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			names[types.SeriesIdentifier{Namespace: 1, Id: ids["cpuload"]}] = "load"
+			if res := b.DeleteSeries(&backend.DeleteSeries{Series: []types.SeriesIdentifier{{Namespace: 1, Id: ids["mem.web1.free"]}}}); res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			if found, _ := search(pattern(types.NameMatchGlob, "*")); !reflect.DeepEqual(found, []string{"load"}) {
+				t.Errorf("renamed: %v", found)
+			}
+			if found, _ := search(pattern(types.NameMatchPrefix, "mem.")); len(found) != 0 {
+				t.Errorf("deleted: %v", found)
+			}
+
+			// invalid patterns
+			for _, s := range []*backend.SearchSeries{pattern(types.NameMatchRegexp, "("), pattern(types.NameMatchGlob, "cpu.{a,b"), pattern("fuzzy", "cpu")} {
+				s.Namespace = 1
+				s.Comparator = backend.SearchSeriesComparatorEquals
+				if res := b.SearchSeries(s); res.Error == nil {
+					t.Errorf("%s %s: expected error", s.NameMatch, s.Name)
+				}
+			}
+		})
+	}
+}
+
+// names in the index without a series key (e.g. a crash between both writes) are skipped without ending the search
+func TestSearchNamesStaleEntries(t *testing.T) {
+	b := backend.NewRedisBackend(&backend.RedisOpts{
+		ConnectionDetails: map[backend.Namespace]backend.RedisConnectionDetails{
+			backend.RedisDefaultConnectionNamespace: {
+				Type: backend.RedisMemory,
+			},
+		},
+	})
+	if err := b.Init(); err != nil {
+		t.Fatal(err)
+	}
+	res := b.CreateOrUpdateSeries(&backend.CreateSeries{
+		Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+			1: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: "z.live"}, SeriesCreateIdentifier: 1},
+		},
+	})
+	if res.Error != nil || res.Results[1].Error != nil {
+		t.Fatal(res.Error, res.Results[1].Error)
+	}
+	stale := make([]*redis.Z, 0, 1500)
+	for i := 0; i < cap(stale); i++ {
+		stale = append(stale, &redis.Z{Member: fmt.Sprintf("a.stale.%04d", i)})
+	}
+	if err := b.GetConnection(1).ZAdd(context.Background(), "names_1", stale...).Err(); err != nil {
+		t.Fatal(err)
+	}
+	search := &backend.SearchSeries{}
+	search.Namespace = 1
+	search.NameMatch = types.NameMatchPrefix
+	search.Comparator = backend.SearchSeriesComparatorEquals
+	found := b.SearchSeries(search)
+	if found.Error != nil || len(found.Series) != 1 || found.Series[0].Id != res.Results[1].Id {
+		t.Errorf("expected the live series, found %v %v", found.Series, found.Error)
+	}
+}
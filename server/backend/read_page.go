@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"container/heap"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+)
+
+// keeps the first limit timestamps of a range that is read in random order, memory is bounded by the limit
+type readPage struct {
+	limit      int
+	timestamps timestampHeap // max heap, the last timestamp of the page on top
+	values     map[uint64]float64
+	typed      map[uint64]types.Value // series that are not float
+	more       bool
+}
+
+func (page *readPage) add(ts uint64, value float64) {
+	if page.keep(ts) {
+		page.values[ts] = value
+	}
+}
+
+func (page *readPage) addTyped(ts uint64, value types.Value) {
+	if page.keep(ts) {
+		page.typed[ts] = value
+	}
+}
+
+// makes room for the timestamp, false if it is not part of the page
+func (page *readPage) keep(ts uint64) bool {
+	_, found := page.values[ts]
+	if _, typedFound := page.typed[ts]; found || typedFound {
+		return true
+	}
+	if len(page.timestamps) >= page.limit {
+		page.more = true
+		if ts > page.timestamps[0] {
+			return false
+		}
+		// replace the last timestamp
+		last := heap.Pop(&page.timestamps).(uint64)
+		delete(page.values, last)
+		delete(page.typed, last)
+	}
+	heap.Push(&page.timestamps, ts)
+	return true
+}
+
+func (page *readPage) result() (res ReadResult) {
+	if len(page.values) < 1 && len(page.typed) < 1 {
+		return
+	}
+	if len(page.values) > 0 {
+		res.Results = page.values
+	}
+	if len(page.typed) > 0 {
+		res.TypedResults = page.typed
+	}
+	if page.more {
+		res.Next = page.timestamps[0] + 1
+	}
+	return
+}
+
+func newReadPage(limit int) *readPage {
+	return &readPage{
+		limit:      limit,
+		timestamps: make(timestampHeap, 0, limit+1),
+		values:     make(map[uint64]float64, limit),
+		typed:      make(map[uint64]types.Value),
+	}
+}
+
+type timestampHeap []uint64
+
+func (h timestampHeap) Len() int           { return len(h) }
+func (h timestampHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h timestampHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *timestampHeap) Push(x interface{}) {
+	*h = append(*h, x.(uint64))
+}
+
+func (h *timestampHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
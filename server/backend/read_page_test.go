@@ -0,0 +1,88 @@
+package backend_test
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"testing"
+)
+
+func TestReadPage(t *testing.T) {
+	redisBackend := backend.NewRedisBackend(&backend.RedisOpts{
+		ConnectionDetails: map[backend.Namespace]backend.RedisConnectionDetails{
+			backend.RedisDefaultConnectionNamespace: {
+				Type: backend.RedisMemory,
+			},
+		},
+	})
+	if err := redisBackend.Init(); err != nil {
+		t.Fatal(err)
+	}
+	backends := map[string]interface {
+		backend.IAbstractBackend
+		backend.IMetadata
+	}{
+		"memory": backend.NewMemoryBackend(),
+		"redis":  redisBackend,
+	}
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			b.SetReverseApi(b)
+			create := b.CreateOrUpdateSeries(&backend.CreateSeries{
+				Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+					1: {SeriesMetadata: types.SeriesMetadata{Name: "paged", Namespace: 0}, SeriesCreateIdentifier: 1},
+				},
+			})
+			if create.Error != nil {
+				t.Fatal(create.Error)
+			}
+			c := backend.Context{Series: create.Results[1].Id, RequestId: backend.NewRequestId()}
+
+			// 10 points spread over 2 days
+			const day = 86400 * 1000
+			start := uint64(1577836800000)
+			var timestamps []uint64
+			var values []float64
+			for i := uint64(0); i < 10; i++ {
+				timestamps = append(timestamps, start+i*day/4)
+				values = append(values, float64(i))
+			}
+			if err := b.Write(backend.ContextWrite{Context: c}, timestamps, values); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.FlushPendingWrites(c.RequestId); err != nil {
+				t.Fatal(err)
+			}
+
+			// pages of 4 points
+			from := start + 1
+			var read []uint64
+			for page := 0; page < 5; page++ {
+				res := b.Read(backend.ContextRead{Context: c, From: from, To: start + 3*day, Limit: 4})
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				if len(res.Results) > 4 {
+					t.Fatalf("page exceeds limit %v", res.Results)
+				}
+				for _, ts := range timestamps {
+					if _, found := res.Results[ts]; found {
+						read = append(read, ts)
+					}
+				}
+				if res.Next == 0 {
+					break
+				}
+				from = res.Next
+			}
+			if len(read) != 9 || read[0] != timestamps[1] || read[8] != timestamps[9] {
+				t.Errorf("unexpected points %v", read)
+			}
+
+			// a limit that covers the range has no next page
+			res := b.Read(backend.ContextRead{Context: c, From: start, To: start + 3*day, Limit: 10})
+			if res.Error != nil || len(res.Results) != 10 || res.Next != 0 {
+				t.Errorf("unexpected read %+v", res)
+			}
+		})
+	}
+}
@@ -1,6 +1,50 @@
 package backend
 
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+)
+
 type ReadResult struct {
-	Error   error
-	Results map[uint64]float64
+	Error        error
+	Results      map[uint64]float64
+	TypedResults map[uint64]types.Value // instead of results for series that are not float
+	ValueType    types.ValueType
+	Fields       []string // names of the values of a fields series
+	Next         uint64   // if limited and the range has more points: the timestamp to read the remaining points from
+}
+
+// only the selected fields of a fields series in this order, empty selects all
+func (res ReadResult) SelectFields(fields []string) (ReadResult, error) {
+	if len(fields) < 1 {
+		return res, nil
+	}
+	if res.ValueType != types.ValueTypeFields {
+		return res, fmt.Errorf("fields selected for a %s series", res.ValueType)
+	}
+	indexes := make([]int, len(fields))
+	for i, field := range fields {
+		indexes[i] = -1
+		for j, name := range res.Fields {
+			if name == field {
+				indexes[i] = j
+			}
+		}
+		if indexes[i] < 0 {
+			return res, fmt.Errorf("unknown field %s", field)
+		}
+	}
+	selected := make(map[uint64]types.Value, len(res.TypedResults))
+	for ts, value := range res.TypedResults {
+		values := make([]float64, len(indexes))
+		for i, idx := range indexes {
+			values[i] = value.Fields[idx]
+		}
+		selected[ts] = types.FieldsValue(values...)
+	}
+	if res.TypedResults != nil {
+		res.TypedResults = selected
+	}
+	res.Fields = fields
+	return res, nil
 }
@@ -1,5 +1,10 @@
 package backend
 
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+)
+
 type Namespace int
 type Series uint64
 type Timestamp float64
@@ -8,8 +13,181 @@ type SeriesMetadata struct {
 	Id        Series
 	Namespace Namespace
 	Name      string
-	Tags      []string `json:",omitempty"`
-	TtlExpire uint64   `json:",omitempty"` //  0 OR time in the future in seconds
+	Tags      []string          `json:",omitempty"`
+	TtlExpire uint64            `json:",omitempty"` //  0 OR time in the future in seconds
+	Ttl       uint              `json:",omitempty"` // relative ttl in seconds the expiry was set with
+	ValueType types.ValueType   `json:",omitempty"`
+	Enum      []string          `json:",omitempty"`
+	Fields    []string          `json:",omitempty"`
+	Labels    map[string]string `json:",omitempty"`
+	Created   uint64            `json:",omitempty"` // time of creation in seconds
+	Creator   *SeriesCreator    `json:",omitempty"`
+}
+
+// the value type of the series allows writing these values
+func (meta SeriesMetadata) validateValues(values []types.Value) error {
+	if !meta.ValueType.Typed() {
+		return fmt.Errorf("%s: series %d is a float series", types.RpcErrorValueTypeMismatch, meta.Id)
+	}
+	for _, value := range values {
+		if err := meta.ValueType.Validate(value, meta.Enum, meta.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (meta SeriesMetadata) validateFloats() error {
+	if meta.ValueType.Typed() {
+		return fmt.Errorf("%s: series %d is a %s series", types.RpcErrorValueTypeMismatch, meta.Id, meta.ValueType)
+	}
+	return nil
+}
+
+// an existing series can only be initialised with its own value type, or without any
+func (meta SeriesMetadata) validateCreate(create types.SeriesCreateMetadata) *types.RpcError {
+	if create.ValueType == types.ValueTypeFloat || (create.ValueType == meta.ValueType && stringsEqual(create.Enum, meta.Enum) && stringsEqual(create.Fields, meta.Fields)) {
+		return nil
+	}
+	return types.WrapErrorStringPointer(fmt.Sprintf("%s: series %s is a %s series", types.RpcErrorValueTypeMismatch, meta.Name, meta.ValueType))
+}
+
+// update of an existing series to the tags and ttl of a create request, nil if they are the same
+// no tags or ttl in the request means they are not changed (older clients and read only lookups do not send them)
+func (meta SeriesMetadata) updateFromCreate(create types.SeriesCreateMetadata) *UpdateSeries {
+	update := &UpdateSeries{
+		Namespace: int(meta.Namespace),
+		Id:        uint64(meta.Id),
+	}
+	if len(create.Tags) > 0 {
+		for _, tag := range create.Tags {
+			if !hasTag(meta.Tags, tag) {
+				update.AddTags = append(update.AddTags, tag)
+			}
+		}
+		for _, tag := range meta.Tags {
+			if !hasTag(create.Tags, tag) {
+				update.RemoveTags = append(update.RemoveTags, tag)
+			}
+		}
+	}
+	if create.Ttl > 0 && create.Ttl != meta.Ttl {
+		update.SetTtl = true
+		update.Ttl = create.Ttl
+	}
+	if len(create.Labels) > 0 {
+		for name, value := range create.Labels {
+			if current, found := meta.Labels[name]; !found || current != value {
+				if update.SetLabels == nil {
+					update.SetLabels = make(map[string]string)
+				}
+				update.SetLabels[name] = value
+			}
+		}
+		for name := range meta.Labels {
+			if _, found := create.Labels[name]; !found {
+				update.RemoveLabels = append(update.RemoveLabels, name)
+			}
+		}
+	}
+	if update.AddTags == nil && update.RemoveTags == nil && !update.SetTtl && update.SetLabels == nil && update.RemoveLabels == nil {
+		return nil
+	}
+	return update
+}
+
+// metadata after the update, changed is false if the update does not change anything
+func (meta SeriesMetadata) applyUpdate(update *UpdateSeries) (updated SeriesMetadata, changed bool) {
+	updated = meta
+	updated.Tags = make([]string, 0, len(meta.Tags)+len(update.AddTags))
+	for _, tag := range meta.Tags {
+		if hasTag(update.RemoveTags, tag) {
+			changed = true
+			continue
+		}
+		updated.Tags = append(updated.Tags, tag)
+	}
+	for _, tag := range update.AddTags {
+		if !hasTag(updated.Tags, tag) {
+			updated.Tags = append(updated.Tags, tag)
+			changed = true
+		}
+	}
+	if update.SetTtl && (update.Ttl != meta.Ttl || (update.Ttl == 0) != (meta.TtlExpire == 0)) {
+		updated.Ttl = update.Ttl
+		updated.TtlExpire = ttlExpire(update.Ttl)
+		changed = true
+	}
+	if len(update.SetLabels) > 0 || len(update.RemoveLabels) > 0 {
+		labels := make(map[string]string, len(meta.Labels)+len(update.SetLabels))
+		for name, value := range meta.Labels {
+			labels[name] = value
+		}
+		for _, name := range update.RemoveLabels {
+			delete(labels, name)
+		}
+		for name, value := range update.SetLabels {
+			labels[name] = value
+		}
+		if !labelsEqual(labels, meta.Labels) {
+			updated.Labels = labels
+			changed = true
+		}
+	}
+	if len(update.Rename) > 0 && update.Rename != meta.Name {
+		updated.Name = update.Rename
+		changed = true
+	}
+	return
+}
+
+// absolute expiry of a relative ttl, 0 for none
+func ttlExpire(ttl uint) uint64 {
+	if ttl < 1 {
+		return 0
+	}
+	return nowSeconds() + uint64(ttl)
+}
+
+func labelsEqual(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, found := b[name]; !found || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// label pairs of the old labels that are no longer there and of the new labels that were not there
+func labelChanges(old map[string]string, new map[string]string) (removed map[string]string, added map[string]string) {
+	removed = make(map[string]string)
+	added = make(map[string]string)
+	for name, value := range old {
+		if v, found := new[name]; !found || v != value {
+			removed[name] = value
+		}
+	}
+	for name, value := range new {
+		if v, found := old[name]; !found || v != value {
+			added[name] = value
+		}
+	}
+	return
+}
+
+func stringsEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func (n Namespace) Int() int {
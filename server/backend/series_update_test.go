@@ -0,0 +1,96 @@
+package backend_test
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateSeries(t *testing.T) {
+	for name, b := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.SetReverseApi(b)
+			create := func(name string, tags []string, ttl uint) types.SeriesMetadataResponse {
+				res := b.CreateOrUpdateSeries(&backend.CreateSeries{
+					Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+						1: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: name, Tags: tags, Ttl: ttl}, SeriesCreateIdentifier: 1},
+					},
+				})
+				if res.Error != nil || res.Results[1].Error != nil {
+					t.Fatal(res.Error, res.Results[1].Error)
+				}
+				return res.Results[1]
+			}
+			search := func(name string, tag string) []types.SeriesIdentifier {
+				s := &backend.SearchSeries{}
+				s.Namespace = 1
+				s.Name = name
+				s.Tag = tag
+				s.Comparator = backend.SearchSeriesComparatorEquals
+				res := b.SearchSeries(s)
+				if res.Error != nil {
+					t.Fatal(res.Error)
+				}
+				return res.Series
+			}
+			created := create("cpu", []string{"a", "b"}, 0)
+			c := backend.Context{Namespace: 1, Series: created.Id, RequestId: backend.NewRequestId()}
+			if err := b.Write(backend.ContextWrite{Context: c}, []uint64{1577836800000}, []float64{1}); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.FlushPendingWrites(c.RequestId); err != nil {
+				t.Fatal(err)
+			}
+
+			// tags of a create request replace the existing ones
+			if res := create("cpu", []string{"b", "c"}, 0); res.New || !res.Changed || res.Id != created.Id {
+				t.Errorf("expected changed series %+v", res)
+			}
+			if len(search("", "a")) != 0 || len(search("", "c")) != 1 || len(search("", "b")) != 1 {
+				t.Error("expected updated tag index")
+			}
+			if res := create("cpu", []string{"c", "b"}, 0); res.Changed {
+				t.Error("expected unchanged series")
+			}
+			if res := create("cpu", nil, 0); res.Changed {
+				t.Error("expected unchanged series without tags")
+			}
+
+			// rename
+			update := b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: created.Id, Rename: "cpu.total", AddTags: []string{"d"}, RemoveTags: []string{"b"}})
+			if update.Error != nil {
+				t.Fatal(update.Error)
+			}
+			if !update.Changed || update.Metadata.Name != "cpu.total" || !reflect.DeepEqual(update.Metadata.Tags, []string{"c", "d"}) {
+				t.Errorf("unexpected update %+v", update)
+			}
+			if len(search("cpu", "")) != 0 || len(search("cpu.total", "")) != 1 || len(search("", "b")) != 0 {
+				t.Error("expected updated name index")
+			}
+			if res := b.Read(backend.ContextRead{Context: c, From: 1577836800000, To: 1577836800000}); res.Error != nil || len(res.Results) != 1 {
+				t.Errorf("expected data of the renamed series %+v", res)
+			}
+			other := create("mem", nil, 0)
+			if update := b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: other.Id, Rename: "cpu.total"}); update.Error == nil {
+				t.Error("expected name exists error")
+			}
+			if update := b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: 1000}); update.Error == nil {
+				t.Error("expected series not found error")
+			}
+
+			// ttl
+			if res := create("cpu.total", nil, 3600); !res.Changed {
+				t.Error("expected changed ttl")
+			}
+			update = b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: created.Id, SetTtl: true, Ttl: 3600})
+			if update.Error != nil || update.Changed || update.Metadata.TtlExpire < 1 {
+				t.Errorf("expected unchanged ttl %+v", update)
+			}
+			update = b.UpdateSeries(&backend.UpdateSeries{Namespace: 1, Id: created.Id, SetTtl: true})
+			if update.Error != nil || !update.Changed || update.Metadata.TtlExpire != 0 {
+				t.Errorf("expected removed ttl %+v", update)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package backend_test
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"reflect"
+	"testing"
+)
+
+func testBackends(t *testing.T) map[string]interface {
+	backend.IAbstractBackend
+	backend.IMetadata
+} {
+	redisBackend := backend.NewRedisBackend(&backend.RedisOpts{
+		ConnectionDetails: map[backend.Namespace]backend.RedisConnectionDetails{
+			backend.RedisDefaultConnectionNamespace: {
+				Type: backend.RedisMemory,
+			},
+		},
+	})
+	if err := redisBackend.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]interface {
+		backend.IAbstractBackend
+		backend.IMetadata
+	}{
+		"memory": backend.NewMemoryBackend(),
+		"redis":  redisBackend,
+	}
+}
+
+func TestValueTypes(t *testing.T) {
+	for name, b := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.SetReverseApi(b)
+			create := b.CreateOrUpdateSeries(&backend.CreateSeries{
+				Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+					1: {SeriesMetadata: types.SeriesMetadata{Name: "state", ValueType: types.ValueTypeString}, SeriesCreateIdentifier: 1},
+				},
+			})
+			if create.Error != nil || create.Results[1].Error != nil {
+				t.Fatal(create.Error, create.Results[1].Error)
+			}
+			c := backend.Context{Series: create.Results[1].Id, RequestId: backend.NewRequestId()}
+
+			// string values may contain the member separator of redis
+			timestamps := []uint64{1577836800000, 1577836800001, 1577836800002}
+			values := []types.Value{types.StringValue("ok"), types.StringValue("warn:disk"), types.StringValue("")}
+			if err := b.WriteValues(backend.ContextWrite{Context: c}, timestamps, values); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.FlushPendingWrites(c.RequestId); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Write(backend.ContextWrite{Context: c}, timestamps[:1], []float64{1}); err == nil {
+				t.Error("expected value type mismatch")
+			}
+
+			res := b.Read(backend.ContextRead{Context: c, From: timestamps[0], To: timestamps[2]})
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			if res.ValueType != types.ValueTypeString || len(res.Results) != 0 || len(res.TypedResults) != 3 {
+				t.Fatalf("unexpected read %+v", res)
+			}
+			for idx, ts := range timestamps {
+				if res.TypedResults[ts].String != values[idx].String {
+					t.Errorf("unexpected value at %d: %+v", ts, res.TypedResults[ts])
+				}
+			}
+
+			// pages
+			res = b.Read(backend.ContextRead{Context: c, From: timestamps[0], To: timestamps[2], Limit: 2})
+			if res.Error != nil || len(res.TypedResults) != 2 || res.Next != timestamps[2] {
+				t.Errorf("unexpected page %+v", res)
+			}
+
+			// an existing series keeps its value type
+			create = b.CreateOrUpdateSeries(&backend.CreateSeries{
+				Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+					2: {SeriesMetadata: types.SeriesMetadata{Name: "state", ValueType: types.ValueTypeInt}, SeriesCreateIdentifier: 2},
+					3: {SeriesMetadata: types.SeriesMetadata{Name: "state"}, SeriesCreateIdentifier: 3},
+				},
+			})
+			if create.Error != nil {
+				t.Fatal(create.Error)
+			}
+			if create.Results[2].Error == nil {
+				t.Error("expected value type mismatch")
+			}
+			if create.Results[3].Error != nil || create.Results[3].Id != c.Series {
+				t.Errorf("expected existing series %+v", create.Results[3])
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	for name, b := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			b.SetReverseApi(b)
+			fields := []string{"temperature", "humidity"}
+			create := b.CreateOrUpdateSeries(&backend.CreateSeries{
+				Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+					1: {SeriesMetadata: types.SeriesMetadata{Name: "device", ValueType: types.ValueTypeFields, Fields: fields}, SeriesCreateIdentifier: 1},
+				},
+			})
+			if create.Error != nil || create.Results[1].Error != nil {
+				t.Fatal(create.Error, create.Results[1].Error)
+			}
+			c := backend.Context{Series: create.Results[1].Id, RequestId: backend.NewRequestId()}
+
+			timestamps := []uint64{1577836800000, 1577836800001}
+			values := []types.Value{types.FieldsValue(21.5, 40), types.FieldsValue(-1.25, 41)}
+			if err := b.WriteValues(backend.ContextWrite{Context: c}, timestamps, values); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.FlushPendingWrites(c.RequestId); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.WriteValues(backend.ContextWrite{Context: c}, timestamps[:1], []types.Value{types.FieldsValue(1)}); err == nil {
+				t.Error("expected missing field error")
+			}
+
+			res := b.Read(backend.ContextRead{Context: c, From: timestamps[0], To: timestamps[1]})
+			if res.Error != nil {
+				t.Fatal(res.Error)
+			}
+			if !reflect.DeepEqual(res.Fields, fields) || !reflect.DeepEqual(res.TypedResults[timestamps[1]].Fields, []float64{-1.25, 41}) {
+				t.Fatalf("unexpected read %+v", res)
+			}
+			selected, err := res.SelectFields([]string{"humidity"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(selected.Fields, []string{"humidity"}) || !reflect.DeepEqual(selected.TypedResults[timestamps[0]].Fields, []float64{40}) {
+				t.Errorf("unexpected selection %+v", selected)
+			}
+			if _, err := res.SelectFields([]string{"pressure"}); err == nil {
+				t.Error("expected unknown field error")
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"time"
+)
+
+// wraps a backend to record write, read and flush latencies and errors
+type instrumentedBackend struct {
+	backend.IAbstractBackend
+	identifier string
+	metrics    *Metrics
+}
+
+func (b *instrumentedBackend) Write(context backend.ContextWrite, timestamps []uint64, values []float64) error {
+	start := time.Now()
+	err := b.IAbstractBackend.Write(context, timestamps, values)
+	b.metrics.observeBackend(b.identifier, "write", start, err)
+	return err
+}
+
+func (b *instrumentedBackend) WriteValues(context backend.ContextWrite, timestamps []uint64, values []types.Value) error {
+	start := time.Now()
+	err := b.IAbstractBackend.WriteValues(context, timestamps, values)
+	b.metrics.observeBackend(b.identifier, "write", start, err)
+	return err
+}
+
+func (b *instrumentedBackend) FlushPendingWrites(requestId backend.RequestId) error {
+	start := time.Now()
+	err := b.IAbstractBackend.FlushPendingWrites(requestId)
+	b.metrics.observeBackend(b.identifier, "flush", start, err)
+	return err
+}
+
+func (b *instrumentedBackend) Read(context backend.ContextRead) backend.ReadResult {
+	start := time.Now()
+	res := b.IAbstractBackend.Read(context)
+	b.metrics.observeBackend(b.identifier, "read", start, res.Error)
+	return res
+}
+
+// the wrapped backend, e.g. to check for optional interfaces
+func (b *instrumentedBackend) unwrap() backend.IAbstractBackend {
+	return b.IAbstractBackend
+}
+
+func newInstrumentedBackend(b backend.IAbstractBackend, identifier string, metrics *Metrics) *instrumentedBackend {
+	return &instrumentedBackend{
+		IAbstractBackend: b,
+		identifier:       identifier,
+		metrics:          metrics,
+	}
+}
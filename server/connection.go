@@ -3,8 +3,9 @@ package server
 import (
 	"fmt"
 	"github.com/RobinUS2/tsxdb/rpc"
-	"log"
+	"github.com/RobinUS2/tsxdb/rpc/types"
 	"net"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,10 +27,29 @@ func (c *Connections) ExpiredConnections() uint64 {
 	return atomic.LoadUint64(&c.expiredConnections)
 }
 
+func (c *Connections) ActiveConnections() int {
+	c.connectionsMux.RLock()
+	n := len(c.connections)
+	c.connectionsMux.RUnlock()
+	return n
+}
+
+// remote addresses of the open connections
+func (c *Connections) RemoteAddrs() []string {
+	c.connectionsMux.RLock()
+	addrs := make([]string, 0, len(c.connections))
+	for addr := range c.connections {
+		addrs = append(addrs, addr.String())
+	}
+	c.connectionsMux.RUnlock()
+	sort.Strings(addrs)
+	return addrs
+}
+
 const ConnectionTimeout = rpc.DefaultTimeout
 
 func (instance *Instance) StartListening() error {
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", instance.opts.ListenPort))
+	listener, err := rpc.Listen(fmt.Sprintf(":%d", instance.opts.ListenPort), instance.opts.Tls)
 	if err != nil {
 		return err
 	}
@@ -41,7 +61,7 @@ func (instance *Instance) StartListening() error {
 			isShuttingDown := atomic.LoadInt32(&instance.shuttingDown) == 1
 			if err != nil {
 				if !isShuttingDown {
-					log.Printf("%s", err)
+					instance.log.WithError(err).Error("rpc listener failed")
 				}
 				break
 			}
@@ -78,20 +98,67 @@ func (instance *Instance) closeRpc() error {
 }
 
 func (instance *Instance) ServeConn(conn net.Conn) {
+	// tls handshake, done here instead of in accept to not block other connections
+	peerIdentity, err := rpc.HandshakePeerIdentity(conn, instance.opts.ConnectTimeout)
+	if err != nil {
+		if logger := instance.errorLogSampler.Sample(instance.log, "tls handshake"); logger != nil {
+			logger.WithError(err).WithField("remote", conn.RemoteAddr().String()).Warn("tls handshake failed")
+		}
+		_ = conn.Close()
+		return
+	}
+
 	// register connection
 	instance.RegisterConn(conn)
 	atomic.AddInt64(&instance.pendingRequests, 1)
 
 	// buffered writer
 	srv := rpc.NewGobServerCodec(conn)
+	srv.SetPeerIdentity(peerIdentity)
+	sessions := &connectionSessions{ids: make(map[SessionId]bool)}
+	srv.SetObserver(func(serviceMethod string, duration time.Duration, body interface{}) {
+		if instance.metrics != nil {
+			instance.metrics.observeResponse(serviceMethod, duration, body)
+		}
+		sessions.observeResponse(body)
+	})
 
 	// serve
 	instance.rpc.ServeCodec(srv)
 
+	// sessions do not outlive their connection
+	instance.expireSessions(sessions.sessionIds())
+
 	// unregister
 	atomic.AddInt64(&instance.pendingRequests, -1)
 }
 
+// sessions authenticated on a connection
+type connectionSessions struct {
+	ids map[SessionId]bool
+	mux sync.Mutex
+}
+
+func (sessions *connectionSessions) observeResponse(body interface{}) {
+	response, ok := body.(*types.AuthResponse)
+	if !ok || response.Error != nil || response.SessionId == 0 {
+		return
+	}
+	sessions.mux.Lock()
+	sessions.ids[SessionId(response.SessionId)] = true
+	sessions.mux.Unlock()
+}
+
+func (sessions *connectionSessions) sessionIds() []SessionId {
+	sessions.mux.Lock()
+	defer sessions.mux.Unlock()
+	ids := make([]SessionId, 0, len(sessions.ids))
+	for id := range sessions.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (instance *Instance) RegisterConn(conn net.Conn) {
 	instance.connectionsMux.Lock()
 	instance.connections[conn.RemoteAddr()] = conn
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultExpiryInterval = time.Second
+const DefaultExpiryMaxPerRun = 1000
+
+// callbacks of the backends, e.g. for series that expired
+type reverseApi struct {
+	server *Instance
+}
+
+func (api *reverseApi) DeleteSeries(ops *backend.DeleteSeries) *backend.DeleteSeriesResult {
+	return api.server.deleteSeries(ops)
+}
+
+// metadata and data of series
+func (instance *Instance) deleteSeries(ops *backend.DeleteSeries) *backend.DeleteSeriesResult {
+	// data first, the series can be deleted again if removing its data fails
+	for _, series := range ops.Series {
+		adminBackend, err := instance.selectAdminBackend(series.Namespace, series.Id)
+		if err != nil {
+			return &backend.DeleteSeriesResult{Error: err}
+		}
+		if err := adminBackend.DeleteSeriesData(backend.Context{Namespace: series.Namespace, Series: series.Id}); err != nil {
+			return &backend.DeleteSeriesResult{Error: err}
+		}
+	}
+	return instance.metaStore.DeleteSeries(ops)
+}
+
+func (instance *Instance) startExpiry() {
+	interval := instance.opts.Expiry.Interval
+	if interval <= 0 {
+		interval = DefaultExpiryInterval
+	}
+	instance.expiryTicker = time.NewTicker(interval)
+	go func() {
+		for range instance.expiryTicker.C {
+			instance.expireSeries()
+		}
+	}()
+}
+
+// deletes the series of which the ttl passed from the expiry queue of the metadata backend
+func (instance *Instance) expireSeries() {
+	expiring, ok := instance.metaStore.(backend.IExpiringBackend)
+	if !ok {
+		return
+	}
+	maxPerRun := instance.opts.Expiry.MaxPerRun
+	if maxPerRun == 0 {
+		maxPerRun = DefaultExpiryMaxPerRun
+	}
+	result := expiring.ExpireSeries(&backend.ExpireSeries{
+		Now: uint64(time.Now().Unix()),
+		Max: maxPerRun,
+	})
+	atomic.AddUint64(&instance.numSeriesExpired, uint64(len(result.Series)))
+	if result.Error != nil {
+		instance.log.WithError(result.Error).Error("series expiry failed")
+	}
+}
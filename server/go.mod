@@ -2,6 +2,8 @@ module github.com/RobinUS2/tsxdb/server
 
 go 1.13
 
+replace github.com/RobinUS2/tsxdb/client => ../client
+
 replace github.com/RobinUS2/tsxdb/rpc => ../rpc
 
 replace github.com/RobinUS2/tsxdb/rpc/types => ../rpc/types
@@ -17,6 +19,7 @@ replace github.com/RobinUS2/tsxdb/tools => ../tools
 replace github.com/RobinUS2/tsxdb/telnet => ../telnet
 
 require (
+	github.com/RobinUS2/tsxdb/client v0.0.0-20200901130747-de49413515ff
 	github.com/RobinUS2/tsxdb/rpc v0.0.0-20200831110925-b62f451e618d
 	github.com/RobinUS2/tsxdb/telnet v0.0.0-20200901125404-22137cdbe6ba
 	github.com/RobinUS2/tsxdb/tools v0.0.0-20200901125404-22137cdbe6ba
@@ -28,7 +31,11 @@ require (
 	github.com/karlseguin/ccache/v2 v2.0.8
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.1
 	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b
+	github.com/sirupsen/logrus v1.9.0
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 	gopkg.in/yaml.v2 v2.4.0
 	k8s.io/apimachinery v0.0.0-20190515023456-b74e4c97951f
 )
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// errors that can occur on every request are logged at most once per interval
+const errorLogSampleInterval = 10 * time.Second
+
+func (instance *Instance) Logger() logrus.FieldLogger {
+	return instance.log
+}
+
+// injected logger, or a new one with the configured level
+func (instance *Instance) initLogger() error {
+	if instance.opts.Logger != nil {
+		instance.log = instance.opts.Logger
+		return nil
+	}
+	logger, err := tools.NewLogger(instance.opts.LogLevel)
+	if err != nil {
+		return err
+	}
+	instance.log = logger
+	return nil
+}
+
+// log an error of a request, sampled per operation
+func (instance *Instance) logRequestError(operation string, fields logrus.Fields, err error) {
+	if logger := instance.errorLogSampler.Sample(instance.log, operation); logger != nil {
+		logger.WithFields(fields).WithError(err).Errorf("%s failed", operation)
+	}
+}
+
+// fields identifying the session, series and request id (if set) of a backend call
+func requestFields(ticket types.SessionTicket, session *Session, c backend.Context) logrus.Fields {
+	fields := sessionFields(SessionId(ticket.Id), session)
+	if c.Series > 0 {
+		fields[tools.LogFieldNamespace] = c.Namespace
+		fields[tools.LogFieldSeries] = c.Series
+	}
+	if !backend.IsEmptyRequestId(c.RequestId) {
+		fields[tools.LogFieldRequest] = c.RequestId
+	}
+	return fields
+}
+
+// fields identifying the session of a request
+func sessionFields(sessionId SessionId, session *Session) logrus.Fields {
+	fields := logrus.Fields{tools.LogFieldSession: sessionId}
+	if session != nil && session.user != nil && session.user.name != SharedTokenUser {
+		fields[tools.LogFieldUser] = session.user.name
+	}
+	return fields
+}
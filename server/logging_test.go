@@ -0,0 +1,46 @@
+package server_test
+
+import (
+	"github.com/RobinUS2/tsxdb/server"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	// invalid level
+	opts := server.NewOpts()
+	opts.AuthToken = "verySecure"
+	opts.LogLevel = "loud"
+	if err := server.New(opts).Init(); err == nil {
+		t.Error("expected invalid log level")
+	}
+
+	// configured level
+	opts = server.NewOpts()
+	opts.AuthToken = "verySecure"
+	opts.LogLevel = "warn"
+	s := server.New(opts)
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger().(*logrus.Logger).GetLevel() != logrus.WarnLevel {
+		t.Error(s.Logger())
+	}
+
+	// injected
+	logger, logs := logtest.NewNullLogger()
+	opts = server.NewOpts()
+	opts.AuthToken = "verySecure"
+	opts.Logger = logger
+	s = server.New(opts)
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Logger() != logger {
+		t.Error("expected injected logger")
+	}
+	if entry := logs.LastEntry(); entry == nil || entry.Level != logrus.WarnLevel {
+		t.Errorf("expected warning about the default backend %+v", logs.AllEntries())
+	}
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const metricsNamespace = "tsxdb"
+const MetricsPath = "/metrics"
+
+const metricStatusOk = "ok"
+const metricStatusError = "error"
+const metricEndpointUnknown = "unknown"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	endpointDuration  *prometheus.HistogramVec // endpoint, status
+	backendDuration   *prometheus.HistogramVec // backend, operation
+	backendErrors     *prometheus.CounterVec   // backend, operation
+	redisPipelineSize *prometheus.HistogramVec // backend
+
+	knownEndpoints map[string]bool // limits label cardinality to registered endpoints
+
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+func (metrics *Metrics) Registry() *prometheus.Registry {
+	return metrics.registry
+}
+
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{})
+}
+
+// rpc codec observer, records the latency of every endpoint call
+func (metrics *Metrics) observeResponse(serviceMethod string, duration time.Duration, body interface{}) {
+	endpoint := strings.SplitN(serviceMethod, ".", 2)[0]
+	if !metrics.knownEndpoints[endpoint] {
+		endpoint = metricEndpointUnknown
+	}
+	status := metricStatusOk
+	if response, ok := body.(types.SessionResponse); ok {
+		if response.ResponseError() != nil {
+			status = metricStatusError
+		}
+	} else {
+		// invalid request
+		status = metricStatusError
+	}
+	metrics.endpointDuration.WithLabelValues(endpoint, status).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) observeBackend(identifier string, operation string, start time.Time, err error) {
+	metrics.backendDuration.WithLabelValues(identifier, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.backendErrors.WithLabelValues(identifier, operation).Inc()
+	}
+}
+
+func (metrics *Metrics) observeRedisPipeline(identifier string) func(commands int) {
+	observer := metrics.redisPipelineSize.WithLabelValues(identifier)
+	return func(commands int) {
+		observer.Observe(float64(commands))
+	}
+}
+
+func (instance *Instance) Metrics() *Metrics {
+	return instance.metrics
+}
+
+func newMetrics(instance *Instance) *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		endpointDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "endpoint_duration_seconds",
+			Help:      "Latency of rpc endpoint calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+		backendDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "backend_duration_seconds",
+			Help:      "Latency of backend writes, reads and flushes.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"}),
+		backendErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "backend_errors_total",
+			Help:      "Failed backend writes, reads and flushes.",
+		}, []string{"backend", "operation"}),
+		redisPipelineSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "redis_pipeline_commands",
+			Help:      "Number of commands in executed redis write pipelines.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}, []string{"backend"}),
+		knownEndpoints: make(map[string]bool),
+	}
+	for _, endpoint := range endpoints {
+		metrics.knownEndpoints[endpoint.name().String()] = true
+	}
+
+	// server statistics, read on scrape
+	counter := func(name string, help string, value func(stats Stats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return float64(value(instance.Statistics()))
+		})
+	}
+	gauge := func(name string, help string, value func() float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      name,
+			Help:      help,
+		}, value)
+	}
+	metrics.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		metrics.endpointDuration,
+		metrics.backendDuration,
+		metrics.backendErrors,
+		metrics.redisPipelineSize,
+		counter("calls_total", "Rpc calls with a valid session.", Stats.NumCalls),
+		counter("values_written_total", "Values written.", Stats.NumValuesWritten),
+		counter("series_created_total", "Series created.", Stats.NumSeriesCreated),
+		counter("series_initialised_total", "Series initialised (created or looked up).", Stats.NumSeriesInitialised),
+		counter("series_updated_total", "Series of which the tags, ttl or name changed.", Stats.NumSeriesUpdated),
+		counter("series_expired_total", "Series deleted with their data once their ttl passed.", Stats.NumSeriesExpired),
+		counter("authentications_total", "Successful authentications.", Stats.NumAuthentications),
+		counter("reads_total", "Series reads.", Stats.NumReads),
+		counter("rate_limited_total", "Calls and writes rejected by rate limits.", Stats.NumRateLimited),
+		counter("quota_exceeded_total", "Calls rejected by quotas.", Stats.NumQuotaExceeded),
+		counter("sessions_expired_total", "Sessions expired after inactivity or with their connection.", Stats.NumSessionsExpired),
+		counter("sessions_logged_out_total", "Sessions ended by a logout.", Stats.NumSessionsLoggedOut),
+		counter("connections_expired_total", "Connections closed after their timeout.", func(Stats) uint64 {
+			return instance.ExpiredConnections()
+		}),
+		gauge("sessions_active", "Sessions currently valid.", func() float64 {
+			return float64(instance.ActiveSessions())
+		}),
+		gauge("connections_active", "Open rpc connections.", func() float64 {
+			return float64(instance.ActiveConnections())
+		}),
+		gauge("pending_requests", "Rpc connections currently being served.", func() float64 {
+			return float64(atomic.LoadInt64(&instance.pendingRequests))
+		}),
+	)
+	return metrics
+}
+
+// serve the metrics over http, only when a metrics port is configured
+func (instance *Instance) startMetrics() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", instance.opts.MetricsHost, instance.opts.MetricsPort))
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, instance.metrics.Handler())
+	instance.metrics.listener = listener
+	instance.metrics.httpServer = &http.Server{
+		Handler:     mux,
+		ReadTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := instance.metrics.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			instance.log.WithError(err).Error("metrics failed to serve")
+		}
+	}()
+	return nil
+}
+
+// address the metrics are served on, nil if not serving
+func (metrics *Metrics) Addr() net.Addr {
+	if metrics.listener == nil {
+		return nil
+	}
+	return metrics.listener.Addr()
+}
+
+func (instance *Instance) stopMetrics() error {
+	if instance.metrics == nil || instance.metrics.httpServer == nil {
+		return nil
+	}
+	return instance.metrics.httpServer.Close()
+}
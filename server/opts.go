@@ -2,14 +2,121 @@ package server
 
 import (
 	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
 type Opts struct {
 	rpc.OptsConnection `yaml:"connection"`
-	TelnetPort         int                 `yaml:"telnet_port"`
-	TelnetHost         string              `yaml:"telnet_host"`
-	Backends           []BackendOpts       `yaml:"backends"`
-	BackendStrategy    BackendStrategyOpts `yaml:"backendStrategy"`
+	TelnetPort         int                  `yaml:"telnet_port"`
+	TelnetHost         string               `yaml:"telnet_host"`
+	TelnetTls          rpc.OptsTls          `yaml:"telnet_tls"`          // tls of the telnet listener, client certificate identities are not passed on to the server
+	TelnetUpstreamTls  rpc.OptsTls          `yaml:"telnet_upstream_tls"` // tls (client side) used by telnet to connect to the rpc listener
+	Backends           []BackendOpts        `yaml:"backends"`
+	BackendStrategy    BackendStrategyOpts  `yaml:"backendStrategy"`
+	Users              []UserOpts           `yaml:"users"` // named credentials, the shared auth token (if any) has all permissions
+	Limits             LimitOpts            `yaml:"limits"`
+	Subscriptions      SubscriptionOpts     `yaml:"subscriptions"`
+	Expiry             ExpiryOpts           `yaml:"expiry"`
+	AlertRules         []AlertRuleOpts      `yaml:"alert_rules"`
+	AlertRulesPath     string               `yaml:"alert_rules_path"` // yaml file(s) with alert_rules, comma separated, added to the rules above
+	RecordingRules     []RecordingRuleOpts  `yaml:"recording_rules"`
+	MetricsPort        int                  `yaml:"metrics_port"` // prometheus metrics are served over http on this port, disabled if 0
+	MetricsHost        string               `yaml:"metrics_host"`
+	TracerProvider     trace.TracerProvider `yaml:"-"`             // optional, spans of endpoint and backend calls continue the traces of clients
+	LogLevel           string               `yaml:"log_level"`     // debug, info (default), warn, error
+	SnapshotPath       string               `yaml:"snapshot_path"` // if set, restored during init (only into empty backends) and written during shutdown (e.g. to keep the memory backend)
+	SnapshotDir        string               `yaml:"snapshot_dir"`  // directory of the archive files of admin snapshots and restores, disabled if empty
+	Logger             logrus.FieldLogger   `yaml:"-"`             // optional, replaces the logger created with LogLevel
+}
+
+// zero values are unlimited
+type LimitOpts struct {
+	RequestsPerSecond     float64 `yaml:"requests_per_second"`      // per session
+	RequestsBurst         float64 `yaml:"requests_burst"`           // defaults to one second of requests
+	PointsPerSecond       float64 `yaml:"points_per_second"`        // written per namespace
+	PointsBurst           float64 `yaml:"points_burst"`             // defaults to one second of points
+	MaxSeriesPerNamespace int     `yaml:"max_series_per_namespace"` // series that can be created per namespace
+	MaxPointsPerRead      int     `yaml:"max_points_per_read"`      // points in a single read response
+	MaxLabelValues        int     `yaml:"max_label_values"`         // distinct values of one label name per namespace
+
+	Namespaces map[int]NamespaceLimitOpts `yaml:"namespaces"` // overrides of the series and label limits per namespace
+}
+
+type NamespaceLimitOpts struct {
+	MaxSeries      int `yaml:"max_series"`       // 0 for the default limit
+	MaxLabelValues int `yaml:"max_label_values"` // 0 for the default limit
+}
+
+func (opts LimitOpts) maxSeries(namespace int) int {
+	if limits, found := opts.Namespaces[namespace]; found && limits.MaxSeries > 0 {
+		return limits.MaxSeries
+	}
+	return opts.MaxSeriesPerNamespace
+}
+
+func (opts LimitOpts) maxLabelValues(namespace int) int {
+	if limits, found := opts.Namespaces[namespace]; found && limits.MaxLabelValues > 0 {
+		return limits.MaxLabelValues
+	}
+	return opts.MaxLabelValues
+}
+
+type SubscriptionOpts struct {
+	BufferSize   int    `yaml:"buffer_size"`   // points buffered per subscription until polled, defaults to 10000
+	SlowConsumer string `yaml:"slow_consumer"` // when the buffer is full: drop (default) new points or disconnect the subscription
+}
+
+// series with a ttl are deleted with their data once expired
+type ExpiryOpts struct {
+	Interval  time.Duration `yaml:"interval"`    // defaults to a second
+	MaxPerRun int           `yaml:"max_per_run"` // series deleted per interval, defaults to 1000, -1 for all
+}
+
+type AlertRuleOpts struct {
+	Name        string        `yaml:"name"`
+	Namespace   int           `yaml:"namespace"`
+	Series      string        `yaml:"series"`      // series name
+	Tag         string        `yaml:"tag"`         // or all series with this tag, each evaluated separately
+	Aggregation string        `yaml:"aggregation"` // avg (default), min, max, sum, count, last, increase, rate (per second)
+	Window      time.Duration `yaml:"window"`      // points of this period up to now are aggregated, e.g. 5m
+	Operator    string        `yaml:"operator"`    // >, >=, <, <=, ==, != comparing the aggregate to the threshold
+	Threshold   float64       `yaml:"threshold"`
+	For         time.Duration `yaml:"for"`          // the condition must hold this long before the alert fires, 0 fires immediately
+	Interval    time.Duration `yaml:"interval"`     // evaluation interval, defaults to 1m
+	Webhook     string        `yaml:"webhook"`      // url receiving a json POST when an alert fires or resolves
+	AlertSeries string        `yaml:"alert_series"` // series in the rule namespace, the number of firing series is written every evaluation
+}
+
+// a query evaluated on an interval, the result is written as a point of a new series
+type RecordingRuleOpts struct {
+	Name        string        `yaml:"name"` // series the result is written to, in the rule namespace
+	Tags        []string      `yaml:"tags"` // of the result series
+	Namespace   int           `yaml:"namespace"`
+	Series      string        `yaml:"series"`      // series name
+	Tag         string        `yaml:"tag"`         // or all series with this tag
+	Aggregation string        `yaml:"aggregation"` // per series over the window: avg (default), min, max, sum, count, last, increase, rate (per second)
+	Window      time.Duration `yaml:"window"`      // points of this period up to now are aggregated, e.g. 1m
+	Combine     string        `yaml:"combine"`     // of the series aggregates: sum (default), avg, min, max, count
+	Interval    time.Duration `yaml:"interval"`    // evaluation interval, defaults to 1m
+}
+
+// contents of alert_rules_path
+type AlertRulesFile struct {
+	AlertRules []AlertRuleOpts `yaml:"alert_rules"`
+}
+
+type UserOpts struct {
+	Name        string           `yaml:"name"`
+	AuthToken   string           `yaml:"auth_token"`
+	Identity    string           `yaml:"identity"` // if set, the user can only authenticate over mutual tls with this client certificate identity
+	Permissions []PermissionOpts `yaml:"permissions"`
+}
+
+type PermissionOpts struct {
+	Namespaces []int    `yaml:"namespaces"` // empty means all namespaces
+	Rights     []string `yaml:"rights"`     // read, write, admin
 }
 
 type BackendOpts struct {
@@ -1,10 +1,12 @@
 package server_test
 
 import (
+	"github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/server"
 	"github.com/RobinUS2/tsxdb/tools"
 	"io/ioutil"
 	"testing"
+	"time"
 )
 
 func TestOpts_ReadYamlFile(t *testing.T) {
@@ -39,3 +41,112 @@ connection:
 		t.Error(opts.AuthToken)
 	}
 }
+
+func TestOpts_ReadYamlFileTls(t *testing.T) {
+	opts := server.NewOpts()
+	tmpFile, err := ioutil.TempFile("", "opts_test")
+	if err != nil {
+		t.Error(err)
+	}
+	yml := `
+connection:
+  tls:
+    enabled: true
+    cert_file: server.pem
+    key_file: server.key
+    ca_file: ca.pem
+    client_auth: require_and_verify
+    allowed_identities: ["ingest"]
+telnet_tls:
+  enabled: true
+`
+	if err := ioutil.WriteFile(tmpFile.Name(), []byte(yml), 0644); err != nil {
+		t.Error(err)
+	}
+	if err := tools.ReadYamlFile(tmpFile.Name(), &opts); err != nil {
+		t.Error(err)
+	}
+	if !opts.Tls.Enabled || opts.Tls.CertFile != "server.pem" || opts.Tls.KeyFile != "server.key" || opts.Tls.CaFile != "ca.pem" {
+		t.Error(opts.Tls)
+	}
+	if opts.Tls.ClientAuth != rpc.ClientAuthRequireAndVerify {
+		t.Error(opts.Tls.ClientAuth)
+	}
+	if len(opts.Tls.AllowedIdentities) != 1 || opts.Tls.AllowedIdentities[0] != "ingest" {
+		t.Error(opts.Tls.AllowedIdentities)
+	}
+	if !opts.TelnetTls.Enabled {
+		t.Error(opts.TelnetTls)
+	}
+}
+
+func TestOpts_ReadYamlFileAlertRules(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "alert_rules_test")
+	if err != nil {
+		t.Error(err)
+	}
+	yml := `
+alert_rules:
+  - name: highCpu
+    namespace: 1
+    tag: cpu
+    aggregation: max
+    window: 5m
+    operator: ">"
+    threshold: 90
+    for: 30s
+    webhook: "http://localhost:8080/alerts"
+    alert_series: cpuAlerts
+`
+	if err := ioutil.WriteFile(tmpFile.Name(), []byte(yml), 0644); err != nil {
+		t.Error(err)
+	}
+	var file server.AlertRulesFile
+	if err := tools.ReadYamlFileInPath(tmpFile.Name(), &file); err != nil {
+		t.Fatal(err)
+	}
+	if len(file.AlertRules) != 1 {
+		t.Fatal(file.AlertRules)
+	}
+	rule := file.AlertRules[0]
+	if rule.Name != "highCpu" || rule.Namespace != 1 || rule.Tag != "cpu" || rule.Aggregation != "max" || rule.Operator != ">" || rule.Threshold != 90 {
+		t.Error(rule)
+	}
+	if rule.Window != 5*time.Minute || rule.For != 30*time.Second || rule.Interval != 0 {
+		t.Error(rule.Window, rule.For, rule.Interval)
+	}
+	if rule.Webhook != "http://localhost:8080/alerts" || rule.AlertSeries != "cpuAlerts" {
+		t.Error(rule.Webhook, rule.AlertSeries)
+	}
+}
+
+func TestOpts_ReadYamlFileLimits(t *testing.T) {
+	opts := server.NewOpts()
+	tmpFile, err := ioutil.TempFile("", "opts_test")
+	if err != nil {
+		t.Error(err)
+	}
+	yml := `
+limits:
+  max_series_per_namespace: 1000
+  max_label_values: 50
+  namespaces:
+    2:
+      max_series: 10
+    3:
+      max_label_values: 5
+`
+	if err := ioutil.WriteFile(tmpFile.Name(), []byte(yml), 0644); err != nil {
+		t.Error(err)
+	}
+	if err := tools.ReadYamlFile(tmpFile.Name(), &opts); err != nil {
+		t.Fatal(err)
+	}
+	limits := opts.Limits
+	if limits.MaxSeriesPerNamespace != 1000 || limits.MaxLabelValues != 50 {
+		t.Error(limits)
+	}
+	if limits.Namespaces[2] != (server.NamespaceLimitOpts{MaxSeries: 10}) || limits.Namespaces[3] != (server.NamespaceLimitOpts{MaxLabelValues: 5}) {
+		t.Error(limits.Namespaces)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	mux    sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64 // max tokens
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst float64) *tokenBucket {
+	if burst < rate {
+		// one second worth
+		burst = rate
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// take n tokens if available, requests larger than the burst pass on a full bucket and leave it in debt
+func (bucket *tokenBucket) take(n float64) bool {
+	bucket.mux.Lock()
+	defer bucket.mux.Unlock()
+	now := time.Now()
+	bucket.tokens = math.Min(bucket.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*bucket.rate)
+	bucket.last = now
+	if bucket.tokens < math.Min(n, bucket.burst) {
+		return false
+	}
+	bucket.tokens -= n
+	return true
+}
+
+func (bucket *tokenBucket) refund(n float64) {
+	bucket.mux.Lock()
+	bucket.tokens = math.Min(bucket.burst, bucket.tokens+n)
+	bucket.mux.Unlock()
+}
+
+type RateLimits struct {
+	pointBuckets    map[int]*tokenBucket // namespace => written points
+	pointBucketsMux sync.Mutex
+}
+
+func NewRateLimits() *RateLimits {
+	return &RateLimits{
+		pointBuckets: make(map[int]*tokenBucket),
+	}
+}
+
+// request bucket of a new session, nil if unlimited
+func (instance *Instance) newRequestBucket() *tokenBucket {
+	limits := instance.opts.Limits
+	if limits.RequestsPerSecond <= 0 {
+		return nil
+	}
+	return newTokenBucket(limits.RequestsPerSecond, limits.RequestsBurst)
+}
+
+// take points to write from the buckets of the namespaces, all or nothing
+func (instance *Instance) allowPoints(pointsPerNamespace map[int]int) bool {
+	limits := instance.opts.Limits
+	if limits.PointsPerSecond <= 0 {
+		return true
+	}
+	instance.pointBucketsMux.Lock()
+	defer instance.pointBucketsMux.Unlock()
+	taken := make(map[int]int)
+	for namespace, points := range pointsPerNamespace {
+		bucket := instance.pointBuckets[namespace]
+		if bucket == nil {
+			bucket = newTokenBucket(limits.PointsPerSecond, limits.PointsBurst)
+			instance.pointBuckets[namespace] = bucket
+		}
+		if !bucket.take(float64(points)) {
+			// return what was taken from other namespaces
+			for namespace, points := range taken {
+				instance.pointBuckets[namespace].refund(float64(points))
+			}
+			return false
+		}
+		taken[namespace] = points
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(10, 0)
+	if bucket.burst != 10 {
+		t.Error(bucket.burst)
+	}
+	if !bucket.take(10) {
+		t.Error("expected full bucket")
+	}
+	if bucket.take(1) {
+		t.Error("expected empty bucket")
+	}
+	time.Sleep(250 * time.Millisecond)
+	if !bucket.take(2) {
+		t.Error("expected refill")
+	}
+
+	// larger than burst passes on a full bucket and leaves debt
+	bucket = newTokenBucket(1, 5)
+	if !bucket.take(20) {
+		t.Error("expected large take on full bucket")
+	}
+	if bucket.take(1) {
+		t.Error("expected bucket in debt")
+	}
+	bucket.refund(20)
+	if !bucket.take(5) {
+		t.Error("expected refund")
+	}
+}
+
+func TestAllowPoints(t *testing.T) {
+	opts := NewOpts()
+	opts.Limits.PointsPerSecond = 10
+	instance := New(opts)
+	if !instance.allowPoints(map[int]int{1: 10}) {
+		t.Error("expected points within limit")
+	}
+	if instance.allowPoints(map[int]int{1: 1, 2: 1}) {
+		t.Error("expected namespace 1 to be limited")
+	}
+	// namespace 2 is refunded when the request is rejected
+	if !instance.allowPoints(map[int]int{2: 10}) {
+		t.Error("expected namespace 2 to be unaffected")
+	}
+}
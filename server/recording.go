@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/pkg/errors"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRecordingInterval = time.Minute
+
+// combinations of the aggregates of all series of a recording rule
+var recordingCombinations = []string{"sum", "avg", "min", "max", "count"}
+
+type recordingRule struct {
+	opts      RecordingRuleOpts
+	aggregate aggregation
+	combine   aggregation
+}
+
+type Recording struct {
+	server      *Instance
+	rules       []*recordingRule
+	tickers     []*time.Ticker
+	numRecorded uint64 // points written by all rules
+}
+
+func newRecordingRule(opts RecordingRuleOpts) (*recordingRule, error) {
+	if len(opts.Name) < 1 {
+		return nil, errors.New("missing name")
+	}
+	if strings.Contains(opts.Name, " ") {
+		return nil, fmt.Errorf("rule %s: %s", opts.Name, types.RpcErrorSeriesNameWhitespace)
+	}
+	if len(opts.Series) < 1 && len(opts.Tag) < 1 {
+		return nil, fmt.Errorf("rule %s: missing series or tag", opts.Name)
+	}
+	if opts.Series == opts.Name {
+		return nil, fmt.Errorf("rule %s: the result series can not be the source", opts.Name)
+	}
+	for _, tag := range opts.Tags {
+		if len(opts.Tag) > 0 && tag == opts.Tag {
+			return nil, fmt.Errorf("rule %s: the result series can not have the source tag %s", opts.Name, tag)
+		}
+	}
+	if len(opts.Aggregation) < 1 {
+		opts.Aggregation = "avg"
+	}
+	aggregate, found := aggregations[opts.Aggregation]
+	if !found {
+		return nil, fmt.Errorf("rule %s: unknown aggregation %s", opts.Name, opts.Aggregation)
+	}
+	if len(opts.Combine) < 1 {
+		opts.Combine = "sum"
+	}
+	var combine aggregation
+	for _, name := range recordingCombinations {
+		if name == opts.Combine {
+			combine = aggregations[name]
+		}
+	}
+	if combine == nil {
+		return nil, fmt.Errorf("rule %s: unknown combine %s, expected one of %s", opts.Name, opts.Combine, strings.Join(recordingCombinations, ", "))
+	}
+	if opts.Window <= 0 {
+		return nil, fmt.Errorf("rule %s: missing window", opts.Name)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultRecordingInterval
+	}
+	return &recordingRule{
+		opts:      opts,
+		aggregate: aggregate,
+		combine:   combine,
+	}, nil
+}
+
+func (instance *Instance) initRecording() error {
+	recording := &Recording{
+		server: instance,
+	}
+	names := make(map[string]bool)
+	for _, opts := range instance.opts.RecordingRules {
+		rule, err := newRecordingRule(opts)
+		if err != nil {
+			return errors.Wrap(err, "invalid recording rule")
+		}
+		key := fmt.Sprintf("%d %s", opts.Namespace, opts.Name)
+		if names[key] {
+			return fmt.Errorf("invalid recording rule: duplicate series %s in namespace %d", opts.Name, opts.Namespace)
+		}
+		names[key] = true
+		recording.rules = append(recording.rules, rule)
+	}
+	instance.recording = recording
+	return nil
+}
+
+// evaluate every rule on its own interval
+func (recording *Recording) start() {
+	for _, rule := range recording.rules {
+		rule := rule
+		recording.tickers = append(recording.tickers, recording.server.every(rule.opts.Interval, "recording rule "+rule.opts.Name, func(now time.Time) error {
+			return recording.evaluate(rule, now)
+		}))
+	}
+}
+
+func (recording *Recording) stop() {
+	for _, ticker := range recording.tickers {
+		ticker.Stop()
+	}
+}
+
+// aggregate the window of every series, combine them and write the result at the evaluation time
+func (recording *Recording) evaluate(rule *recordingRule, now time.Time) error {
+	server := recording.server
+	series, err := server.findSeries(rule.opts.Namespace, rule.opts.Series, rule.opts.Tag)
+	if err != nil {
+		return err
+	}
+	to := uint64(now.UnixNano() / int64(time.Millisecond))
+	from := to - uint64(rule.opts.Window/time.Millisecond)
+
+	// series without points in the window are left out, except when counting, as are non-numeric and unreadable series
+	aggregates := make([]float64, 0, len(series))
+	for _, meta := range series {
+		if !meta.ValueType.Numeric() {
+			continue
+		}
+		points, err := server.readSeries(rule.opts.Namespace, uint64(meta.Id), from, to)
+		if err != nil {
+			if logger := server.errorLogSampler.Sample(server.log, "recording rule "+rule.opts.Name); logger != nil {
+				logger.Warnf("recording rule %s: series %s: %s", rule.opts.Name, meta.Name, err)
+			}
+			continue
+		}
+		if len(points) < 1 && rule.opts.Aggregation != "count" {
+			continue
+		}
+		aggregates = append(aggregates, rule.aggregate(sortedValues(points), rule.opts.Window))
+	}
+	if len(aggregates) < 1 && rule.opts.Combine != "count" {
+		// nothing to record
+		return nil
+	}
+	value := rule.combine(aggregates, rule.opts.Window)
+	if err := server.writeSeries(rule.opts.Namespace, rule.opts.Name, rule.opts.Tags, []uint64{to}, []float64{value}); err != nil {
+		return err
+	}
+	atomic.AddUint64(&recording.numRecorded, 1)
+	return nil
+}
+
+func (recording *Recording) recorded() uint64 {
+	return atomic.LoadUint64(&recording.numRecorded)
+}
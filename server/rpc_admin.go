@@ -0,0 +1,326 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewAdminEndpoint())
+}
+
+type AdminEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *AdminEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewAdminEndpoint() *AdminEndpoint {
+	return &AdminEndpoint{}
+}
+
+func (endpoint *AdminEndpoint) Execute(args *types.AdminRequest, resp *types.AdminResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointAdmin, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+
+	// server wide operations, only for admins of all namespaces
+	if !session.user.Admin() {
+		resp.Error = &types.RpcErrorPermissionDenied
+		return nil
+	}
+	logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+	logFields[tools.LogFieldNamespace] = args.Namespace
+	server.log.WithFields(logFields).Infof("admin command %s", args.Command)
+
+	if err := server.executeAdminCommand(args, resp); err != nil {
+		server.logRequestError("admin."+string(args.Command), logFields, err)
+		resp.Error = types.WrapErrorPointer(err)
+	}
+	return nil
+}
+
+func (instance *Instance) executeAdminCommand(args *types.AdminRequest, resp *types.AdminResponse) error {
+	switch args.Command {
+	case types.AdminCommandNamespaces:
+		result := instance.metaStore.ListNamespaces()
+		if result.Error != nil {
+			return result.Error
+		}
+		resp.Namespaces = result.Namespaces
+	case types.AdminCommandSeries:
+		result := instance.metaStore.ListSeries(&backend.ListSeries{Namespace: args.Namespace})
+		if result.Error != nil {
+			return result.Error
+		}
+		for _, meta := range result.Series {
+			if !strings.Contains(meta.Name, args.Name) {
+				continue
+			}
+			resp.Series = append(resp.Series, adminSeries(meta))
+		}
+	case types.AdminCommandDescribe:
+		result := instance.metaStore.ListSeries(&backend.ListSeries{Namespace: args.Namespace, Ids: []uint64{args.Series}})
+		if result.Error != nil {
+			return result.Error
+		}
+		series := adminSeries(result.Series[0])
+		adminBackend, err := instance.selectAdminBackend(args.Namespace, args.Series)
+		if err != nil {
+			return err
+		}
+		described := adminBackend.DescribeSeries(backend.Context{Namespace: args.Namespace, Series: args.Series})
+		if described.Error != nil {
+			return described.Error
+		}
+		series.Points = described.Points
+		series.From = described.From
+		series.To = described.To
+		resp.Series = []types.AdminSeries{series}
+	case types.AdminCommandDelete:
+		result := instance.deleteSeries(&backend.DeleteSeries{
+			Series: []types.SeriesIdentifier{{Namespace: args.Namespace, Id: args.Series}},
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		resp.Affected = 1
+	case types.AdminCommandStats:
+		resp.Stats = instance.adminStats()
+	case types.AdminCommandSessions:
+		resp.Sessions = instance.adminSessions()
+	case types.AdminCommandConnections:
+		resp.Connections = instance.RemoteAddrs()
+	case types.AdminCommandKick:
+		if instance.removeSession(SessionId(args.Session)) {
+			atomic.AddUint64(&instance.loggedOutSessions, 1)
+			resp.Affected = 1
+		}
+	case types.AdminCommandFlush:
+		for _, b := range instance.backends {
+			if adminBackend, ok := b.(backend.IAdminBackend); ok {
+				if err := adminBackend.FlushAll(); err != nil {
+					return err
+				}
+			}
+		}
+	case types.AdminCommandCompact:
+		for _, b := range instance.backends {
+			if adminBackend, ok := b.(backend.IAdminBackend); ok {
+				result := adminBackend.Compact()
+				resp.Affected += result.Removed
+				if result.Error != nil {
+					return result.Error
+				}
+			}
+		}
+	case types.AdminCommandSnapshot, types.AdminCommandRestore:
+		path, err := instance.snapshotDirPath(args.Path)
+		if err != nil {
+			return err
+		}
+		var result SnapshotResult
+		if args.Command == types.AdminCommandSnapshot {
+			result, err = instance.SnapshotFile(path)
+		} else {
+			result, err = instance.RestoreFile(path)
+		}
+		resp.Affected = result.Series
+		resp.Points = result.Points
+		if err != nil {
+			return err
+		}
+	case types.AdminCommandAlerts:
+		resp.Alerts = instance.alerting.alerts()
+	case types.AdminCommandCardinality:
+		cardinality, err := instance.adminCardinality(args.Namespace, args.Limit)
+		if err != nil {
+			return err
+		}
+		resp.Cardinality = cardinality
+	default:
+		return fmt.Errorf("unknown admin command %s", args.Command)
+	}
+	return nil
+}
+
+// data backend of the series, if it supports administration
+func (instance *Instance) selectAdminBackend(namespace int, series uint64) (backend.IAdminBackend, error) {
+	b, err := instance.SelectBackend(backend.ContextBackend{Context: backend.Context{Namespace: namespace, Series: series}})
+	if err != nil {
+		return nil, err
+	}
+	if instrumented, ok := b.(*instrumentedBackend); ok {
+		b = instrumented.unwrap()
+	}
+	adminBackend, ok := b.(backend.IAdminBackend)
+	if !ok {
+		return nil, fmt.Errorf("backend %s does not support administration", b.Type())
+	}
+	return adminBackend, nil
+}
+
+func adminSeries(meta backend.SeriesMetadata) types.AdminSeries {
+	return types.AdminSeries{
+		SeriesIdentifier: types.SeriesIdentifier{
+			Namespace: meta.Namespace.Int(),
+			Id:        uint64(meta.Id),
+		},
+		Name:      meta.Name,
+		Tags:      meta.Tags,
+		TtlExpire: meta.TtlExpire,
+		ValueType: meta.ValueType,
+		Enum:      meta.Enum,
+		Fields:    meta.Fields,
+		Labels:    meta.Labels,
+		Created:   meta.Created,
+	}
+}
+
+// series of a namespace, distinct values per label key and who created the series when
+func (instance *Instance) adminCardinality(namespace int, limit int) (*types.AdminCardinality, error) {
+	result := instance.metaStore.ListSeries(&backend.ListSeries{Namespace: namespace})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	cardinality := &types.AdminCardinality{
+		Series: len(result.Series),
+	}
+
+	// label keys
+	names := instance.metaStore.ListLabels(&backend.ListLabels{Namespace: namespace})
+	if names.Error != nil {
+		return nil, names.Error
+	}
+	for _, name := range names.Values {
+		values := instance.metaStore.ListLabels(&backend.ListLabels{Namespace: namespace, Name: name})
+		if values.Error != nil {
+			return nil, values.Error
+		}
+		cardinality.Labels = append(cardinality.Labels, types.AdminCardinalityCount{Key: name, Count: len(values.Values)})
+	}
+	sort.SliceStable(cardinality.Labels, func(i, j int) bool {
+		return cardinality.Labels[i].Count > cardinality.Labels[j].Count
+	})
+
+	// days and creators, series created before these were recorded are not counted
+	days := make(map[string]int)
+	creators := make(map[backend.SeriesCreator]int)
+	for _, meta := range result.Series {
+		if meta.Created > 0 {
+			days[time.Unix(int64(meta.Created), 0).UTC().Format("2006-01-02")]++
+		}
+		if meta.Creator != nil {
+			creators[*meta.Creator]++
+		}
+	}
+	for day, count := range days {
+		cardinality.Days = append(cardinality.Days, types.AdminCardinalityCount{Key: day, Count: count})
+	}
+	sort.Slice(cardinality.Days, func(i, j int) bool {
+		return cardinality.Days[i].Key < cardinality.Days[j].Key
+	})
+	for creator, count := range creators {
+		cardinality.Creators = append(cardinality.Creators, types.AdminCreatorCardinality{User: creator.User, Session: creator.Session, Series: count})
+	}
+	sort.Slice(cardinality.Creators, func(i, j int) bool {
+		a, b := cardinality.Creators[i], cardinality.Creators[j]
+		if a.Series != b.Series {
+			return a.Series > b.Series
+		}
+		return a.Session < b.Session
+	})
+
+	// top n
+	if limit > 0 {
+		if len(cardinality.Labels) > limit {
+			cardinality.Labels = cardinality.Labels[:limit]
+		}
+		if len(cardinality.Creators) > limit {
+			cardinality.Creators = cardinality.Creators[:limit]
+		}
+	}
+	return cardinality, nil
+}
+
+func (instance *Instance) adminStats() map[string]uint64 {
+	stats := instance.Statistics()
+	return map[string]uint64{
+		"calls":               stats.NumCalls(),
+		"values_written":      stats.NumValuesWritten(),
+		"series_created":      stats.NumSeriesCreated(),
+		"series_initialised":  stats.NumSeriesInitialised(),
+		"series_updated":      stats.NumSeriesUpdated(),
+		"series_expired":      stats.NumSeriesExpired(),
+		"authentications":     stats.NumAuthentications(),
+		"reads":               stats.NumReads(),
+		"rate_limited":        stats.NumRateLimited(),
+		"quota_exceeded":      stats.NumQuotaExceeded(),
+		"sessions_active":     stats.NumSessionsActive(),
+		"sessions_expired":    stats.NumSessionsExpired(),
+		"sessions_logged_out": stats.NumSessionsLoggedOut(),
+		"connections_active":  uint64(instance.ActiveConnections()),
+		"connections_expired": instance.ExpiredConnections(),
+		"pending_requests":    uint64(atomic.LoadInt64(&instance.pendingRequests)),
+		"subscriptions":       uint64(instance.ActiveSubscriptions()),
+		"alerts_firing":       uint64(instance.alerting.firing()),
+		"points_recorded":     instance.recording.recorded(),
+	}
+}
+
+func (instance *Instance) adminSessions() []types.AdminSession {
+	instance.sessionsMux.RLock()
+	sessions := make([]types.AdminSession, 0, len(instance.sessions))
+	for id, session := range instance.sessions {
+		sessions = append(sessions, types.AdminSession{
+			Id:       int(id),
+			User:     session.user.Name(),
+			Identity: session.identity,
+			Expires:  atomic.LoadInt64(&session.expires),
+		})
+	}
+	instance.sessionsMux.RUnlock()
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Id < sessions[j].Id
+	})
+	return sessions
+}
+
+func (endpoint *AdminEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *AdminEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointAdmin)
+}
@@ -43,12 +43,25 @@ func (endpoint *AuthEndpoint) Execute(args *types.AuthRequest, resp *types.AuthR
 		}
 	}()
 
+	// older clients do not send a version
+	if args.Version != types.AuthVersion {
+		resp.Error = &types.RpcErrorAuthVersion
+		return nil
+	}
+
 	nonce, _ := base64.StdEncoding.DecodeString(args.Nonce)
 	signature, _ := base64.StdEncoding.DecodeString(args.Signature)
 
-	// signature
+	// user (empty name is the shared auth token)
 	server := endpoint.getServer()
-	mac := hmac.New(sha512.New, []byte(server.opts.AuthToken))
+	user := server.getUser(args.User)
+	if user == nil {
+		resp.Error = &types.RpcErrorAuthFailed
+		return nil
+	}
+
+	// signature
+	mac := hmac.New(sha512.New, user.token)
 	mac.Write(nonce)
 	expected := mac.Sum(nil)
 	if !hmac.Equal(signature, expected) || nonce == nil || len(nonce) < 32 || signature == nil || len(signature) < 1 {
@@ -56,13 +69,21 @@ func (endpoint *AuthEndpoint) Execute(args *types.AuthRequest, resp *types.AuthR
 		return nil
 	}
 
+	// users bound to a client certificate can only authenticate over mutual tls with that identity
+	if user.identity != "" && user.identity != args.PeerIdentity() {
+		resp.Error = &types.RpcErrorAuthFailed
+		return nil
+	}
+
 	// validate stage specific
-	if args.SessionTicket.Nonce == 0 {
-		// stage 1
-		var token = make([]byte, 32)
-		if _, err := rand.Read(token); err != nil {
+	if args.SessionTicket.Id == 0 {
+		// stage 1, replaying this is harmless since the session secret can only be derived with the auth token
+		var serverNonce = make([]byte, 32)
+		if _, err := rand.Read(serverNonce); err != nil {
 			resp.Error = types.WrapErrorPointer(errors.New("entropy error"))
+			return nil
 		}
+		token := tools.SessionSecret(user.token, nonce, serverNonce)
 		if len(token) != 32 {
 			panic("token length")
 		}
@@ -73,16 +94,21 @@ func (endpoint *AuthEndpoint) Execute(args *types.AuthRequest, resp *types.AuthR
 				break
 			}
 		}
-		resp.SessionSecret = base64.StdEncoding.EncodeToString(token)
+		resp.ServerNonce = base64.StdEncoding.EncodeToString(serverNonce)
 
 		// store in server
-		server.registerSessionToken(SessionId(resp.SessionId), token)
+		server.registerSessionToken(SessionId(resp.SessionId), token, args.PeerIdentity(), user)
 	} else {
 		// stage 2
-		if err := server.validateSession(args.SessionTicket); err != nil {
+		session, err := server.validateSession(args.SessionTicket, types.EndpointAuth, args)
+		if err != nil {
 			resp.Error = types.WrapErrorPointer(err)
 			return nil
 		}
+		if session.user != user {
+			resp.Error = &types.RpcErrorAuthFailed
+			return nil
+		}
 
 		// auth stats
 		atomic.AddUint64(&server.numAuthentications, 1)
@@ -106,27 +132,60 @@ func (endpoint *AuthEndpoint) name() EndpointName {
 	return EndpointName(types.EndpointAuth)
 }
 
-func (instance *Instance) validateSession(ticket types.SessionTicket) error {
+var errSessionExpired = types.RpcErrorSessionExpired.Error()
+var errRateLimited = types.RpcErrorRateLimited.Error()
+
+// response error for a failed session validation, clients authenticate again on expired sessions and back off when rate limited
+func sessionError(err error) *types.RpcError {
+	switch err {
+	case errSessionExpired:
+		return &types.RpcErrorSessionExpired
+	case errRateLimited:
+		return &types.RpcErrorRateLimited
+	}
+	return &types.RpcErrorAuthFailed
+}
+
+func (instance *Instance) validateSession(ticket types.SessionTicket, endpoint types.Endpoint, request types.SignedRequest) (*Session, error) {
 	if ticket.Id == 0 {
-		return errors.New("missing session id")
+		return nil, errors.New("missing session id")
 	}
 	if ticket.Nonce == 0 {
-		return errors.New("missing session nonce")
+		return nil, errors.New("missing session nonce")
+	}
+	session := instance.getSession(SessionId(ticket.Id))
+	if session == nil || len(session.token) != 32 {
+		// expired or logged out
+		return nil, errSessionExpired
 	}
-	token := instance.getTokenFromSessionId(SessionId(ticket.Id))
-	if len(token) != 32 {
-		return errors.New("session continuation token not found")
+	token := session.token
+
+	// sessions created over mutual tls can only be continued by the same client certificate identity
+	if session.identity != ticket.PeerIdentity() {
+		return nil, types.RpcErrorAuthFailed.Error()
+	}
+
+	// signature over nonce and request (constant time)
+	if !tools.VerifySessionTicket(token, ticket, endpoint, request) {
+		return nil, types.RpcErrorAuthFailed.Error()
 	}
-	//log.Printf("token should be %s", base64.StdEncoding.EncodeToString(token))
 
-	// compute of nonce
-	expectedSessionSignature := tools.HmacInt(token, ticket.Nonce)
-	if expectedSessionSignature != ticket.Signature {
-		return types.RpcErrorAuthFailed.Error()
+	// each nonce is only valid once
+	if !session.acceptNonce(ticket.Nonce) {
+		return nil, types.RpcErrorReplayedNonce.Error()
+	}
+
+	// sliding expiry
+	session.touch()
+
+	// calls per session
+	if session.requests != nil && !session.requests.take(1) {
+		atomic.AddUint64(&instance.numRateLimited, 1)
+		return nil, errRateLimited
 	}
 
 	// track statistics of calls
 	atomic.AddUint64(&instance.numCalls, 1)
 
-	return nil
+	return session, nil
 }
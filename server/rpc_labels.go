@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"sync"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewLabelsEndpoint())
+}
+
+type LabelsEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *LabelsEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewLabelsEndpoint() *LabelsEndpoint {
+	return &LabelsEndpoint{}
+}
+
+func (endpoint *LabelsEndpoint) Execute(args *types.LabelsRequest, resp *types.LabelsResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointLabels, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+	if !session.user.Allowed(args.Namespace, RightRead) {
+		resp.Error = &types.RpcErrorPermissionDenied
+		return nil
+	}
+
+	// label names or values
+	result := server.metaStore.ListLabels(&backend.ListLabels{Namespace: args.Namespace, Name: args.Name})
+	if result.Error != nil {
+		logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+		logFields[tools.LogFieldNamespace] = args.Namespace
+		server.logRequestError("backend.ListLabels", logFields, result.Error)
+		resp.Error = types.WrapErrorPointer(result.Error)
+		return nil
+	}
+	resp.Values = result.Values
+	return nil
+}
+
+func (endpoint *LabelsEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *LabelsEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointLabels)
+}
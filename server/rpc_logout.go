@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"sync"
+	"sync/atomic"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewLogoutEndpoint())
+}
+
+type LogoutEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *LogoutEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewLogoutEndpoint() *LogoutEndpoint {
+	return &LogoutEndpoint{}
+}
+
+func (endpoint *LogoutEndpoint) Execute(args *types.LogoutRequest, resp *types.LogoutResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	if _, err := server.validateSession(args.SessionTicket, types.EndpointLogout, args); err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+
+	// end session, further calls with it fail as expired
+	if server.removeSession(SessionId(args.SessionTicket.Id)) {
+		atomic.AddUint64(&server.loggedOutSessions, 1)
+	}
+	return nil
+}
+
+func (endpoint *LogoutEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *LogoutEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointLogout)
+}
@@ -27,7 +27,7 @@ func NewNoOpEndpoint() *NoOpEndpoint {
 	return &NoOpEndpoint{}
 }
 
-func (endpoint *NoOpEndpoint) Execute(args *types.ReadRequest, resp *types.ReadResponse) error {
+func (endpoint *NoOpEndpoint) Execute(args *types.NoOpRequest, resp *types.NoOpResponse) error {
 	// deal with panics, else the whole RPC server could crash
 	defer func() {
 		if r := recover(); r != nil {
@@ -37,8 +37,8 @@ func (endpoint *NoOpEndpoint) Execute(args *types.ReadRequest, resp *types.ReadR
 
 	// auth
 	server := endpoint.getServer()
-	if err := server.validateSession(args.SessionTicket); err != nil {
-		resp.Error = &types.RpcErrorAuthFailed
+	if _, err := server.validateSession(args.SessionTicket, types.EndpointNoOp, args); err != nil {
+		resp.Error = sessionError(err)
 		return nil
 	}
 	return nil
@@ -2,8 +2,10 @@ package server
 
 import (
 	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/server/backend"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -31,6 +33,14 @@ func NewReaderEndpoint() *ReaderEndpoint {
 }
 
 func (endpoint *ReaderEndpoint) Execute(args *types.ReadRequest, resp *types.ReadResponse) error {
+	server := endpoint.getServer()
+
+	// tracing, ended after recovering from panics
+	ctx, span := server.startEndpointSpan(types.EndpointReader, &args.TraceContext)
+	defer func() {
+		endSpan(span, resp.Error)
+	}()
+
 	// deal with panics, else the whole RPC server could crash
 	defer func() {
 		if r := recover(); r != nil {
@@ -38,16 +48,24 @@ func (endpoint *ReaderEndpoint) Execute(args *types.ReadRequest, resp *types.Rea
 		}
 	}()
 
-	server := endpoint.getServer()
-
 	// auth
-	if err := server.validateSession(args.SessionTicket); err != nil {
-		resp.Error = &types.RpcErrorAuthFailed
+	session, err := server.validateSession(args.SessionTicket, types.EndpointReader, args)
+	if err != nil {
+		resp.Error = sessionError(err)
 		return nil
 	}
 
+	// permissions, checked for all queries before reading any
+	for _, query := range args.Queries {
+		if !session.user.Allowed(query.Namespace, RightRead) {
+			resp.Error = &types.RpcErrorPermissionDenied
+			return nil
+		}
+	}
+
 	// backend
 	finalResults := make(map[uint64]map[uint64]float64)
+	numPoints := 0
 	for _, query := range args.Queries {
 		c := backend.ContextBackend{}
 		c.Series = query.Id
@@ -58,20 +76,79 @@ func (endpoint *ReaderEndpoint) Execute(args *types.ReadRequest, resp *types.Rea
 			return nil
 		}
 
+		// pagination, the continuation token is the timestamp the next page starts at
+		read := backend.ContextRead{Context: c.Context, From: query.From, To: query.To, Limit: query.Limit}
+		if query.Continuation > 0 {
+			if query.Continuation < query.From || query.Continuation > query.To {
+				resp.Error = types.WrapErrorStringPointer(fmt.Sprintf("invalid continuation %d", query.Continuation))
+				return nil
+			}
+			read.From = query.Continuation
+		}
+		if maxPoints := server.opts.Limits.MaxPointsPerRead; maxPoints > 0 && read.Limit > maxPoints {
+			// smaller pages instead of an error
+			read.Limit = maxPoints
+		}
+
 		// read
-		readResult := backendInstance.Read(backend.ContextRead{Context: c.Context, From: query.From, To: query.To})
+		_, readSpan := server.startBackendSpan(ctx, "backend.Read", seriesAttributes(c.Context)...)
+		readResult := backendInstance.Read(read)
+		rpc.EndSpan(readSpan, readResult.Error)
 		if readResult.Error != nil && !strings.Contains(readResult.Error.Error(), types.RpcErrorNoDataFound.String()) {
 			// return all errors, except if no data found, since we can query 1-N series, 1 series no data is not a fatal error
+			server.logRequestError("backend.Read", requestFields(args.SessionTicket, session, c.Context), readResult.Error)
 			resp.Error = types.WrapErrorPointer(readResult.Error)
 			return nil
 		}
+		if read.Limit > 0 && readResult.Error != nil {
+			// no data is an empty page
+			readResult.Error = nil
+		}
+		if readResult.Error == nil {
+			if readResult, err = readResult.SelectFields(query.Fields); err != nil {
+				resp.Error = types.WrapErrorPointer(err)
+				return nil
+			}
+		}
 		// aggregation layer
 		rollupResults := server.rollupReader.Process(readResult)
 		if rollupResults.Error != nil {
 			resp.Error = types.WrapErrorPointer(rollupResults.Error)
 			return nil
 		}
-		finalResults[query.Id] = rollupResults.Results
+		if read.Limit > 0 {
+			if resp.Pages == nil {
+				resp.Pages = make(map[uint64]types.ReadPage)
+			}
+			resp.Pages[query.Id] = newReadPage(rollupResults)
+		} else if rollupResults.TypedResults != nil {
+			if resp.TypedResults == nil {
+				resp.TypedResults = make(map[uint64]map[uint64]types.Value)
+			}
+			resp.TypedResults[query.Id] = rollupResults.TypedResults
+		} else {
+			finalResults[query.Id] = rollupResults.Results
+		}
+		if rollupResults.ValueType.Typed() {
+			if resp.ValueTypes == nil {
+				resp.ValueTypes = make(map[uint64]types.ValueType)
+			}
+			resp.ValueTypes[query.Id] = rollupResults.ValueType
+		}
+		if len(rollupResults.Fields) > 0 {
+			if resp.Fields == nil {
+				resp.Fields = make(map[uint64][]string)
+			}
+			resp.Fields[query.Id] = rollupResults.Fields
+		}
+
+		// response size
+		numPoints += len(rollupResults.Results) + len(rollupResults.TypedResults)
+		if maxPoints := server.opts.Limits.MaxPointsPerRead; maxPoints > 0 && numPoints > maxPoints {
+			atomic.AddUint64(&server.numQuotaExceeded, 1)
+			resp.Error = types.WrapErrorStringPointer(fmt.Sprintf("%s: more than %d points, narrow the time range", types.RpcErrorQuotaExceeded, maxPoints))
+			return nil
+		}
 	}
 	resp.Results = finalResults
 
@@ -81,6 +158,36 @@ func (endpoint *ReaderEndpoint) Execute(args *types.ReadRequest, resp *types.Rea
 	return nil
 }
 
+// points of a limited read sorted by time
+func newReadPage(result backend.ReadResult) types.ReadPage {
+	page := types.ReadPage{
+		Times:        make([]uint64, 0, len(result.Results)+len(result.TypedResults)),
+		Values:       make([]float64, 0, len(result.Results)),
+		Continuation: result.Next,
+		Fields:       result.Fields,
+	}
+	for ts := range result.Results {
+		page.Times = append(page.Times, ts)
+	}
+	for ts := range result.TypedResults {
+		page.Times = append(page.Times, ts)
+	}
+	sort.Slice(page.Times, func(i, j int) bool {
+		return page.Times[i] < page.Times[j]
+	})
+	if result.TypedResults != nil {
+		page.TypedValues = make([]types.Value, 0, len(result.TypedResults))
+		for _, ts := range page.Times {
+			page.TypedValues = append(page.TypedValues, result.TypedResults[ts])
+		}
+		return page
+	}
+	for _, ts := range page.Times {
+		page.Values = append(page.Values, result.Results[ts])
+	}
+	return page
+}
+
 func (endpoint *ReaderEndpoint) register(opts *EndpointOpts) error {
 	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
 		return err
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"sort"
+	"sync"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewSearchEndpoint())
+}
+
+type SearchEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *SearchEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewSearchEndpoint() *SearchEndpoint {
+	return &SearchEndpoint{}
+}
+
+func (endpoint *SearchEndpoint) Execute(args *types.SearchRequest, resp *types.SearchResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointSearch, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+	if !session.user.Allowed(args.Namespace, RightRead) {
+		resp.Error = &types.RpcErrorPermissionDenied
+		return nil
+	}
+	for _, matcher := range args.Matchers {
+		if _, err := matcher.Compile(); err != nil {
+			resp.Error = types.WrapErrorPointer(err)
+			return nil
+		}
+	}
+	if _, _, err := (types.NameMatcher{Type: args.NameMatch, Pattern: args.Name}).Compile(); err != nil {
+		resp.Error = types.WrapErrorPointer(err)
+		return nil
+	}
+	logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+	logFields[tools.LogFieldNamespace] = args.Namespace
+
+	// matching ids
+	search := &backend.SearchSeries{}
+	search.Namespace = args.Namespace
+	search.Name = args.Name
+	search.NameMatch = args.NameMatch
+	search.Tag = args.Tag
+	search.Matchers = args.Matchers
+	search.Comparator = backend.SearchSeriesComparatorEquals
+	search.After = args.After
+	search.Limit = args.Limit
+	searchResult := server.metaStore.SearchSeries(search)
+	if searchResult.Error != nil {
+		server.logRequestError("backend.SearchSeries", logFields, searchResult.Error)
+		resp.Error = types.WrapErrorPointer(searchResult.Error)
+		return nil
+	}
+	if len(searchResult.Series) < 1 {
+		return nil
+	}
+
+	// metadata of the matches
+	ids := make([]uint64, 0, len(searchResult.Series))
+	for _, series := range searchResult.Series {
+		ids = append(ids, series.Id)
+	}
+	list := server.metaStore.ListSeries(&backend.ListSeries{Namespace: args.Namespace, Ids: ids})
+	if list.Error != nil {
+		server.logRequestError("backend.ListSeries", logFields, list.Error)
+		resp.Error = types.WrapErrorPointer(list.Error)
+		return nil
+	}
+	resp.Series = make([]types.SearchResult, 0, len(list.Series))
+	for _, meta := range list.Series {
+		resp.Series = append(resp.Series, types.SearchResult{
+			Id:     uint64(meta.Id),
+			Name:   meta.Name,
+			Tags:   meta.Tags,
+			Labels: meta.Labels,
+		})
+	}
+
+	// pages in name order, name patterns are paged by the name index of the backend already
+	sort.Slice(resp.Series, func(i, j int) bool {
+		return resp.Series[i].Name < resp.Series[j].Name
+	})
+	if len(args.After) > 0 {
+		first := sort.Search(len(resp.Series), func(i int) bool {
+			return resp.Series[i].Name > args.After
+		})
+		resp.Series = resp.Series[first:]
+	}
+	if args.Limit > 0 && len(resp.Series) > args.Limit {
+		resp.Series = resp.Series[:args.Limit]
+		resp.Next = resp.Series[args.Limit-1].Name
+	}
+	if len(searchResult.Next) > 0 {
+		resp.Next = searchResult.Next
+	}
+	return nil
+}
+
+func (endpoint *SearchEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *SearchEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointSearch)
+}
@@ -1,10 +1,13 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/server/backend"
-	"log"
+	"github.com/RobinUS2/tsxdb/tools"
+	"go.opentelemetry.io/otel/attribute"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -32,7 +35,13 @@ func NewSeriesMetadataEndpoint() *SeriesMetadataEndpoint {
 }
 
 func (endpoint *SeriesMetadataEndpoint) Execute(args *types.SeriesMetadataRequest, resp *types.SeriesMetadataResponse) error {
-	log.Printf("executing SeriesMetadataRequest: %+v", args)
+	server := endpoint.getServer()
+
+	// tracing, ended after recovering from panics
+	ctx, span := server.startEndpointSpan(types.EndpointSeriesMetadata, &args.TraceContext)
+	defer func() {
+		endSpan(span, resp.Error)
+	}()
 
 	// deal with panics, else the whole RPC server could crash
 	defer func() {
@@ -41,48 +50,225 @@ func (endpoint *SeriesMetadataEndpoint) Execute(args *types.SeriesMetadataReques
 		}
 	}()
 
-	server := endpoint.getServer()
-
 	// auth
-	if err := server.validateSession(args.SessionTicket); err != nil {
-		resp.Error = &types.RpcErrorAuthFailed
+	session, err := server.validateSession(args.SessionTicket, types.EndpointSeriesMetadata, args)
+	if err != nil {
+		resp.Error = sessionError(err)
 		return nil
 	}
+	logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+	logFields[tools.LogFieldNamespace] = args.SeriesCreateMetadata.Namespace
+	server.log.WithFields(logFields).Debugf("executing SeriesMetadataRequest: %+v", args.SeriesCreateMetadata)
 
-	// validate name
-	if strings.Contains(args.SeriesCreateMetadata.Name, " ") {
-		resp.Error = &types.RpcErrorSeriesNameWhitespace
-		return nil
-	}
-	if len(args.SeriesCreateMetadata.Name) < 1 {
-		resp.Error = &types.RpcErrorSeriesNameEmpty
+	// validation, permissions and limits
+	resolved, create := server.resolveSeriesMetadata(ctx, session, args.SeriesCreateMetadata, nil)
+	if !create {
+		resp.Id = resolved.Id
+		resp.Error = resolved.Error
+		resp.SeriesCreateIdentifier = resolved.SeriesCreateIdentifier
 		return nil
 	}
 
+	// snapshots wait for series being created
+	server.snapshotMux.RLock()
+	defer server.snapshotMux.RUnlock()
+
 	// metadata
+	_, createSpan := server.startBackendSpan(ctx, "backend.CreateOrUpdateSeries", attribute.Int("tsxdb.namespace", args.SeriesCreateMetadata.Namespace))
 	result := server.metaStore.CreateOrUpdateSeries(&backend.CreateSeries{
 		Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
 			args.SeriesCreateIdentifier: args.SeriesCreateMetadata,
 		},
+		Creator: &backend.SeriesCreator{User: session.user.Name(), Session: args.SessionTicket.Id},
 	})
-	log.Printf("executing SeriesMetadataRequest result: %+v", result)
+	rpc.EndSpan(createSpan, result.Error)
+	if result.Error != nil {
+		server.logRequestError("backend.CreateOrUpdateSeries", logFields, result.Error)
+	}
+	server.log.WithFields(logFields).Debugf("executing SeriesMetadataRequest result: %+v", result)
 	thisResult := result.Results[args.SeriesCreateIdentifier] // only support one for now
 	// for some reason assigning thisResult to resp is not working, probably since the reference is part of the RPC pipe
 	resp.New = thisResult.New
+	resp.Changed = thisResult.Changed
 	resp.Id = thisResult.Id
 	resp.Error = thisResult.Error
 	resp.SeriesCreateIdentifier = thisResult.SeriesCreateIdentifier
 
 	// basic stats
 	if resp.New {
+		server.seriesCreated(args.SeriesCreateMetadata.Namespace, resp.Id, args.SeriesCreateMetadata.Name, args.SeriesCreateMetadata.Tags)
 		atomic.AddUint64(&server.numSeriesCreated, 1)
 	} else {
+		if resp.Changed {
+			server.seriesUpdated(args.SeriesCreateMetadata.Namespace, resp.Id, args.SeriesCreateMetadata.Name, args.SeriesCreateMetadata.Tags)
+			atomic.AddUint64(&server.numSeriesUpdated, 1)
+		}
 		atomic.AddUint64(&server.numSeriesInitialised, 1)
 	}
 
 	return nil
 }
 
+// validates the metadata of one series, create is true if it has to be created or updated in the meta store
+// else the response is final: an error or the id of an existing series for read only users
+// pending tracks what will be created by the same request, nil for a single series
+func (server *Instance) resolveSeriesMetadata(ctx context.Context, session *Session, meta types.SeriesCreateMetadata, pending *pendingCreates) (resp types.SeriesMetadataResponse, create bool) {
+	resp.SeriesCreateIdentifier = meta.SeriesCreateIdentifier
+
+	// validate name
+	if strings.Contains(meta.Name, " ") {
+		resp.Error = &types.RpcErrorSeriesNameWhitespace
+		return resp, false
+	}
+	if len(meta.Name) < 1 {
+		resp.Error = &types.RpcErrorSeriesNameEmpty
+		return resp, false
+	}
+
+	// validate value type, only string series have an enum and only fields series have fields
+	if !meta.ValueType.Valid() || (len(meta.Enum) > 0 && meta.ValueType != types.ValueTypeString) || (len(meta.Fields) > 0 && meta.ValueType != types.ValueTypeFields) {
+		resp.Error = &types.RpcErrorInvalidValueType
+		return resp, false
+	}
+	if err := types.ValidateLabels(meta.Labels); err != nil {
+		resp.Error = types.WrapErrorPointer(err)
+		return resp, false
+	}
+	if meta.ValueType == types.ValueTypeFields {
+		if err := types.ValidateFields(meta.Fields); err != nil {
+			resp.Error = types.WrapErrorPointer(err)
+			return resp, false
+		}
+	}
+
+	// permissions, read only users can resolve existing series but not create them
+	namespace := meta.Namespace
+	if !session.user.Allowed(namespace, RightWrite) {
+		if !session.user.Allowed(namespace, RightRead) {
+			resp.Error = &types.RpcErrorPermissionDenied
+			return resp, false
+		}
+		search := &backend.SearchSeries{}
+		search.Namespace = namespace
+		search.Name = meta.Name
+		search.Comparator = backend.SearchSeriesComparatorEquals
+		_, searchSpan := server.startBackendSpan(ctx, "backend.SearchSeries", attribute.Int("tsxdb.namespace", namespace))
+		searchResult := server.metaStore.SearchSeries(search)
+		rpc.EndSpan(searchSpan, searchResult.Error)
+		if searchResult.Error != nil {
+			resp.Error = types.WrapErrorPointer(searchResult.Error)
+			return resp, false
+		}
+		if len(searchResult.Series) < 1 {
+			resp.Error = &types.RpcErrorPermissionDenied
+			return resp, false
+		}
+		resp.Id = searchResult.Series[0].Id
+		atomic.AddUint64(&server.numSeriesInitialised, 1)
+		return resp, false
+	}
+
+	// distinct values per label name
+	newLabelValues, labelsErr := server.checkLabelValues(namespace, meta.Labels, pending)
+	if labelsErr != nil {
+		resp.Error = labelsErr
+		return resp, false
+	}
+
+	// max series per namespace, only checked for series that do not exist yet (concurrent creates can overshoot slightly)
+	if maxSeries := server.opts.Limits.maxSeries(namespace); maxSeries > 0 {
+		search := &backend.SearchSeries{}
+		search.Namespace = namespace
+		search.Name = meta.Name
+		search.Comparator = backend.SearchSeriesComparatorEquals
+		_, searchSpan := server.startBackendSpan(ctx, "backend.SearchSeries", attribute.Int("tsxdb.namespace", namespace))
+		searchResult := server.metaStore.SearchSeries(search)
+		rpc.EndSpan(searchSpan, searchResult.Error)
+		if searchResult.Error != nil {
+			resp.Error = types.WrapErrorPointer(searchResult.Error)
+			return resp, false
+		}
+		if len(searchResult.Series) < 1 {
+			countResult := server.metaStore.CountSeries(&backend.CountSeries{Namespace: namespace})
+			if countResult.Error != nil {
+				resp.Error = types.WrapErrorPointer(countResult.Error)
+				return resp, false
+			}
+			count := countResult.Count
+			if pending != nil {
+				count += pending.series[namespace]
+			}
+			if count >= maxSeries {
+				atomic.AddUint64(&server.numQuotaExceeded, 1)
+				resp.Error = types.WrapErrorStringPointer(fmt.Sprintf("%s: namespace %d has %d series", types.RpcErrorCardinalityExceeded, namespace, count))
+				return resp, false
+			}
+			if pending != nil {
+				pending.series[namespace]++
+			}
+		}
+	}
+	if pending != nil {
+		for _, key := range newLabelValues {
+			if pending.labelValues[key] == nil {
+				pending.labelValues[key] = make(map[string]bool)
+			}
+			pending.labelValues[key][meta.Labels[key.name]] = true
+		}
+	}
+	return resp, true
+}
+
+// label values that do not exist yet, an error if they exceed the limit of distinct values (concurrent creates can overshoot slightly)
+func (server *Instance) checkLabelValues(namespace int, labels map[string]string, pending *pendingCreates) (newValues []labelName, rpcErr *types.RpcError) {
+	maxValues := server.opts.Limits.maxLabelValues(namespace)
+	if maxValues < 1 {
+		return nil, nil
+	}
+	for _, name := range types.SortedLabelNames(labels) {
+		key := labelName{namespace: namespace, name: name}
+		value := labels[name]
+		if pending != nil && pending.labelValues[key][value] {
+			continue
+		}
+		countResult := server.metaStore.CountLabelValues(&backend.CountLabelValues{Namespace: namespace, Name: name, Value: value})
+		if countResult.Error != nil {
+			return nil, types.WrapErrorPointer(countResult.Error)
+		}
+		if countResult.Exists {
+			continue
+		}
+		count := countResult.Count
+		if pending != nil {
+			count += len(pending.labelValues[key])
+		}
+		if count >= maxValues {
+			atomic.AddUint64(&server.numQuotaExceeded, 1)
+			return nil, types.WrapErrorStringPointer(fmt.Sprintf("%s: label %s of namespace %d has %d values", types.RpcErrorCardinalityExceeded, name, namespace, count))
+		}
+		newValues = append(newValues, key)
+	}
+	return newValues, nil
+}
+
+type labelName struct {
+	namespace int
+	name      string
+}
+
+// series and label values that will be created by the same batch request
+type pendingCreates struct {
+	series      map[int]int // per namespace
+	labelValues map[labelName]map[string]bool
+}
+
+func newPendingCreates() *pendingCreates {
+	return &pendingCreates{
+		series:      make(map[int]int),
+		labelValues: make(map[labelName]map[string]bool),
+	}
+}
+
 func (endpoint *SeriesMetadataEndpoint) register(opts *EndpointOpts) error {
 	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
 		return err
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
+	"sync/atomic"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewSeriesMetadataBatchEndpoint())
+}
+
+type SeriesMetadataBatchEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *SeriesMetadataBatchEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewSeriesMetadataBatchEndpoint() *SeriesMetadataBatchEndpoint {
+	return &SeriesMetadataBatchEndpoint{}
+}
+
+type seriesKey struct {
+	namespace int
+	name      string
+}
+
+func (endpoint *SeriesMetadataBatchEndpoint) Execute(args *types.SeriesMetadataBatchRequest, resp *types.SeriesMetadataBatchResponse) error {
+	server := endpoint.getServer()
+
+	// tracing, ended after recovering from panics
+	ctx, span := server.startEndpointSpan(types.EndpointSeriesMetadataBatch, &args.TraceContext)
+	defer func() {
+		endSpan(span, resp.Error)
+	}()
+
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	session, err := server.validateSession(args.SessionTicket, types.EndpointSeriesMetadataBatch, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+	if len(args.Series) > types.MaxSeriesMetadataBatchSize {
+		resp.Error = types.WrapErrorStringPointer(fmt.Sprintf("batch of %d series exceeds the maximum of %d", len(args.Series), types.MaxSeriesMetadataBatchSize))
+		return nil
+	}
+	logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+	server.log.WithFields(logFields).Debugf("executing SeriesMetadataBatchRequest with %d series", len(args.Series))
+
+	// validation, permissions and limits per series, duplicates in the batch are created once
+	resp.Series = make([]types.SeriesMetadataResponse, len(args.Series))
+	create := make(map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata)
+	createIdentifiers := make(map[seriesKey]types.SeriesCreateIdentifier)
+	pending := newPendingCreates()
+	for i, meta := range args.Series {
+		key := seriesKey{namespace: meta.Namespace, name: meta.Name}
+		if _, found := createIdentifiers[key]; found {
+			continue
+		}
+		resolved, needsCreate := server.resolveSeriesMetadata(ctx, session, meta, pending)
+		resp.Series[i] = resolved
+		if needsCreate {
+			create[meta.SeriesCreateIdentifier] = meta
+			createIdentifiers[key] = meta.SeriesCreateIdentifier
+		}
+	}
+
+	// metadata
+	var results map[types.SeriesCreateIdentifier]types.SeriesMetadataResponse
+	if len(create) > 0 {
+		// snapshots wait for series being created
+		server.snapshotMux.RLock()
+		defer server.snapshotMux.RUnlock()
+
+		_, createSpan := server.startBackendSpan(ctx, "backend.CreateOrUpdateSeries", attribute.Int("tsxdb.series", len(create)))
+		result := server.metaStore.CreateOrUpdateSeries(&backend.CreateSeries{
+			Series:  create,
+			Creator: &backend.SeriesCreator{User: session.user.Name(), Session: args.SessionTicket.Id},
+		})
+		rpc.EndSpan(createSpan, result.Error)
+		if result.Error != nil {
+			server.logRequestError("backend.CreateOrUpdateSeries", logFields, result.Error)
+			resp.Error = types.WrapErrorPointer(result.Error)
+			return nil
+		}
+		results = result.Results
+	}
+
+	// responses in request order
+	for i, meta := range args.Series {
+		identifier, found := createIdentifiers[seriesKey{namespace: meta.Namespace, name: meta.Name}]
+		if !found {
+			continue
+		}
+		result, found := results[identifier]
+		if !found {
+			resp.Series[i].Error = &types.RpcErrorSeriesInitNoId
+			continue
+		}
+		resp.Series[i].Id = result.Id
+		resp.Series[i].Error = result.Error
+		resp.Series[i].SeriesCreateIdentifier = meta.SeriesCreateIdentifier
+		if identifier != meta.SeriesCreateIdentifier {
+			// duplicate
+			continue
+		}
+		resp.Series[i].New = result.New
+		resp.Series[i].Changed = result.Changed
+
+		// basic stats
+		if result.New {
+			server.seriesCreated(meta.Namespace, result.Id, meta.Name, meta.Tags)
+			atomic.AddUint64(&server.numSeriesCreated, 1)
+		} else {
+			if result.Changed {
+				server.seriesUpdated(meta.Namespace, result.Id, meta.Name, meta.Tags)
+				atomic.AddUint64(&server.numSeriesUpdated, 1)
+			}
+			atomic.AddUint64(&server.numSeriesInitialised, 1)
+		}
+	}
+	return nil
+}
+
+func (endpoint *SeriesMetadataBatchEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *SeriesMetadataBatchEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointSeriesMetadataBatch)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewSeriesUpdateEndpoint())
+}
+
+type SeriesUpdateEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *SeriesUpdateEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewSeriesUpdateEndpoint() *SeriesUpdateEndpoint {
+	return &SeriesUpdateEndpoint{}
+}
+
+func (endpoint *SeriesUpdateEndpoint) Execute(args *types.SeriesUpdateRequest, resp *types.SeriesUpdateResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointSeriesUpdate, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+	if !session.user.Allowed(args.Namespace, RightWrite) {
+		resp.Error = &types.RpcErrorPermissionDenied
+		return nil
+	}
+	if args.Id < 1 {
+		resp.Error = &types.RpcErrorMissingSeriesId
+		return nil
+	}
+	if strings.Contains(args.Rename, " ") {
+		resp.Error = &types.RpcErrorSeriesNameWhitespace
+		return nil
+	}
+	if err := types.ValidateLabels(args.SetLabels); err != nil {
+		resp.Error = types.WrapErrorPointer(err)
+		return nil
+	}
+	if _, labelsErr := server.checkLabelValues(args.Namespace, args.SetLabels, nil); labelsErr != nil {
+		resp.Error = labelsErr
+		return nil
+	}
+	logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+	logFields[tools.LogFieldNamespace] = args.Namespace
+	logFields[tools.LogFieldSeries] = args.Id
+
+	// snapshots wait for metadata changes
+	server.snapshotMux.RLock()
+	defer server.snapshotMux.RUnlock()
+
+	result := server.metaStore.UpdateSeries(&backend.UpdateSeries{
+		Namespace:    args.Namespace,
+		Id:           args.Id,
+		AddTags:      args.AddTags,
+		RemoveTags:   args.RemoveTags,
+		SetTtl:       args.SetTtl,
+		Ttl:          args.Ttl,
+		Rename:       args.Rename,
+		SetLabels:    args.SetLabels,
+		RemoveLabels: args.RemoveLabels,
+	})
+	if result.Error != nil {
+		server.logRequestError("backend.UpdateSeries", logFields, result.Error)
+		resp.Error = types.WrapErrorPointer(result.Error)
+		return nil
+	}
+	resp.Changed = result.Changed
+	resp.Name = result.Metadata.Name
+	resp.Tags = result.Metadata.Tags
+	resp.Labels = result.Metadata.Labels
+	resp.Ttl = result.Metadata.Ttl
+	if resp.Changed {
+		server.seriesUpdated(args.Namespace, args.Id, resp.Name, resp.Tags)
+		atomic.AddUint64(&server.numSeriesUpdated, 1)
+	}
+	return nil
+}
+
+func (endpoint *SeriesUpdateEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *SeriesUpdateEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointSeriesUpdate)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"sync"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewSubscribeEndpoint())
+}
+
+type SubscribeEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *SubscribeEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewSubscribeEndpoint() *SubscribeEndpoint {
+	return &SubscribeEndpoint{}
+}
+
+func (endpoint *SubscribeEndpoint) Execute(args *types.SubscribeRequest, resp *types.SubscribeResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointSubscribe, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+	if !session.user.Allowed(args.Namespace, RightRead) {
+		resp.Error = &types.RpcErrorPermissionDenied
+		return nil
+	}
+	if len(args.Ids) < 1 && len(args.Name) < 1 && len(args.Tag) < 1 {
+		resp.Error = types.WrapErrorStringPointer("missing series ids, name or tag")
+		return nil
+	}
+	logFields := sessionFields(SessionId(args.SessionTicket.Id), session)
+	logFields[tools.LogFieldNamespace] = args.Namespace
+
+	// series of the namespace
+	sub := &subscription{
+		user:      session.user,
+		namespace: args.Namespace,
+		name:      args.Name,
+		tag:       args.Tag,
+		ids:       make(map[uint64]bool),
+	}
+	if len(args.Ids) > 0 {
+		list := server.metaStore.ListSeries(&backend.ListSeries{Namespace: args.Namespace, Ids: args.Ids})
+		if list.Error != nil {
+			resp.Error = types.WrapErrorPointer(list.Error)
+			return nil
+		}
+		for _, id := range args.Ids {
+			sub.ids[id] = true
+		}
+	}
+	if len(args.Name) > 0 || len(args.Tag) > 0 {
+		search := &backend.SearchSeries{}
+		search.Namespace = args.Namespace
+		search.Name = args.Name
+		search.Tag = args.Tag
+		search.Comparator = backend.SearchSeriesComparatorEquals
+		searchResult := server.metaStore.SearchSeries(search)
+		if searchResult.Error != nil {
+			server.logRequestError("backend.SearchSeries", logFields, searchResult.Error)
+			resp.Error = types.WrapErrorPointer(searchResult.Error)
+			return nil
+		}
+		for _, series := range searchResult.Series {
+			sub.ids[series.Id] = true
+		}
+	}
+	server.addSubscription(sub)
+	resp.Subscription = sub.id
+	server.log.WithFields(logFields).Debugf("subscription %d to %d series", sub.id, len(sub.ids))
+	return nil
+}
+
+func (endpoint *SubscribeEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *SubscribeEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointSubscribe)
+}
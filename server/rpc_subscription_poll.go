@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"sync"
+	"time"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewSubscriptionPollEndpoint())
+}
+
+type SubscriptionPollEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *SubscriptionPollEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewSubscriptionPollEndpoint() *SubscriptionPollEndpoint {
+	return &SubscriptionPollEndpoint{}
+}
+
+func (endpoint *SubscriptionPollEndpoint) Execute(args *types.SubscriptionPollRequest, resp *types.SubscriptionPollResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointSubscriptionPoll, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+
+	// only the user that subscribed can poll
+	sub := server.getSubscription(args.Subscription, session.user)
+	if sub == nil {
+		resp.Error = &types.RpcErrorSubscriptionNotFound
+		return nil
+	}
+	points, dropped, closed := sub.poll(time.Duration(args.MaxWait) * time.Millisecond)
+	if closed {
+		if server.getSubscription(sub.id, session.user) == nil {
+			// unsubscribed during the poll
+			resp.Error = &types.RpcErrorSubscriptionNotFound
+			return nil
+		}
+		server.removeSubscription(sub.id)
+		resp.Error = &types.RpcErrorSubscriptionSlowConsumer
+		return nil
+	}
+	resp.Points = points
+	resp.Dropped = dropped
+	return nil
+}
+
+func (endpoint *SubscriptionPollEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *SubscriptionPollEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointSubscriptionPoll)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"sync"
+)
+
+func init() {
+	// init on module load
+	registerEndpoint(NewUnsubscribeEndpoint())
+}
+
+type UnsubscribeEndpoint struct {
+	server    *Instance
+	serverMux sync.RWMutex
+}
+
+func (endpoint *UnsubscribeEndpoint) getServer() *Instance {
+	endpoint.serverMux.RLock()
+	s := endpoint.server
+	endpoint.serverMux.RUnlock()
+	return s
+}
+
+func NewUnsubscribeEndpoint() *UnsubscribeEndpoint {
+	return &UnsubscribeEndpoint{}
+}
+
+func (endpoint *UnsubscribeEndpoint) Execute(args *types.UnsubscribeRequest, resp *types.UnsubscribeResponse) error {
+	// deal with panics, else the whole RPC server could crash
+	defer func() {
+		if r := recover(); r != nil {
+			resp.Error = types.WrapErrorPointer(fmt.Errorf("%s", r))
+		}
+	}()
+
+	// auth
+	server := endpoint.getServer()
+	session, err := server.validateSession(args.SessionTicket, types.EndpointUnsubscribe, args)
+	if err != nil {
+		resp.Error = sessionError(err)
+		return nil
+	}
+	if server.getSubscription(args.Subscription, session.user) == nil {
+		resp.Error = &types.RpcErrorSubscriptionNotFound
+		return nil
+	}
+	server.removeSubscription(args.Subscription)
+	return nil
+}
+
+func (endpoint *UnsubscribeEndpoint) register(opts *EndpointOpts) error {
+	if err := opts.server.rpc.RegisterName(endpoint.name().String(), endpoint); err != nil {
+		return err
+	}
+	endpoint.serverMux.Lock()
+	endpoint.server = opts.server
+	endpoint.serverMux.Unlock()
+	return nil
+}
+
+func (endpoint *UnsubscribeEndpoint) name() EndpointName {
+	return EndpointName(types.EndpointUnsubscribe)
+}
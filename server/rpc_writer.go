@@ -2,8 +2,10 @@ package server
 
 import (
 	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc"
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/server/backend"
+	"go.opentelemetry.io/otel/attribute"
 	"sync"
 	"sync/atomic"
 )
@@ -30,6 +32,14 @@ func NewWriterEndpoint() *WriterEndpoint {
 }
 
 func (endpoint *WriterEndpoint) Execute(args *types.WriteRequest, resp *types.WriteResponse) error {
+	server := endpoint.getServer()
+
+	// tracing, ended after recovering from panics
+	ctx, span := server.startEndpointSpan(types.EndpointWriter, &args.TraceContext)
+	defer func() {
+		endSpan(span, resp.Error)
+	}()
+
 	// deal with panics, else the whole RPC server could crash
 	defer func() {
 		if r := recover(); r != nil {
@@ -37,27 +47,26 @@ func (endpoint *WriterEndpoint) Execute(args *types.WriteRequest, resp *types.Wr
 		}
 	}()
 
-	server := endpoint.getServer()
-
 	// auth
-	if err := server.validateSession(args.SessionTicket); err != nil {
-		resp.Error = &types.RpcErrorAuthFailed
+	session, err := server.validateSession(args.SessionTicket, types.EndpointWriter, args)
+	if err != nil {
+		resp.Error = sessionError(err)
 		return nil
 	}
 
-	// request ID to track this specific request
-	requestId := backend.NewRequestId()
-
-	// track backend instances for final flushes
-	backendInstances := make(map[backend.IAbstractBackend]bool)
-
-	var numTimesTotal int
+	// validate all series before spending the points budget, else requests that are refused anyway drain it
+	pointsPerNamespace := make(map[int]int)
 	for _, batchItem := range args.Series {
 		numTimes := len(batchItem.Times)
-		numTimesTotal += numTimes
 		numValues := len(batchItem.Values)
-
-		// basic validation
+		if len(batchItem.TypedValues) > 0 {
+			if numValues > 0 {
+				// a series has one value type
+				resp.Error = types.WrapErrorStringPointer(fmt.Sprintf("%s: values and typed values for series %d", types.RpcErrorValueTypeMismatch, batchItem.Id))
+				return nil
+			}
+			numValues = len(batchItem.TypedValues)
+		}
 		if numTimes < 1 {
 			resp.Error = &types.RpcErrorNoValues
 			return nil
@@ -70,6 +79,34 @@ func (endpoint *WriterEndpoint) Execute(args *types.WriteRequest, resp *types.Wr
 			resp.Error = &types.RpcErrorMissingSeriesId
 			return nil
 		}
+		if !session.user.Allowed(batchItem.Namespace, RightWrite) {
+			resp.Error = &types.RpcErrorPermissionDenied
+			return nil
+		}
+		pointsPerNamespace[batchItem.Namespace] += numTimes
+	}
+
+	// points per second per namespace
+	if !server.allowPoints(pointsPerNamespace) {
+		atomic.AddUint64(&server.numRateLimited, 1)
+		resp.Error = &types.RpcErrorRateLimited
+		return nil
+	}
+
+	// snapshots wait for writes in progress
+	server.snapshotMux.RLock()
+	defer server.snapshotMux.RUnlock()
+
+	// request ID to track this specific request
+	requestId := backend.NewRequestId()
+
+	// track backend instances for final flushes
+	backendInstances := make(map[backend.IAbstractBackend]bool)
+
+	var numTimesTotal int
+	for _, batchItem := range args.Series {
+		numTimes := len(batchItem.Times)
+		numTimesTotal += numTimes
 
 		// backend
 		c := backend.ContextBackend{}
@@ -85,8 +122,15 @@ func (endpoint *WriterEndpoint) Execute(args *types.WriteRequest, resp *types.Wr
 
 		// write
 		writeContext := backend.ContextWrite(c)
-		err = backendInstance.Write(writeContext, batchItem.Times, batchItem.Values)
+		_, writeSpan := server.startBackendSpan(ctx, "backend.Write", append(seriesAttributes(c.Context), attribute.Int("tsxdb.points", numTimes))...)
+		if len(batchItem.TypedValues) > 0 {
+			err = backendInstance.WriteValues(writeContext, batchItem.Times, batchItem.TypedValues)
+		} else {
+			err = backendInstance.Write(writeContext, batchItem.Times, batchItem.Values)
+		}
+		rpc.EndSpan(writeSpan, err)
 		if err != nil {
+			server.logRequestError("backend.Write", requestFields(args.SessionTicket, session, c.Context), err)
 			e := types.RpcError(err.Error())
 			resp.Error = &e
 			return nil
@@ -95,7 +139,11 @@ func (endpoint *WriterEndpoint) Execute(args *types.WriteRequest, resp *types.Wr
 
 	// flush backends
 	for backendInstance := range backendInstances {
-		if err := backendInstance.FlushPendingWrites(requestId); err != nil {
+		_, flushSpan := server.startBackendSpan(ctx, "backend.FlushPendingWrites", attribute.String("tsxdb.backend", backendInstance.Type().String()))
+		err := backendInstance.FlushPendingWrites(requestId)
+		rpc.EndSpan(flushSpan, err)
+		if err != nil {
+			server.logRequestError("backend.FlushPendingWrites", requestFields(args.SessionTicket, session, backend.Context{RequestId: requestId}), err)
 			e := types.RpcError(err.Error())
 			resp.Error = &e
 			return nil
@@ -104,6 +152,11 @@ func (endpoint *WriterEndpoint) Execute(args *types.WriteRequest, resp *types.Wr
 
 	resp.Num = numTimesTotal
 
+	// live subscriptions
+	for _, batchItem := range args.Series {
+		server.publish(batchItem.Namespace, batchItem.Id, batchItem.Times, batchItem.Values, batchItem.TypedValues)
+	}
+
 	// basic stats
 	atomic.AddUint64(&server.numValuesWritten, uint64(resp.Num))
 
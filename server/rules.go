@@ -0,0 +1,95 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// aggregation of the points of one series in a window, values are ordered by time
+type aggregation func(values []float64, window time.Duration) float64
+
+var aggregations = map[string]aggregation{
+	"avg": func(values []float64, window time.Duration) float64 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	},
+	"min": func(values []float64, window time.Duration) float64 {
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return min
+	},
+	"max": func(values []float64, window time.Duration) float64 {
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return max
+	},
+	"sum": func(values []float64, window time.Duration) float64 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	},
+	"count": func(values []float64, window time.Duration) float64 {
+		return float64(len(values))
+	},
+	"last": func(values []float64, window time.Duration) float64 {
+		return values[len(values)-1]
+	},
+	"increase": increase,
+	"rate": func(values []float64, window time.Duration) float64 {
+		return increase(values, window) / window.Seconds()
+	},
+}
+
+// increase of a counter, a lower value is a counter reset
+func increase(values []float64, window time.Duration) float64 {
+	var total float64
+	for i := 1; i < len(values); i++ {
+		if delta := values[i] - values[i-1]; delta >= 0 {
+			total += delta
+		} else {
+			total += values[i]
+		}
+	}
+	return total
+}
+
+// values ordered by time
+func sortedValues(points map[uint64]float64) []float64 {
+	times := make([]uint64, 0, len(points))
+	for ts := range points {
+		times = append(times, ts)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+	values := make([]float64, 0, len(times))
+	for _, ts := range times {
+		values = append(values, points[ts])
+	}
+	return values
+}
+
+// run fn on every tick until the ticker is stopped, errors are logged sampled
+func (instance *Instance) every(interval time.Duration, name string, fn func(now time.Time) error) *time.Ticker {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for now := range ticker.C {
+			if err := fn(now); err != nil {
+				if logger := instance.errorLogSampler.Sample(instance.log, name); logger != nil {
+					logger.Warnf("%s: %s", name, err)
+				}
+			}
+		}
+	}()
+	return ticker
+}
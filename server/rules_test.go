@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregations(t *testing.T) {
+	values := sortedValues(map[uint64]float64{3000: 30, 1000: 10, 2000: 5, 4000: 20})
+	if len(values) != 4 || values[0] != 10 || values[3] != 20 {
+		t.Fatal(values)
+	}
+	for name, expected := range map[string]float64{
+		"avg":   16.25,
+		"min":   5,
+		"max":   30,
+		"sum":   65,
+		"count": 4,
+		"last":  20,
+		// resets after 10 and 30 count the new value as increase
+		"increase": 5 + 25 + 20,
+		"rate":     50.0 / 10,
+	} {
+		if v := aggregations[name](values, 10*time.Second); v != expected {
+			t.Errorf("%s expected %v got %v", name, expected, v)
+		}
+	}
+}
+
+func TestNewRecordingRule(t *testing.T) {
+	rule, err := newRecordingRule(RecordingRuleOpts{Name: "apiRequests", Tag: "service:api", Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.opts.Aggregation != "avg" || rule.opts.Combine != "sum" || rule.opts.Interval != defaultRecordingInterval {
+		t.Error(rule.opts)
+	}
+	for name, opts := range map[string]RecordingRuleOpts{
+		"whitespace":  {Name: "api requests", Tag: "service:api", Window: time.Minute},
+		"source":      {Name: "apiRequests", Window: time.Minute},
+		"self":        {Name: "apiRequests", Series: "apiRequests", Window: time.Minute},
+		"self tag":    {Name: "apiRequests", Tag: "service:api", Tags: []string{"service:api"}, Window: time.Minute},
+		"aggregation": {Name: "apiRequests", Tag: "service:api", Window: time.Minute, Aggregation: "median"},
+		"combine":     {Name: "apiRequests", Tag: "service:api", Window: time.Minute, Combine: "rate"},
+		"window":      {Name: "apiRequests", Tag: "service:api"},
+	} {
+		if _, err := newRecordingRule(opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -39,7 +39,7 @@ func main() {
 	if err := instance.Start(); err != nil {
 		log.Fatalf("unable to start server %s", err)
 	}
-	log.Printf("server started %s:%d", opts.ListenHost, opts.ListenPort)
+	instance.Logger().Infof("server started %s:%d", opts.ListenHost, opts.ListenPort)
 
 	// listen for shutdown
 	signal.Notify(shutdown, os.Interrupt)
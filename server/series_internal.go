@@ -0,0 +1,114 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/pkg/errors"
+	"strings"
+	"sync/atomic"
+)
+
+// series of the namespace with this name, or all series with this tag
+func (instance *Instance) findSeries(namespace int, name string, tag string) ([]backend.SeriesMetadata, error) {
+	search := &backend.SearchSeries{}
+	search.Namespace = namespace
+	search.Name = name
+	search.Tag = tag
+	search.Comparator = backend.SearchSeriesComparatorEquals
+	found := instance.metaStore.SearchSeries(search)
+	if found.Error != nil {
+		return nil, found.Error
+	}
+	if len(found.Series) < 1 {
+		return nil, nil
+	}
+	ids := make([]uint64, 0, len(found.Series))
+	for _, series := range found.Series {
+		ids = append(ids, series.Id)
+	}
+	list := instance.metaStore.ListSeries(&backend.ListSeries{Namespace: namespace, Ids: ids})
+	if list.Error != nil {
+		return nil, list.Error
+	}
+	return list.Series, nil
+}
+
+// points of a series within the time range (inclusive), empty without data
+// int and bool series are converted to float, other value types can not be read
+func (instance *Instance) readSeries(namespace int, id uint64, from uint64, to uint64) (map[uint64]float64, error) {
+	c := backend.ContextBackend{}
+	c.Namespace = namespace
+	c.Series = id
+	backendInstance, err := instance.SelectBackend(c)
+	if err != nil {
+		return nil, err
+	}
+	result := backendInstance.Read(backend.ContextRead{Context: c.Context, From: from, To: to})
+	if result.Error != nil {
+		if strings.Contains(result.Error.Error(), types.RpcErrorNoDataFound.String()) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	if !result.ValueType.Typed() {
+		return result.Results, nil
+	}
+	points := make(map[uint64]float64, len(result.TypedResults))
+	for ts, value := range result.TypedResults {
+		v, err := value.Float64(result.ValueType)
+		if err != nil {
+			return nil, err
+		}
+		points[ts] = v
+	}
+	return points, nil
+}
+
+// write points generated by the server itself, the series is created if it does not exist yet
+func (instance *Instance) writeSeries(namespace int, name string, tags []string, times []uint64, values []float64) error {
+	// snapshots wait for writes in progress
+	instance.snapshotMux.RLock()
+	defer instance.snapshotMux.RUnlock()
+
+	// metadata
+	identifier := types.SeriesCreateIdentifier(tools.RandomInsecureIdentifier())
+	create := instance.metaStore.CreateOrUpdateSeries(&backend.CreateSeries{
+		Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+			identifier: {
+				SeriesMetadata:         types.SeriesMetadata{Namespace: namespace, Name: name, Tags: tags},
+				SeriesCreateIdentifier: identifier,
+			},
+		},
+	})
+	if create.Error != nil {
+		return create.Error
+	}
+	created := create.Results[identifier]
+	if created.Error != nil {
+		return created.Error.Error()
+	}
+	if created.New {
+		instance.seriesCreated(namespace, created.Id, name, tags)
+		atomic.AddUint64(&instance.numSeriesCreated, 1)
+	}
+
+	// data
+	c := backend.ContextBackend{}
+	c.Namespace = namespace
+	c.Series = created.Id
+	c.RequestId = backend.NewRequestId()
+	backendInstance, err := instance.SelectBackend(c)
+	if err != nil {
+		return err
+	}
+	if err := backendInstance.Write(backend.ContextWrite(c), times, values); err != nil {
+		return errors.Wrapf(err, "series %s", name)
+	}
+	if err := backendInstance.FlushPendingWrites(c.RequestId); err != nil {
+		return errors.Wrapf(err, "series %s", name)
+	}
+	instance.publish(namespace, created.Id, times, values, nil)
+	atomic.AddUint64(&instance.numValuesWritten, uint64(len(times)))
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/RobinUS2/tsxdb/server/backend"
 	"github.com/RobinUS2/tsxdb/server/rollup"
 	"github.com/RobinUS2/tsxdb/telnet"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/sirupsen/logrus"
 	"net"
 	"net/rpc"
 	"sync"
@@ -14,6 +16,7 @@ type Instance struct {
 	opts            *Opts
 	rpc             *rpc.Server
 	backendSelector *backend.Selector
+	backends        []backend.IAbstractBackend // as constructed, without instrumentation
 	rollupReader    *rollup.Reader
 	shuttingDown    int32 // set to true during shutdown
 
@@ -21,16 +24,34 @@ type Instance struct {
 
 	*Sessions
 
+	*RateLimits
+
+	*Subscriptions
+
+	users map[string]*User // user name => user, populated during init
+
 	rpcListener    net.Listener
 	rpcListenerMux sync.RWMutex
 
 	metaStore backend.IMetadata
 
+	snapshotMux sync.RWMutex // writes and series creation hold a read lock, snapshots and restores a write lock
+
 	telnetServer *telnet.Instance
 
+	metrics *Metrics
+
+	alerting  *Alerting
+	recording *Recording
+
+	log             logrus.FieldLogger
+	errorLogSampler *tools.LogSampler
+
 	// stats
 	Stats
 	statsTicker *time.Ticker
+
+	expiryTicker *time.Ticker
 }
 
 func (instance *Instance) MetaStore() backend.IMetadata {
@@ -56,10 +77,15 @@ func (instance *Instance) Opts() *Opts {
 
 func New(opts *Opts) *Instance {
 	return &Instance{
-		opts:         opts,
-		rpc:          rpc.NewServer(),
-		rollupReader: rollup.NewReader(),
-		Sessions:     NewSessions(),
-		Connections:  NewConnections(),
+		opts:          opts,
+		rpc:           rpc.NewServer(),
+		rollupReader:  rollup.NewReader(),
+		Sessions:      NewSessions(),
+		RateLimits:    NewRateLimits(),
+		Subscriptions: NewSubscriptions(),
+		Connections:   NewConnections(),
+		// replaced during init
+		log:             logrus.StandardLogger(),
+		errorLogSampler: tools.NewLogSampler(errorLogSampleInterval),
 	}
 }
@@ -3,14 +3,26 @@ package server
 import (
 	"fmt"
 	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
 	"github.com/pkg/errors"
-	"log"
 	"strings"
 	"time"
 )
 
 // initialise all server stuff without actually listening
 func (instance *Instance) Init() error {
+	// logging
+	if err := instance.initLogger(); err != nil {
+		return err
+	}
+
+	// subscriptions
+	switch instance.opts.Subscriptions.SlowConsumer {
+	case "", SlowConsumerDrop, SlowConsumerDisconnect:
+	default:
+		return fmt.Errorf("unknown slow_consumer %s, expected %s or %s", instance.opts.Subscriptions.SlowConsumer, SlowConsumerDrop, SlowConsumerDisconnect)
+	}
+
 	// register all endpoints
 	endpointOpts := &EndpointOpts{server: instance}
 	for _, endpoint := range endpoints {
@@ -22,7 +34,7 @@ func (instance *Instance) Init() error {
 	// default backend?
 	if len(instance.opts.Backends) < 1 {
 		// default backend memory
-		log.Printf("WARN no backends defined, creating default non-persistent embedded memory backend")
+		instance.log.Warn("no backends defined, creating default non-persistent embedded memory backend")
 		instance.opts.Backends = []BackendOpts{
 			{
 				Identifier: backend.DefaultIdentifier,
@@ -44,13 +56,24 @@ func (instance *Instance) Init() error {
 	if len(backends) > 1 {
 		return errors.New("no more than 1 backend supported for now")
 	}
+	instance.backends = backends
 
 	// backend strategy
 	if len(strings.TrimSpace(instance.opts.BackendStrategy.Type)) < 1 {
 		instance.opts.BackendStrategy.Type = backend.SimpleStrategyType.String()
 	}
 	myStrategy := backend.StrategyInstanceFactory(instance.opts.BackendStrategy.Type, instance.opts.BackendStrategy.Options)
-	myStrategy.SetBackends(backends)
+	instance.metrics = newMetrics(instance)
+	dataBackends := make([]backend.IAbstractBackend, 0)
+	for i, backendInstance := range backends {
+		identifier := instance.opts.Backends[i].Identifier
+		backendInstance.SetLogger(instance.log.WithField(tools.LogFieldBackend, identifier))
+		if redisBackend, ok := backendInstance.(*backend.RedisBackend); ok {
+			redisBackend.SetPipelineObserver(instance.metrics.observeRedisPipeline(identifier))
+		}
+		dataBackends = append(dataBackends, newInstrumentedBackend(backendInstance, identifier, instance.metrics))
+	}
+	myStrategy.SetBackends(dataBackends)
 
 	// backend selector
 	instance.backendSelector = backend.NewSelector()
@@ -59,8 +82,8 @@ func (instance *Instance) Init() error {
 	}
 
 	// must have auth
-	if len(strings.TrimSpace(instance.opts.AuthToken)) < 1 {
-		return errors.New("missing mandatory auth token option")
+	if err := instance.initUsers(); err != nil {
+		return err
 	}
 
 	// metadata
@@ -83,7 +106,7 @@ func (instance *Instance) Init() error {
 
 	// link backends back to the system
 	for _, backendInstance := range backends {
-		backendInstance.SetReverseApi(instance.metaStore)
+		backendInstance.SetReverseApi(&reverseApi{server: instance})
 	}
 
 	// init backends
@@ -93,11 +116,27 @@ func (instance *Instance) Init() error {
 		}
 	}
 
+	// previous snapshot
+	if err := instance.restoreSnapshotPath(); err != nil {
+		return err
+	}
+
+	// alert and recording rules, evaluated once everything is initialised
+	if err := instance.initAlerting(); err != nil {
+		return err
+	}
+	if err := instance.initRecording(); err != nil {
+		return err
+	}
+	instance.alerting.start()
+	instance.recording.start()
+	instance.startExpiry()
+
 	// stats ticker
 	instance.statsTicker = time.NewTicker(60 * time.Second)
 	go func() {
 		for range instance.statsTicker.C {
-			log.Printf("stats %+v", instance.Statistics())
+			instance.log.Infof("stats %+v", instance.Statistics())
 		}
 	}()
 
@@ -117,5 +156,13 @@ func (instance *Instance) Init() error {
 		}
 	}()
 
+	// subscription ticker
+	instance.subscriptionTicker = time.NewTicker(time.Second)
+	go func() {
+		for range instance.subscriptionTicker.C {
+			instance.removeIdleSubscriptions()
+		}
+	}()
+
 	return nil
 }
@@ -1,19 +1,28 @@
 package server
 
 import (
-	"log"
 	"sync/atomic"
 	"time"
 )
 
 // stop listening, this should only be called once per instance
 func (instance *Instance) Shutdown() error {
-	log.Println("shutting down")
+	instance.log.Info("shutting down")
 	atomic.StoreInt32(&instance.shuttingDown, 1)
 
 	// tickers
 	instance.statsTicker.Stop()
 	instance.sessionTicker.Stop()
+	instance.subscriptionTicker.Stop()
+	if instance.expiryTicker != nil {
+		instance.expiryTicker.Stop()
+	}
+	if instance.alerting != nil {
+		instance.alerting.stop()
+	}
+	if instance.recording != nil {
+		instance.recording.stop()
+	}
 
 	// poll RPC listener shutdown
 	if instance.RpcListener() != nil {
@@ -35,6 +44,16 @@ func (instance *Instance) Shutdown() error {
 		}
 	}
 
+	// snapshot once no more writes come in
+	if err := instance.writeSnapshotPath(); err != nil {
+		return err
+	}
+
+	// metrics
+	if err := instance.stopMetrics(); err != nil {
+		return err
+	}
+
 	// shutdown telnet
 	if instance.telnetServer != nil {
 		if err := instance.telnetServer.Shutdown(); err != nil {
@@ -42,6 +61,6 @@ func (instance *Instance) Shutdown() error {
 		}
 	}
 
-	log.Println("shutdown complete")
+	instance.log.Info("shutdown complete")
 	return nil
 }
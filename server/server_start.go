@@ -3,7 +3,7 @@ package server
 import (
 	"fmt"
 	"github.com/RobinUS2/tsxdb/telnet"
-	"log"
+	"github.com/RobinUS2/tsxdb/tools"
 )
 
 // start server listening, this should only be called once per instance
@@ -20,19 +20,30 @@ func (instance *Instance) Start() (err error) {
 		return err
 	}
 
+	// metrics
+	if instance.Opts().MetricsPort > 0 {
+		if err := instance.startMetrics(); err != nil {
+			return err
+		}
+	}
+
 	// telnet server
 	if instance.Opts().TelnetPort > 0 {
 		telOpts := telnet.NewOpts()
 		telOpts.Port = instance.Opts().TelnetPort
 		telOpts.Host = instance.Opts().TelnetHost
 		telOpts.AuthToken = instance.Opts().AuthToken
+		telOpts.UserAuth = len(instance.Opts().Users) > 0
 		telOpts.ServerHost = instance.Opts().ListenHost
 		telOpts.ServerPort = instance.Opts().ListenPort
+		telOpts.Tls = instance.Opts().TelnetTls
+		telOpts.ServerTls = instance.Opts().TelnetUpstreamTls
+		telOpts.Logger = instance.log.WithField(tools.LogFieldComponent, "telnet")
 		instance.telnetServer = telnet.New(telOpts)
 		go func() {
 			err := instance.telnetServer.Listen()
 			if err != nil {
-				log.Printf("telnet failed to listen %s", err)
+				instance.log.WithError(err).Error("telnet failed to listen")
 			}
 		}()
 	}
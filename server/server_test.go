@@ -6,7 +6,6 @@ import (
 	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/server"
 	"github.com/RobinUS2/tsxdb/tools"
-	"k8s.io/apimachinery/pkg/util/rand"
 	"net/rpc"
 	"testing"
 )
@@ -36,7 +35,7 @@ func TestNew(t *testing.T) {
 	// auth
 	var sessionId int
 	var sessionSecret []byte
-	var authTwoRequest types.AuthRequest
+	var nonce uint64
 	{
 		// 1
 		authRequest, _ := tools.BasicAuthRequest(opts.OptsConnection)
@@ -46,20 +45,24 @@ func TestNew(t *testing.T) {
 			t.Error("error:", err)
 		}
 		sessionId = authReply.SessionId
-		sessionSecret, _ = base64.StdEncoding.DecodeString(authReply.SessionSecret)
+		clientNonce, _ := base64.StdEncoding.DecodeString(authRequest.Nonce)
+		serverNonce, _ := base64.StdEncoding.DecodeString(authReply.ServerNonce)
+		sessionSecret = tools.SessionSecret([]byte(token), clientNonce, serverNonce)
 		//log.Printf("%+v", authReply)
 	}
 	{
 		// 2
-		authTwoRequest, _ = tools.BasicAuthRequest(opts.OptsConnection)
-		authTwoRequest.SessionTicket.Id = sessionId
-		authTwoRequest.SessionTicket.Nonce = rand.Int()
-		authTwoRequest.SessionTicket.Signature = tools.HmacInt(sessionSecret, authTwoRequest.SessionTicket.Nonce)
+		authTwoRequest, _ := tools.BasicAuthRequest(opts.OptsConnection)
+		nonce++
+		authTwoRequest.SessionTicket = tools.SignSessionTicket(sessionSecret, sessionId, nonce, types.EndpointAuth, authTwoRequest)
 		var authReply *types.AuthResponse
 		err = c.Call(types.EndpointAuth.String()+"."+types.MethodName, authTwoRequest, &authReply)
 		if err != nil {
 			t.Error("error:", err)
 		}
+		if authReply.Error != nil {
+			t.Error(authReply.Error.String())
+		}
 		//log.Printf("%+v", authReply)
 	}
 
@@ -73,8 +76,9 @@ func TestNew(t *testing.T) {
 				},
 				SeriesCreateIdentifier: 1234,
 			},
-			SessionTicket: authTwoRequest.SessionTicket,
 		}
+		nonce++
+		params.SessionTicket = tools.SignSessionTicket(sessionSecret, sessionId, nonce, types.EndpointSeriesMetadata, params)
 		var reply *types.SeriesMetadataResponse
 		err = c.Call(types.EndpointSeriesMetadata.String()+"."+types.MethodName, params, &reply)
 		if err != nil {
@@ -92,8 +96,9 @@ func TestNew(t *testing.T) {
 				Id: seriesId,
 			},
 		}},
-		SessionTicket: authTwoRequest.SessionTicket,
 	}
+	nonce++
+	params.SessionTicket = tools.SignSessionTicket(sessionSecret, sessionId, nonce, types.EndpointWriter, params)
 	var reply *types.WriteResponse
 	err = c.Call(types.EndpointWriter.String()+"."+types.MethodName, params, &reply)
 	if err != nil {
@@ -105,6 +110,29 @@ func TestNew(t *testing.T) {
 	if reply.Error != nil {
 		t.Error(reply.Error.String())
 	}
+
+	// replay of the same write is rejected
+	reply = nil
+	err = c.Call(types.EndpointWriter.String()+"."+types.MethodName, params, &reply)
+	if err != nil {
+		t.Error("error:", err)
+	}
+	if reply.Error == nil || reply.Num != 0 {
+		t.Error("expected replay to be rejected", reply)
+	}
+
+	// tampered payload with a fresh nonce is rejected
+	params.Series[0].Values[0] = 7.0
+	params.SessionTicket.Nonce++
+	reply = nil
+	err = c.Call(types.EndpointWriter.String()+"."+types.MethodName, params, &reply)
+	if err != nil {
+		t.Error("error:", err)
+	}
+	if reply.Error == nil || reply.Num != 0 {
+		t.Error("expected tampered request to be rejected", reply)
+	}
+
 	if err := c.Close(); err != nil {
 		t.Error(err)
 	}
@@ -10,41 +10,114 @@ type SessionId int
 type SessionToken []byte
 type FutureUnixTime int64
 
+type Session struct {
+	token    SessionToken
+	identity string       // verified tls client certificate identity the session was created with, empty without mutual tls
+	user     *User        // user that authenticated the session
+	expires  int64        // unix timestamp, extended on every validated call
+	requests *tokenBucket // rate limit of calls, nil if unlimited
+
+	nonceMux     sync.Mutex
+	highestNonce uint64
+	seenNonces   uint64 // bitmap of accepted nonces, bit 0 is the highest nonce
+}
+
+// nonces may arrive out of order (concurrent calls on one connection), but only within this window
+const nonceWindow = 64
+
+// accept each nonce only once, call after verifying the signature so forged tickets can not burn nonces
+func (session *Session) acceptNonce(nonce uint64) bool {
+	if nonce == 0 {
+		return false
+	}
+	session.nonceMux.Lock()
+	defer session.nonceMux.Unlock()
+	if nonce > session.highestNonce {
+		shift := nonce - session.highestNonce
+		if shift >= nonceWindow {
+			session.seenNonces = 0
+		} else {
+			session.seenNonces <<= shift
+		}
+		session.seenNonces |= 1
+		session.highestNonce = nonce
+		return true
+	}
+	offset := session.highestNonce - nonce
+	if offset >= nonceWindow {
+		// too old
+		return false
+	}
+	mask := uint64(1) << offset
+	if session.seenNonces&mask != 0 {
+		// replay
+		return false
+	}
+	session.seenNonces |= mask
+	return true
+}
+
+// keep the session alive for another timeout period
+func (session *Session) touch() {
+	atomic.StoreInt64(&session.expires, time.Now().Unix()+int64(ConnectionTimeout.Seconds())+1)
+}
+
 type Sessions struct {
-	sessionTicker    *time.Ticker
-	sessionTokens    map[SessionId]SessionToken // session id => secret
-	sessionTokensMux sync.RWMutex
-	expireSlots      map[FutureUnixTime][]SessionId // unix timestamp in future -> secrets
-	expireSlotsMux   sync.RWMutex
-	expiredSession   uint64
+	sessionTicker     *time.Ticker
+	sessions          map[SessionId]*Session // session id => session (secret etc.)
+	sessionsMux       sync.RWMutex
+	expireSlots       map[FutureUnixTime][]SessionId // unix timestamp in future -> secrets
+	expireSlotsMux    sync.RWMutex
+	expiredSession    uint64
+	loggedOutSessions uint64
 }
 
 func (s *Sessions) ExpiredSession() uint64 {
 	return atomic.LoadUint64(&s.expiredSession)
 }
 
-func (instance *Instance) getTokenFromSessionId(sessionId SessionId) SessionToken {
-	instance.sessionTokensMux.RLock()
-	token := instance.sessionTokens[sessionId]
-	instance.sessionTokensMux.RUnlock()
-	return token
+func (s *Sessions) LoggedOutSessions() uint64 {
+	return atomic.LoadUint64(&s.loggedOutSessions)
+}
+
+func (s *Sessions) ActiveSessions() int {
+	s.sessionsMux.RLock()
+	n := len(s.sessions)
+	s.sessionsMux.RUnlock()
+	return n
 }
 
-func (instance *Instance) registerSessionToken(sessionId SessionId, token SessionToken) {
-	instance.sessionTokensMux.RLock()
-	_, existing := instance.sessionTokens[sessionId]
-	instance.sessionTokensMux.RUnlock()
+func (instance *Instance) getSession(sessionId SessionId) *Session {
+	instance.sessionsMux.RLock()
+	session := instance.sessions[sessionId]
+	instance.sessionsMux.RUnlock()
+	return session
+}
+
+func (instance *Instance) registerSessionToken(sessionId SessionId, token SessionToken, identity string, user *User) {
+	instance.sessionsMux.RLock()
+	_, existing := instance.sessions[sessionId]
+	instance.sessionsMux.RUnlock()
 	if existing {
 		return
 	}
 
-	instance.sessionTokensMux.Lock()
-	instance.sessionTokens[sessionId] = token
-	instance.sessionTokensMux.Unlock()
+	session := &Session{
+		token:    token,
+		identity: identity,
+		user:     user,
+		requests: instance.newRequestBucket(),
+	}
+	session.touch()
+	instance.sessionsMux.Lock()
+	instance.sessions[sessionId] = session
+	instance.sessionsMux.Unlock()
 
 	// register for future time in expire via ticker
-	nowUnix := time.Now().Unix()
-	expireTs := FutureUnixTime(nowUnix + int64(ConnectionTimeout.Seconds()) + 1)
+	instance.scheduleSessionExpire(sessionId, FutureUnixTime(atomic.LoadInt64(&session.expires)))
+}
+
+func (instance *Instance) scheduleSessionExpire(sessionId SessionId, expireTs FutureUnixTime) {
 	instance.Sessions.expireSlotsMux.Lock()
 	if instance.Sessions.expireSlots[expireTs] == nil {
 		instance.Sessions.expireSlots[expireTs] = make([]SessionId, 0)
@@ -53,6 +126,28 @@ func (instance *Instance) registerSessionToken(sessionId SessionId, token Sessio
 	instance.Sessions.expireSlotsMux.Unlock()
 }
 
+// remove the session before it expires (logout)
+func (instance *Instance) removeSession(sessionId SessionId) bool {
+	instance.sessionsMux.Lock()
+	_, found := instance.sessions[sessionId]
+	delete(instance.sessions, sessionId)
+	instance.sessionsMux.Unlock()
+	// the expire slot is cleaned up by the ticker
+	return found
+}
+
+// expire sessions before their timeout, e.g. once their connection is closed (sessions that logged out are skipped)
+func (instance *Instance) expireSessions(sessionIds []SessionId) int {
+	numDeleted := 0
+	for _, sessionId := range sessionIds {
+		if instance.removeSession(sessionId) {
+			numDeleted++
+		}
+	}
+	atomic.AddUint64(&instance.Sessions.expiredSession, uint64(numDeleted))
+	return numDeleted
+}
+
 func (instance *Instance) sessionExpire() int {
 	nowUnix := time.Now().Unix()
 
@@ -73,26 +168,36 @@ func (instance *Instance) sessionExpire() int {
 		return 0
 	}
 
-	// remove all expired tokens
-	numDeleted := 0
-	instance.Sessions.sessionTokensMux.Lock()
+	instance.Sessions.expireSlotsMux.Lock()
+	sessionIds := make([]SessionId, 0)
 	for _, expiredSlot := range expiredSlots {
-		sessionIds, found := instance.Sessions.expireSlots[expiredSlot]
+		sessionIds = append(sessionIds, instance.Sessions.expireSlots[expiredSlot]...)
+		delete(instance.Sessions.expireSlots, expiredSlot)
+	}
+	instance.Sessions.expireSlotsMux.Unlock()
+
+	// remove all expired tokens, sessions used in the meantime move to a later slot
+	numDeleted := 0
+	rescheduled := make(map[SessionId]FutureUnixTime)
+	instance.Sessions.sessionsMux.Lock()
+	for _, sessionId := range sessionIds {
+		session, found := instance.Sessions.sessions[sessionId]
 		if !found {
+			// logged out
 			continue
 		}
-		for _, sessionId := range sessionIds {
-			delete(instance.Sessions.sessionTokens, sessionId)
-			numDeleted++
+		expires := FutureUnixTime(atomic.LoadInt64(&session.expires))
+		if expires >= FutureUnixTime(nowUnix) {
+			rescheduled[sessionId] = expires
+			continue
 		}
+		delete(instance.Sessions.sessions, sessionId)
+		numDeleted++
 	}
-	instance.Sessions.sessionTokensMux.Unlock()
-
-	instance.Sessions.expireSlotsMux.Lock()
-	for _, expiredSlot := range expiredSlots {
-		delete(instance.Sessions.expireSlots, expiredSlot)
+	instance.Sessions.sessionsMux.Unlock()
+	for sessionId, expires := range rescheduled {
+		instance.scheduleSessionExpire(sessionId, expires)
 	}
-	instance.Sessions.expireSlotsMux.Unlock()
 
 	atomic.AddUint64(&instance.Sessions.expiredSession, uint64(numDeleted))
 
@@ -102,7 +207,7 @@ func (instance *Instance) sessionExpire() int {
 func NewSessions() *Sessions {
 	return &Sessions{
 		sessionTicker: nil,
-		sessionTokens: make(map[SessionId]SessionToken),
+		sessions:      make(map[SessionId]*Session),
 		expireSlots:   make(map[FutureUnixTime][]SessionId),
 	}
 }
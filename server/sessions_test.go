@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSession_AcceptNonce(t *testing.T) {
+	session := &Session{}
+	if session.acceptNonce(0) {
+		t.Error("zero nonce")
+	}
+	for _, nonce := range []uint64{1, 2, 5, 4, 3} {
+		if !session.acceptNonce(nonce) {
+			t.Errorf("expected %d to be accepted", nonce)
+		}
+	}
+	for _, nonce := range []uint64{1, 3, 5} {
+		if session.acceptNonce(nonce) {
+			t.Errorf("expected replay of %d to be rejected", nonce)
+		}
+	}
+
+	// out of window
+	if !session.acceptNonce(5 + nonceWindow) {
+		t.Error("expected jump ahead to be accepted")
+	}
+	if session.acceptNonce(5) {
+		t.Error("expected nonce outside window to be rejected")
+	}
+	if !session.acceptNonce(6) {
+		t.Error("expected unseen nonce within window to be accepted")
+	}
+	if session.acceptNonce(6) || session.acceptNonce(5+nonceWindow) {
+		t.Error("expected replays within window to be rejected")
+	}
+}
+
+func TestSessionExpireSliding(t *testing.T) {
+	instance := New(NewOpts())
+	past := FutureUnixTime(time.Now().Unix() - 5)
+
+	// used session is moved to a later slot
+	instance.registerSessionToken(1, make(SessionToken, 32), "", nil)
+	instance.scheduleSessionExpire(1, past)
+	if n := instance.sessionExpire(); n != 0 {
+		t.Errorf("expected used session to stay, expired %d", n)
+	}
+	if instance.getSession(1) == nil {
+		t.Fatal("expected session")
+	}
+
+	// idle session is removed
+	atomic.StoreInt64(&instance.getSession(1).expires, int64(past))
+	instance.scheduleSessionExpire(1, past)
+	if n := instance.sessionExpire(); n != 1 {
+		t.Errorf("expected idle session to expire, expired %d", n)
+	}
+	if instance.getSession(1) != nil || instance.ExpiredSession() != 1 {
+		t.Error("expected session to be removed")
+	}
+}
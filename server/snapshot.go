@@ -0,0 +1,300 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// archive format: gzip compressed json lines, a header followed by one line per series (metadata and points)
+const SnapshotVersion = 1
+
+type snapshotHeader struct {
+	Version int
+	Created int64 // unix timestamp in seconds
+}
+
+type snapshotSeries struct {
+	Namespace   int
+	Id          uint64 // id at the time of the snapshot, restored series get a new id
+	Name        string
+	Tags        []string               `json:",omitempty"`
+	TtlExpire   uint64                 `json:",omitempty"` // unix timestamp in seconds
+	ValueType   types.ValueType        `json:",omitempty"`
+	Enum        []string               `json:",omitempty"`
+	Fields      []string               `json:",omitempty"`
+	Labels      map[string]string      `json:",omitempty"`
+	Created     uint64                 `json:",omitempty"` // unix timestamp in seconds
+	Creator     *backend.SeriesCreator `json:",omitempty"`
+	Timestamps  []uint64
+	Values      []float64
+	TypedValues []types.Value `json:",omitempty"` // instead of values for series that are not float
+}
+
+type SnapshotResult struct {
+	Series int
+	Points int
+}
+
+// consistent snapshot of all metadata and data, writes and series creation wait until it is done
+func (instance *Instance) Snapshot(w io.Writer) (result SnapshotResult, err error) {
+	instance.snapshotMux.Lock()
+	defer instance.snapshotMux.Unlock()
+
+	compressed := gzip.NewWriter(w)
+	encoder := json.NewEncoder(compressed)
+	if err := encoder.Encode(snapshotHeader{Version: SnapshotVersion, Created: time.Now().Unix()}); err != nil {
+		return result, err
+	}
+	namespaces := instance.metaStore.ListNamespaces()
+	if namespaces.Error != nil {
+		return result, namespaces.Error
+	}
+	for _, namespace := range namespaces.Namespaces {
+		list := instance.metaStore.ListSeries(&backend.ListSeries{Namespace: namespace})
+		if list.Error != nil {
+			return result, list.Error
+		}
+		for _, meta := range list.Series {
+			adminBackend, err := instance.selectAdminBackend(namespace, uint64(meta.Id))
+			if err != nil {
+				return result, err
+			}
+			read := adminBackend.ReadSeries(backend.Context{Namespace: namespace, Series: uint64(meta.Id)})
+			if read.Error != nil {
+				return result, errors.Wrapf(read.Error, "series %d", meta.Id)
+			}
+			series := snapshotSeries{
+				Namespace:  namespace,
+				Id:         uint64(meta.Id),
+				Name:       meta.Name,
+				Tags:       meta.Tags,
+				TtlExpire:  meta.TtlExpire,
+				ValueType:  meta.ValueType,
+				Enum:       meta.Enum,
+				Fields:     meta.Fields,
+				Labels:     meta.Labels,
+				Created:    meta.Created,
+				Creator:    meta.Creator,
+				Timestamps: make([]uint64, 0, len(read.Results)+len(read.TypedResults)),
+				Values:     make([]float64, 0, len(read.Results)),
+			}
+			for ts := range read.Results {
+				series.Timestamps = append(series.Timestamps, ts)
+			}
+			for ts := range read.TypedResults {
+				series.Timestamps = append(series.Timestamps, ts)
+			}
+			sort.Slice(series.Timestamps, func(i, j int) bool {
+				return series.Timestamps[i] < series.Timestamps[j]
+			})
+			for _, ts := range series.Timestamps {
+				if meta.ValueType.Typed() {
+					series.TypedValues = append(series.TypedValues, read.TypedResults[ts])
+				} else {
+					series.Values = append(series.Values, read.Results[ts])
+				}
+			}
+			if err := encoder.Encode(series); err != nil {
+				return result, err
+			}
+			result.Series++
+			result.Points += len(series.Timestamps)
+		}
+	}
+	return result, compressed.Close()
+}
+
+// add all series of a snapshot, existing series (same namespace and name) get the points added
+func (instance *Instance) Restore(r io.Reader) (result SnapshotResult, err error) {
+	instance.snapshotMux.Lock()
+	defer instance.snapshotMux.Unlock()
+
+	compressed, err := gzip.NewReader(r)
+	if err != nil {
+		return result, err
+	}
+	decoder := json.NewDecoder(compressed)
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil {
+		return result, errors.Wrap(err, "invalid snapshot header")
+	}
+	if header.Version != SnapshotVersion {
+		return result, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+	nowSeconds := uint64(time.Now().Unix())
+	for {
+		var series snapshotSeries
+		if err := decoder.Decode(&series); err == io.EOF {
+			break
+		} else if err != nil {
+			return result, err
+		}
+		numValues := len(series.Values)
+		if series.ValueType.Typed() {
+			numValues = len(series.TypedValues)
+		}
+		if len(series.Timestamps) != numValues {
+			return result, fmt.Errorf("series %d: %s", series.Id, types.RpcErrorNumTimeValuePairsMisMatch)
+		}
+
+		// remaining ttl, expired series are not restored
+		var ttl uint
+		if series.TtlExpire > 0 {
+			if series.TtlExpire <= nowSeconds {
+				continue
+			}
+			ttl = uint(series.TtlExpire - nowSeconds)
+		}
+
+		// metadata
+		identifier := types.SeriesCreateIdentifier(series.Id)
+		create := instance.metaStore.CreateOrUpdateSeries(&backend.CreateSeries{
+			Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+				identifier: {
+					SeriesMetadata: types.SeriesMetadata{
+						Namespace: series.Namespace,
+						Name:      series.Name,
+						Tags:      series.Tags,
+						Ttl:       ttl,
+						ValueType: series.ValueType,
+						Enum:      series.Enum,
+						Fields:    series.Fields,
+						Labels:    series.Labels,
+					},
+					SeriesCreateIdentifier: identifier,
+				},
+			},
+			Creator: series.Creator,
+			Created: series.Created,
+		})
+		if create.Error != nil {
+			return result, create.Error
+		}
+		created := create.Results[identifier]
+		if created.Error != nil {
+			return result, created.Error.Error()
+		}
+		result.Series++
+		if len(series.Timestamps) < 1 {
+			continue
+		}
+
+		// data
+		c := backend.ContextBackend{}
+		c.Namespace = series.Namespace
+		c.Series = created.Id
+		c.RequestId = backend.NewRequestId()
+		backendInstance, err := instance.SelectBackend(c)
+		if err != nil {
+			return result, err
+		}
+		if series.ValueType.Typed() {
+			err = backendInstance.WriteValues(backend.ContextWrite(c), series.Timestamps, series.TypedValues)
+		} else {
+			err = backendInstance.Write(backend.ContextWrite(c), series.Timestamps, series.Values)
+		}
+		if err != nil {
+			return result, errors.Wrapf(err, "series %d", series.Id)
+		}
+		if err := backendInstance.FlushPendingWrites(c.RequestId); err != nil {
+			return result, errors.Wrapf(err, "series %d", series.Id)
+		}
+		result.Points += len(series.Timestamps)
+	}
+	return result, nil
+}
+
+// snapshot to a file, replaced only once the snapshot is complete
+func (instance *Instance) SnapshotFile(path string) (result SnapshotResult, err error) {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return result, err
+	}
+	buffered := bufio.NewWriter(file)
+	result, err = instance.Snapshot(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return result, err
+	}
+	return result, os.Rename(tmpPath, path)
+}
+
+func (instance *Instance) RestoreFile(path string) (result SnapshotResult, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return instance.Restore(bufio.NewReader(file))
+}
+
+// archive file of admin snapshots and restores, only files in the configured directory can be used
+func (instance *Instance) snapshotDirPath(name string) (string, error) {
+	if len(instance.opts.SnapshotDir) < 1 {
+		return "", errors.New("snapshots are disabled, snapshot_dir is not configured")
+	}
+	if len(name) < 1 {
+		return "", errors.New("missing path")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid snapshot file name %s, expected a name in the snapshot directory", name)
+	}
+	return filepath.Join(instance.opts.SnapshotDir, name), nil
+}
+
+// restore the configured snapshot (if it exists) during init
+// backends that persist their data (e.g. redis) have it already, a restore would add all points again
+func (instance *Instance) restoreSnapshotPath() error {
+	path := instance.opts.SnapshotPath
+	if len(path) < 1 || !tools.FileExists(path) {
+		return nil
+	}
+	namespaces := instance.metaStore.ListNamespaces()
+	if namespaces.Error != nil {
+		return namespaces.Error
+	}
+	if len(namespaces.Namespaces) > 0 {
+		instance.log.Infof("snapshot %s not restored, the metadata backend contains series", path)
+		return nil
+	}
+	result, err := instance.RestoreFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore snapshot %s", path)
+	}
+	instance.log.Infof("restored snapshot %s with %d series and %d points", path, result.Series, result.Points)
+	return nil
+}
+
+// snapshot to the configured path (if any) during shutdown
+func (instance *Instance) writeSnapshotPath() error {
+	path := instance.opts.SnapshotPath
+	if len(path) < 1 {
+		return nil
+	}
+	result, err := instance.SnapshotFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write snapshot %s", path)
+	}
+	instance.log.Infof("wrote snapshot %s with %d series and %d points", path, result.Series, result.Points)
+	return nil
+}
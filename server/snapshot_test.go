@@ -0,0 +1,54 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreSnapshotPathNotEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsxdb-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	opts := NewOpts()
+	opts.AuthToken = "verySecure123@#$"
+	instance := New(opts)
+	if err := instance.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = instance.Shutdown()
+	}()
+	create := instance.metaStore.CreateOrUpdateSeries(&backend.CreateSeries{
+		Series: map[types.SeriesCreateIdentifier]types.SeriesCreateMetadata{
+			1: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: "kept"}, SeriesCreateIdentifier: 1},
+			2: {SeriesMetadata: types.SeriesMetadata{Namespace: 1, Name: "deleted"}, SeriesCreateIdentifier: 2},
+		},
+	})
+	if create.Error != nil {
+		t.Fatal(create.Error)
+	}
+	path := filepath.Join(dir, "snapshot.json.gz")
+	if _, err := instance.SnapshotFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// backends with series are not restored into, the snapshot would write all points again and bring back deleted series
+	if res := instance.metaStore.DeleteSeries(&backend.DeleteSeries{Series: []types.SeriesIdentifier{{Namespace: 1, Id: create.Results[2].Id}}}); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	instance.opts.SnapshotPath = path
+	if err := instance.restoreSnapshotPath(); err != nil {
+		t.Fatal(err)
+	}
+	if count := instance.metaStore.CountSeries(&backend.CountSeries{Namespace: 1}); count.Error != nil || count.Count != 1 {
+		t.Errorf("expected snapshot not to be restored %+v", count)
+	}
+}
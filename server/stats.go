@@ -7,8 +7,35 @@ type Stats struct {
 	numValuesWritten     uint64
 	numSeriesCreated     uint64
 	numSeriesInitialised uint64
+	numSeriesUpdated     uint64
+	numSeriesExpired     uint64
 	numAuthentications   uint64
 	numReads             uint64
+	numSessionsActive    uint64
+	numSessionsExpired   uint64
+	numSessionsLoggedOut uint64
+	numRateLimited       uint64
+	numQuotaExceeded     uint64
+}
+
+func (s Stats) NumRateLimited() uint64 {
+	return s.numRateLimited
+}
+
+func (s Stats) NumQuotaExceeded() uint64 {
+	return s.numQuotaExceeded
+}
+
+func (s Stats) NumSessionsActive() uint64 {
+	return s.numSessionsActive
+}
+
+func (s Stats) NumSessionsExpired() uint64 {
+	return s.numSessionsExpired
+}
+
+func (s Stats) NumSessionsLoggedOut() uint64 {
+	return s.numSessionsLoggedOut
 }
 
 func (s Stats) NumReads() uint64 {
@@ -23,6 +50,14 @@ func (s Stats) NumSeriesInitialised() uint64 {
 	return s.numSeriesInitialised
 }
 
+func (s Stats) NumSeriesUpdated() uint64 {
+	return s.numSeriesUpdated
+}
+
+func (s Stats) NumSeriesExpired() uint64 {
+	return s.numSeriesExpired
+}
+
 func (s Stats) NumSeriesCreated() uint64 {
 	return s.numSeriesCreated
 }
@@ -41,7 +76,14 @@ func (instance *Instance) Statistics() Stats {
 		numValuesWritten:     atomic.LoadUint64(&instance.numValuesWritten),
 		numSeriesCreated:     atomic.LoadUint64(&instance.numSeriesCreated),
 		numSeriesInitialised: atomic.LoadUint64(&instance.numSeriesInitialised),
+		numSeriesUpdated:     atomic.LoadUint64(&instance.numSeriesUpdated),
+		numSeriesExpired:     atomic.LoadUint64(&instance.numSeriesExpired),
 		numAuthentications:   atomic.LoadUint64(&instance.numAuthentications),
 		numReads:             atomic.LoadUint64(&instance.numReads),
+		numSessionsActive:    uint64(instance.ActiveSessions()),
+		numSessionsExpired:   instance.ExpiredSession(),
+		numSessionsLoggedOut: instance.LoggedOutSessions(),
+		numRateLimited:       atomic.LoadUint64(&instance.numRateLimited),
+		numQuotaExceeded:     atomic.LoadUint64(&instance.numQuotaExceeded),
 	}
 }
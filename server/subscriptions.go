@@ -0,0 +1,261 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultSubscriptionBufferSize = 10000
+const SlowConsumerDrop = "drop"             // points that do not fit the buffer are dropped and counted
+const SlowConsumerDisconnect = "disconnect" // the subscription is closed once the buffer is full
+const maxSubscriptionPollWait = 30 * time.Second
+const subscriptionIdleTimeout = time.Minute // subscriptions that are not polled are removed
+
+type subscription struct {
+	id        uint64
+	user      *User
+	namespace int
+	name      string // series created later with this name are added, empty for any
+	tag       string // series created later with this tag are added, empty for any
+
+	mux      sync.Mutex
+	ids      map[uint64]bool
+	points   []types.SubscriptionPoint // buffered until polled
+	dropped  uint64
+	closed   bool
+	lastPoll time.Time
+	notify   chan struct{} // signalled when points are buffered or the subscription is closed
+}
+
+func (sub *subscription) matches(name string, tags []string) bool {
+	if len(sub.name) < 1 && len(sub.tag) < 1 {
+		// ids only
+		return false
+	}
+	if len(sub.name) > 0 && sub.name != name {
+		return false
+	}
+	if len(sub.tag) > 0 {
+		for _, tag := range tags {
+			if tag == sub.tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// unsafe, not locked
+func (sub *subscription) signal() {
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+type Subscriptions struct {
+	subscriptionTicker *time.Ticker
+	subscriptions      map[uint64]*subscription
+	subscriptionsMux   sync.RWMutex
+	subscriptionsCount int32 // fast path for writes without subscriptions
+	subscriptionId     uint64
+}
+
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		subscriptions: make(map[uint64]*subscription),
+	}
+}
+
+func (s *Subscriptions) ActiveSubscriptions() int {
+	return int(atomic.LoadInt32(&s.subscriptionsCount))
+}
+
+func (s *Subscriptions) addSubscription(sub *subscription) {
+	sub.id = atomic.AddUint64(&s.subscriptionId, 1)
+	sub.lastPoll = time.Now()
+	sub.notify = make(chan struct{}, 1)
+	s.subscriptionsMux.Lock()
+	s.subscriptions[sub.id] = sub
+	atomic.StoreInt32(&s.subscriptionsCount, int32(len(s.subscriptions)))
+	s.subscriptionsMux.Unlock()
+}
+
+// subscription of the user, nil if not found
+func (s *Subscriptions) getSubscription(id uint64, user *User) *subscription {
+	s.subscriptionsMux.RLock()
+	sub := s.subscriptions[id]
+	s.subscriptionsMux.RUnlock()
+	if sub == nil || sub.user != user {
+		return nil
+	}
+	return sub
+}
+
+func (s *Subscriptions) removeSubscription(id uint64) {
+	s.subscriptionsMux.Lock()
+	if sub, found := s.subscriptions[id]; found {
+		sub.mux.Lock()
+		sub.closed = true
+		sub.signal()
+		sub.mux.Unlock()
+		delete(s.subscriptions, id)
+	}
+	atomic.StoreInt32(&s.subscriptionsCount, int32(len(s.subscriptions)))
+	s.subscriptionsMux.Unlock()
+}
+
+// remove subscriptions that are not polled anymore (e.g. abandoned clients), they would keep buffering points
+func (s *Subscriptions) removeIdleSubscriptions() int {
+	if atomic.LoadInt32(&s.subscriptionsCount) < 1 {
+		return 0
+	}
+	numRemoved := 0
+	s.subscriptionsMux.Lock()
+	for id, sub := range s.subscriptions {
+		sub.mux.Lock()
+		idle := time.Since(sub.lastPoll) > subscriptionIdleTimeout
+		if idle {
+			sub.closed = true
+			sub.points = nil
+			sub.signal()
+		}
+		sub.mux.Unlock()
+		if idle {
+			delete(s.subscriptions, id)
+			numRemoved++
+		}
+	}
+	atomic.StoreInt32(&s.subscriptionsCount, int32(len(s.subscriptions)))
+	s.subscriptionsMux.Unlock()
+	return numRemoved
+}
+
+// add series created after the subscription started that match its name or tag
+func (s *Subscriptions) seriesCreated(namespace int, id uint64, name string, tags []string) {
+	if atomic.LoadInt32(&s.subscriptionsCount) < 1 {
+		return
+	}
+	s.subscriptionsMux.RLock()
+	defer s.subscriptionsMux.RUnlock()
+	for _, sub := range s.subscriptions {
+		if sub.namespace != namespace || !sub.matches(name, tags) {
+			continue
+		}
+		sub.mux.Lock()
+		sub.ids[id] = true
+		sub.mux.Unlock()
+	}
+}
+
+// subscriptions by name or tag follow renames and tag changes
+func (s *Subscriptions) seriesUpdated(namespace int, id uint64, name string, tags []string) {
+	if atomic.LoadInt32(&s.subscriptionsCount) < 1 {
+		return
+	}
+	s.subscriptionsMux.RLock()
+	defer s.subscriptionsMux.RUnlock()
+	for _, sub := range s.subscriptions {
+		if sub.namespace != namespace || (len(sub.name) < 1 && len(sub.tag) < 1) {
+			continue
+		}
+		sub.mux.Lock()
+		if sub.matches(name, tags) {
+			sub.ids[id] = true
+		} else {
+			delete(sub.ids, id)
+		}
+		sub.mux.Unlock()
+	}
+}
+
+// buffer written points for the subscriptions of the series, series that are not float have typed values instead of values
+func (s *Subscriptions) publish(namespace int, id uint64, times []uint64, values []float64, typed []types.Value, valueType types.ValueType, bufferSize int, disconnect bool) {
+	if atomic.LoadInt32(&s.subscriptionsCount) < 1 {
+		return
+	}
+	s.subscriptionsMux.RLock()
+	defer s.subscriptionsMux.RUnlock()
+	for _, sub := range s.subscriptions {
+		if sub.namespace != namespace {
+			continue
+		}
+		sub.mux.Lock()
+		if !sub.ids[id] || sub.closed {
+			sub.mux.Unlock()
+			continue
+		}
+		for idx, ts := range times {
+			if len(sub.points) >= bufferSize {
+				if disconnect {
+					sub.closed = true
+					sub.points = nil
+					break
+				}
+				sub.dropped += uint64(len(times) - idx)
+				break
+			}
+			point := types.SubscriptionPoint{
+				SeriesIdentifier: types.SeriesIdentifier{Namespace: namespace, Id: id},
+				Time:             ts,
+			}
+			if len(typed) > 0 {
+				value := typed[idx]
+				point.Typed = &value
+				point.ValueType = valueType
+			} else {
+				point.Value = values[idx]
+			}
+			sub.points = append(sub.points, point)
+		}
+		sub.signal()
+		sub.mux.Unlock()
+	}
+}
+
+// wait up to maxWait for points, returns the buffered points and the number of dropped points since the previous poll
+func (sub *subscription) poll(maxWait time.Duration) (points []types.SubscriptionPoint, dropped uint64, closed bool) {
+	if maxWait > maxSubscriptionPollWait {
+		maxWait = maxSubscriptionPollWait
+	}
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	for {
+		sub.mux.Lock()
+		sub.lastPoll = time.Now()
+		if sub.closed || len(sub.points) > 0 || sub.dropped > 0 {
+			points, dropped, closed = sub.points, sub.dropped, sub.closed
+			sub.points = nil
+			sub.dropped = 0
+			sub.mux.Unlock()
+			return
+		}
+		sub.mux.Unlock()
+		select {
+		case <-sub.notify:
+		case <-timer.C:
+			return nil, 0, false
+		}
+	}
+}
+
+func (instance *Instance) publish(namespace int, id uint64, times []uint64, values []float64, typed []types.Value) {
+	opts := instance.opts.Subscriptions
+	bufferSize := opts.BufferSize
+	if bufferSize < 1 {
+		bufferSize = DefaultSubscriptionBufferSize
+	}
+	// subscribers format typed values with the value type of the series
+	var valueType types.ValueType
+	if len(typed) > 0 && atomic.LoadInt32(&instance.subscriptionsCount) > 0 {
+		list := instance.metaStore.ListSeries(&backend.ListSeries{Namespace: namespace, Ids: []uint64{id}})
+		if list.Error == nil && len(list.Series) == 1 {
+			valueType = list.Series[0].ValueType
+		}
+	}
+	instance.Subscriptions.publish(namespace, id, times, values, typed, valueType, bufferSize, opts.SlowConsumer == SlowConsumerDisconnect)
+}
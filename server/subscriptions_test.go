@@ -0,0 +1,30 @@
+package server
+
+import (
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"testing"
+	"time"
+)
+
+func TestRemoveIdleSubscriptions(t *testing.T) {
+	s := NewSubscriptions()
+	idle := &subscription{ids: map[uint64]bool{1: true}}
+	active := &subscription{ids: map[uint64]bool{1: true}}
+	s.addSubscription(idle)
+	s.addSubscription(active)
+	s.publish(1, 1, []uint64{1000}, []float64{1}, nil, types.ValueTypeFloat, DefaultSubscriptionBufferSize, false)
+
+	// not polled since
+	idle.mux.Lock()
+	idle.lastPoll = time.Now().Add(-2 * subscriptionIdleTimeout)
+	idle.mux.Unlock()
+	if n := s.removeIdleSubscriptions(); n != 1 {
+		t.Errorf("expected 1 idle subscription removed, was %d", n)
+	}
+	if s.ActiveSubscriptions() != 1 || s.getSubscription(active.id, nil) == nil {
+		t.Error("expected active subscription to be kept")
+	}
+	if !idle.closed || idle.points != nil {
+		t.Error("expected idle subscription to be closed and its points released")
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/server/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (instance *Instance) tracer() trace.Tracer {
+	return rpc.Tracer(instance.opts.TracerProvider)
+}
+
+// span of an endpoint call, child of the client span if the request carries a trace context
+func (instance *Instance) startEndpointSpan(endpoint types.Endpoint, traceContext *types.TraceContext) (context.Context, trace.Span) {
+	ctx := rpc.ExtractTraceContext(context.Background(), traceContext)
+	return instance.tracer().Start(ctx, endpoint.String(), trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// span of a backend call within an endpoint call, e.g. backend.Write
+func (instance *Instance) startBackendSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return instance.tracer().Start(ctx, operation, trace.WithAttributes(attributes...))
+}
+
+func seriesAttributes(c backend.Context) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int("tsxdb.namespace", c.Namespace),
+		attribute.Int64("tsxdb.series", int64(c.Series)),
+	}
+}
+
+func endSpan(span trace.Span, rpcError *types.RpcError) {
+	if rpcError != nil {
+		rpc.EndSpan(span, rpcError.Error())
+		return
+	}
+	rpc.EndSpan(span, nil)
+}
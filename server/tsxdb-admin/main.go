@@ -0,0 +1,252 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var configPathsStr string
+var listenHost string
+var listenPort int
+var user string
+var authToken string
+var namespace int
+var seriesId uint64
+var name string
+var sessionId int
+var path string
+var limit int
+
+func init() {
+	flag.StringVar(&configPathsStr, "config", "", "Connection configuration file (path(s)), optional")
+	flag.StringVar(&listenHost, "host", "", "Server host (overrides config)")
+	flag.IntVar(&listenPort, "port", 0, "Server port (overrides config)")
+	flag.StringVar(&user, "user", "", "User with admin rights on all namespaces, empty for the shared auth token")
+	flag.StringVar(&authToken, "token", os.Getenv("TSXDB_AUTH_TOKEN"), "Auth token (defaults to $TSXDB_AUTH_TOKEN)")
+	flag.IntVar(&namespace, "namespace", 0, "Namespace of series, describe and delete")
+	flag.Uint64Var(&seriesId, "id", 0, "Series id of describe and delete")
+	flag.StringVar(&name, "name", "", "Only series containing this in their name")
+	flag.IntVar(&sessionId, "session", 0, "Session id to kick")
+	flag.StringVar(&path, "file", "", "Archive file in the snapshot directory of the server to snapshot to or restore from")
+	flag.IntVar(&limit, "limit", 10, "Top label keys and creators of cardinality, 0 for all")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "usage: tsxdb-admin [flags] <command>\n\ncommands: %s\n\nflags:\n", strings.Join(commandNames(), ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+var commands = map[types.AdminCommand]func(request *types.AdminRequest) error{
+	types.AdminCommandNamespaces:  nil,
+	types.AdminCommandSeries:      nil,
+	types.AdminCommandDescribe:    requireSeries,
+	types.AdminCommandDelete:      requireSeries,
+	types.AdminCommandStats:       nil,
+	types.AdminCommandSessions:    nil,
+	types.AdminCommandConnections: nil,
+	types.AdminCommandKick: func(request *types.AdminRequest) error {
+		if request.Session == 0 {
+			return fmt.Errorf("missing -session")
+		}
+		return nil
+	},
+	types.AdminCommandFlush:       nil,
+	types.AdminCommandCompact:     nil,
+	types.AdminCommandSnapshot:    requirePath,
+	types.AdminCommandRestore:     requirePath,
+	types.AdminCommandAlerts:      nil,
+	types.AdminCommandCardinality: nil,
+}
+
+func requireSeries(request *types.AdminRequest) error {
+	if request.Series == 0 {
+		return fmt.Errorf("missing -id")
+	}
+	return nil
+}
+
+func requirePath(request *types.AdminRequest) error {
+	if len(request.Path) < 1 {
+		return fmt.Errorf("missing -file")
+	}
+	return nil
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for command := range commands {
+		names = append(names, string(command))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func main() {
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := types.AdminCommand(flag.Arg(0))
+	validate, found := commands[command]
+	if !found {
+		flag.Usage()
+		os.Exit(2)
+	}
+	request := types.AdminRequest{
+		Command:   command,
+		Namespace: namespace,
+		Series:    seriesId,
+		Name:      name,
+		Session:   sessionId,
+		Path:      path,
+		Limit:     limit,
+	}
+	if validate != nil {
+		if err := validate(&request); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// connection
+	opts := client.NewOpts()
+	if len(configPathsStr) > 0 {
+		connectionOpts := rpc.NewOptsConnection()
+		if err := tools.ReadYamlFileInPath(configPathsStr, &connectionOpts); err != nil {
+			log.Fatalf("failed to read config %s", err)
+		}
+		opts.OptsConnection = connectionOpts
+	}
+	if len(listenHost) > 0 {
+		opts.ListenHost = listenHost
+	}
+	if listenPort > 0 {
+		opts.ListenPort = listenPort
+	}
+	if len(user) > 0 {
+		opts.User = user
+	}
+	if len(authToken) > 0 {
+		opts.AuthToken = authToken
+	}
+	opts.EagerInitSeries = false
+	c := client.New(opts)
+	defer c.Close()
+
+	response, err := c.Admin(request)
+	if err != nil {
+		log.Fatalf("%s failed: %s", command, err)
+	}
+	printResponse(command, response)
+}
+
+func printResponse(command types.AdminCommand, response *types.AdminResponse) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() {
+		_ = w.Flush()
+	}()
+	switch command {
+	case types.AdminCommandNamespaces:
+		_, _ = fmt.Fprintln(w, "NAMESPACE")
+		for _, namespace := range response.Namespaces {
+			_, _ = fmt.Fprintf(w, "%d\n", namespace)
+		}
+	case types.AdminCommandSeries:
+		_, _ = fmt.Fprintln(w, "NAMESPACE\tID\tNAME\tTAGS\tTTL EXPIRE")
+		for _, series := range response.Series {
+			_, _ = fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", series.Namespace, series.Id, series.Name, strings.Join(series.Tags, ","), formatUnix(int64(series.TtlExpire)))
+		}
+	case types.AdminCommandDescribe:
+		for _, series := range response.Series {
+			_, _ = fmt.Fprintf(w, "namespace\t%d\n", series.Namespace)
+			_, _ = fmt.Fprintf(w, "id\t%d\n", series.Id)
+			_, _ = fmt.Fprintf(w, "name\t%s\n", series.Name)
+			_, _ = fmt.Fprintf(w, "tags\t%s\n", strings.Join(series.Tags, ","))
+			if len(series.Labels) > 0 {
+				labels := make([]string, 0, len(series.Labels))
+				for _, name := range types.SortedLabelNames(series.Labels) {
+					labels = append(labels, name+"="+series.Labels[name])
+				}
+				_, _ = fmt.Fprintf(w, "labels\t%s\n", strings.Join(labels, ","))
+			}
+			_, _ = fmt.Fprintf(w, "ttl expire\t%s\n", formatUnix(int64(series.TtlExpire)))
+			_, _ = fmt.Fprintf(w, "value type\t%s\n", series.ValueType)
+			if len(series.Enum) > 0 {
+				_, _ = fmt.Fprintf(w, "enum\t%s\n", strings.Join(series.Enum, ","))
+			}
+			if len(series.Fields) > 0 {
+				_, _ = fmt.Fprintf(w, "fields\t%s\n", strings.Join(series.Fields, ","))
+			}
+			_, _ = fmt.Fprintf(w, "points\t%d\n", series.Points)
+			if series.Points > 0 {
+				_, _ = fmt.Fprintf(w, "from\t%s\n", formatUnixMillis(series.From))
+				_, _ = fmt.Fprintf(w, "to\t%s\n", formatUnixMillis(series.To))
+			}
+		}
+	case types.AdminCommandStats:
+		names := make([]string, 0, len(response.Stats))
+		for name := range response.Stats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			_, _ = fmt.Fprintf(w, "%s\t%d\n", name, response.Stats[name])
+		}
+	case types.AdminCommandSessions:
+		_, _ = fmt.Fprintln(w, "ID\tUSER\tIDENTITY\tEXPIRES")
+		for _, session := range response.Sessions {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", session.Id, session.User, session.Identity, formatUnix(session.Expires))
+		}
+	case types.AdminCommandConnections:
+		_, _ = fmt.Fprintln(w, "REMOTE")
+		for _, addr := range response.Connections {
+			_, _ = fmt.Fprintln(w, addr)
+		}
+	case types.AdminCommandAlerts:
+		_, _ = fmt.Fprintln(w, "RULE\tNAMESPACE\tID\tNAME\tSTATE\tVALUE\tSINCE")
+		for _, alert := range response.Alerts {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%v\t%s\n", alert.Rule, alert.Namespace, alert.Id, alert.Name, alert.State, alert.Value, formatUnix(alert.Since))
+		}
+	case types.AdminCommandCardinality:
+		cardinality := response.Cardinality
+		_, _ = fmt.Fprintf(w, "series\t%d\n", cardinality.Series)
+		_, _ = fmt.Fprintln(w, "\nLABEL\tVALUES")
+		for _, label := range cardinality.Labels {
+			_, _ = fmt.Fprintf(w, "%s\t%d\n", label.Key, label.Count)
+		}
+		_, _ = fmt.Fprintln(w, "\nDAY\tSERIES")
+		for _, day := range cardinality.Days {
+			_, _ = fmt.Fprintf(w, "%s\t%d\n", day.Key, day.Count)
+		}
+		_, _ = fmt.Fprintln(w, "\nUSER\tSESSION\tSERIES")
+		for _, creator := range cardinality.Creators {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", creator.User, creator.Session, creator.Series)
+		}
+	case types.AdminCommandSnapshot, types.AdminCommandRestore:
+		_, _ = fmt.Fprintf(w, "%s: %d series, %d points\n", command, response.Affected, response.Points)
+	case types.AdminCommandDelete, types.AdminCommandKick, types.AdminCommandCompact:
+		_, _ = fmt.Fprintf(w, "%s: %d affected\n", command, response.Affected)
+	default:
+		_, _ = fmt.Fprintf(w, "%s: ok\n", command)
+	}
+}
+
+func formatUnix(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
+
+func formatUnixMillis(ts uint64) string {
+	return time.Unix(0, int64(ts)*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano)
+}
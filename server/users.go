@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+type Right string
+
+const RightRead Right = "read"
+const RightWrite Right = "write"
+const RightAdmin Right = "admin" // implies read and write
+
+// the user authenticating with the shared auth token
+const SharedTokenUser = ""
+
+type User struct {
+	name        string
+	token       []byte
+	identity    string
+	permissions []Permission
+}
+
+func (user *User) Name() string {
+	return user.name
+}
+
+type Permission struct {
+	namespaces map[int]bool // nil for all namespaces
+	rights     map[Right]bool
+}
+
+func (permission Permission) allows(namespace int, right Right) bool {
+	if permission.namespaces != nil && !permission.namespaces[namespace] {
+		return false
+	}
+	return permission.rights[right] || permission.rights[RightAdmin]
+}
+
+// can the user exercise this right in the namespace
+func (user *User) Allowed(namespace int, right Right) bool {
+	if user == nil {
+		return false
+	}
+	for _, permission := range user.permissions {
+		if permission.allows(namespace, right) {
+			return true
+		}
+	}
+	return false
+}
+
+// admin of all namespaces, required for server wide operations
+func (user *User) Admin() bool {
+	if user == nil {
+		return false
+	}
+	for _, permission := range user.permissions {
+		if permission.namespaces == nil && permission.rights[RightAdmin] {
+			return true
+		}
+	}
+	return false
+}
+
+func NewUser(opts UserOpts) (*User, error) {
+	if len(strings.TrimSpace(opts.Name)) < 1 {
+		return nil, errors.New("user without name")
+	}
+	if len(strings.TrimSpace(opts.AuthToken)) < 1 {
+		return nil, fmt.Errorf("user %s without auth token", opts.Name)
+	}
+	user := &User{
+		name:        opts.Name,
+		token:       []byte(opts.AuthToken),
+		identity:    opts.Identity,
+		permissions: make([]Permission, 0),
+	}
+	for _, permissionOpts := range opts.Permissions {
+		permission := Permission{
+			rights: make(map[Right]bool),
+		}
+		if len(permissionOpts.Namespaces) > 0 {
+			permission.namespaces = make(map[int]bool)
+			for _, namespace := range permissionOpts.Namespaces {
+				permission.namespaces[namespace] = true
+			}
+		}
+		for _, right := range permissionOpts.Rights {
+			switch Right(strings.ToLower(right)) {
+			case RightRead, RightWrite, RightAdmin:
+				permission.rights[Right(strings.ToLower(right))] = true
+			default:
+				return nil, fmt.Errorf("user %s has unknown right %s", opts.Name, right)
+			}
+		}
+		user.permissions = append(user.permissions, permission)
+	}
+	return user, nil
+}
+
+func newSharedTokenUser(token string) *User {
+	return &User{
+		name:  SharedTokenUser,
+		token: []byte(token),
+		permissions: []Permission{
+			{
+				rights: map[Right]bool{RightAdmin: true},
+			},
+		},
+	}
+}
+
+func (instance *Instance) initUsers() error {
+	instance.users = make(map[string]*User)
+	if len(strings.TrimSpace(instance.opts.AuthToken)) > 0 {
+		instance.users[SharedTokenUser] = newSharedTokenUser(instance.opts.AuthToken)
+	}
+	for _, userOpts := range instance.opts.Users {
+		user, err := NewUser(userOpts)
+		if err != nil {
+			return err
+		}
+		if _, found := instance.users[user.name]; found {
+			return fmt.Errorf("duplicate user %s", user.name)
+		}
+		instance.users[user.name] = user
+	}
+
+	// must have auth
+	if len(instance.users) < 1 {
+		return errors.New("missing mandatory auth token option (or users)")
+	}
+	return nil
+}
+
+func (instance *Instance) getUser(name string) *User {
+	// read only after init, no locking needed
+	return instance.users[name]
+}
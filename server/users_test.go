@@ -0,0 +1,65 @@
+package server_test
+
+import (
+	"github.com/RobinUS2/tsxdb/server"
+	"testing"
+)
+
+func TestUser_Allowed(t *testing.T) {
+	user, err := server.NewUser(server.UserOpts{
+		Name:      "teamA",
+		AuthToken: "secret",
+		Permissions: []server.PermissionOpts{
+			{Namespaces: []int{1, 2}, Rights: []string{"read", "write"}},
+			{Namespaces: []int{3}, Rights: []string{"READ"}},
+			{Namespaces: []int{4}, Rights: []string{"admin"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		namespace int
+		right     server.Right
+		expected  bool
+	}{
+		{1, server.RightRead, true},
+		{2, server.RightWrite, true},
+		{3, server.RightRead, true},
+		{3, server.RightWrite, false},
+		{4, server.RightWrite, true},
+		{4, server.RightAdmin, true},
+		{1, server.RightAdmin, false},
+		{0, server.RightRead, false},
+	}
+	for _, c := range cases {
+		if user.Allowed(c.namespace, c.right) != c.expected {
+			t.Errorf("namespace %d right %s expected %v", c.namespace, c.right, c.expected)
+		}
+	}
+
+	// all namespaces
+	user, err = server.NewUser(server.UserOpts{
+		Name:        "reader",
+		AuthToken:   "secret",
+		Permissions: []server.PermissionOpts{{Rights: []string{"read"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Allowed(123, server.RightRead) || user.Allowed(123, server.RightWrite) {
+		t.Error("expected read only on all namespaces")
+	}
+}
+
+func TestNewUserInvalid(t *testing.T) {
+	if _, err := server.NewUser(server.UserOpts{AuthToken: "secret"}); err == nil {
+		t.Error("name is required")
+	}
+	if _, err := server.NewUser(server.UserOpts{Name: "teamA"}); err == nil {
+		t.Error("auth token is required")
+	}
+	if _, err := server.NewUser(server.UserOpts{Name: "teamA", AuthToken: "secret", Permissions: []server.PermissionOpts{{Rights: []string{"delete"}}}}); err == nil {
+		t.Error("unknown right")
+	}
+}
@@ -1,11 +1,20 @@
 package telnet
 
+import (
+	"github.com/RobinUS2/tsxdb/rpc"
+	"github.com/sirupsen/logrus"
+)
+
 type Opts struct {
 	Host       string
 	Port       int
 	AuthToken  string
+	UserAuth   bool // named users authenticate against the server, no shared auth token required
 	ServerHost string
 	ServerPort int
+	Tls        rpc.OptsTls        // tls of the telnet listener, identities of client certificates are verified here only
+	ServerTls  rpc.OptsTls        // tls used to connect to the server
+	Logger     logrus.FieldLogger // optional, defaults to the standard logger
 }
 
 func NewOpts() *Opts {
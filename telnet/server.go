@@ -4,10 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"github.com/RobinUS2/tsxdb/rpc"
 	"github.com/reiver/go-oi"
 	tel "github.com/reiver/go-telnet" // weird things happen if package with same name is imported as the package/module it's in unless aliased
+	"github.com/sirupsen/logrus"
 	"io"
-	"log"
 	"net"
 	"strconv"
 	"strings"
@@ -51,14 +52,14 @@ func (instance *Instance) SetListener(listener net.Listener) {
 }
 
 func (instance *Instance) Listen() error {
-	if len(strings.TrimSpace(instance.opts.AuthToken)) < 1 {
+	if len(strings.TrimSpace(instance.opts.AuthToken)) < 1 && !instance.opts.UserAuth {
 		return errors.New("missing auth token")
 	}
 	listenStr := fmt.Sprintf("%s:%d", instance.opts.Host, instance.opts.Port)
-	log.Printf("telnet listening at %s", listenStr)
+	instance.logger().Infof("telnet listening at %s", listenStr)
 
-	// listener
-	listener, err := net.Listen("tcp", listenStr)
+	// listener (tls if enabled, identities of client certificates are verified during the handshake)
+	listener, err := rpc.Listen(listenStr, instance.opts.Tls)
 	if err != nil {
 		return err
 	}
@@ -78,7 +79,7 @@ func (instance *Instance) Shutdown() error {
 		if err := instance.Listener().Close(); err != nil {
 			return err
 		}
-		log.Println("tel listener shutdown")
+		instance.logger().Info("telnet listener shutdown")
 	}
 	instance.SetListener(nil)
 	instance.SetServer(nil)
@@ -91,6 +92,7 @@ func (instance *Instance) Serve(w tel.Writer, r tel.Reader) {
 	lines := make(chan string, 1)
 	session := NewSession(instance)
 	session.SetWriter(w)
+	defer session.Close()
 
 	// into line buffer
 	const newline = '\n'
@@ -230,6 +232,13 @@ func (instance *Instance) ServeTELNET(ctx tel.Context, w tel.Writer, r tel.Reade
 	instance.Serve(w, r)
 }
 
+func (instance *Instance) logger() logrus.FieldLogger {
+	if instance.opts.Logger == nil {
+		return logrus.StandardLogger()
+	}
+	return instance.opts.Logger
+}
+
 func New(opts *Opts) *Instance {
 	return &Instance{
 		opts: opts,
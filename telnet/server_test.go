@@ -3,8 +3,12 @@ package telnet_test
 import (
 	"errors"
 	"fmt"
+	"github.com/RobinUS2/tsxdb/client"
+	"github.com/RobinUS2/tsxdb/rpc/types"
 	"github.com/RobinUS2/tsxdb/server"
 	"github.com/RobinUS2/tsxdb/telnet"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"io"
 	"math"
 	"strings"
@@ -69,6 +73,9 @@ func TestInstance_ServeTELNET(t *testing.T) {
 	o.AuthToken = serverOpts.AuthToken
 	o.ServerPort = serverOpts.ListenPort
 	o.ServerHost = serverOpts.ListenHost
+	logger, logs := logtest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	o.Logger = logger
 	instance := telnet.New(o)
 	w := &MockWriter{
 		output: make(chan string, 1),
@@ -264,6 +271,16 @@ func TestInstance_ServeTELNET(t *testing.T) {
 		}
 	}()
 	instance.Serve(w, r)
+
+	// lines are logged at debug level, credentials never
+	if len(logs.AllEntries()) < 1 {
+		t.Error("expected debug logs")
+	}
+	for _, entry := range logs.AllEntries() {
+		if strings.Contains(entry.Message, serverOpts.AuthToken) {
+			t.Errorf("credentials logged %s", entry.Message)
+		}
+	}
 }
 
 func bytesToChan(line []byte, c chan byte) {
@@ -280,3 +297,126 @@ type test struct {
 func (test *test) validate(s string) error {
 	return test.validationFn(s)
 }
+
+func TestInstance_Subscribe(t *testing.T) {
+	serverOpts := server.NewOpts()
+	serverOpts.AuthToken = "verySecure"
+	s := server.New(serverOpts)
+	if err := s.Init(); err != nil {
+		t.Error(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal("server could not be started", err)
+	}
+	defer func() {
+		_ = s.Shutdown()
+	}()
+
+	o := telnet.NewOpts()
+	o.AuthToken = serverOpts.AuthToken
+	o.ServerPort = serverOpts.ListenPort
+	o.ServerHost = serverOpts.ListenHost
+	instance := telnet.New(o)
+	w := &MockWriter{
+		output: make(chan string, 10),
+	}
+	r := &MockReader{
+		data:     make(chan byte, 100),
+		shutdown: make(chan bool, 1),
+	}
+	done := make(chan bool)
+	go func() {
+		instance.Serve(w, r)
+		done <- true
+	}()
+
+	// read the replies of a command, in any order since messages are pushed asynchronously
+	send := func(cmd string, expect ...string) {
+		bytesToChan([]byte(cmd+"\r\n"), r.data)
+		received := make(map[string]bool)
+		for range expect {
+			select {
+			case line := <-w.output:
+				received[strings.TrimSpace(line)] = true
+			case <-time.After(10 * time.Second):
+				t.Fatalf("%s timed out", cmd)
+			}
+		}
+		for _, line := range expect {
+			if !received[line] {
+				t.Errorf("%s expected %q got %v", cmd, line, received)
+			}
+		}
+	}
+	send("auth verySecure", "+OK")
+	send("SUBSCRIBE subscribedSeries", "*3\r\n$9\r\nsubscribe\r\n$16\r\nsubscribedSeries\r\n:1")
+	send("SUBSCRIBE subscribedSeries tag:subscribedTag",
+		"*3\r\n$9\r\nsubscribe\r\n$16\r\nsubscribedSeries\r\n:1",
+		"*3\r\n$9\r\nsubscribe\r\n$17\r\ntag:subscribedTag\r\n:2",
+	)
+
+	// series is created after subscribing
+	bytesToChan([]byte("ZADD subscribedSeries 1558110305 10.5\r\n"), r.data)
+	received := make([]string, 0)
+	for len(received) < 2 {
+		select {
+		case line := <-w.output:
+			received = append(received, strings.TrimSpace(line))
+		case <-time.After(10 * time.Second):
+			t.Fatalf("no message, received %v", received)
+		}
+	}
+	var message string
+	for _, line := range received {
+		if strings.Contains(line, "message") {
+			message = line
+		} else if line != ":1" {
+			t.Errorf("unexpected %q", line)
+		}
+	}
+	clientOpts := client.NewOpts()
+	clientOpts.AuthToken = serverOpts.AuthToken
+	clientOpts.ListenHost = serverOpts.ListenHost
+	clientOpts.ListenPort = serverOpts.ListenPort
+	c := client.New(clientOpts)
+	defer c.Close()
+	found, err := c.SearchSeries(0, "subscribedSeries", "")
+	if err != nil || len(found) != 1 {
+		t.Fatal(err, found)
+	}
+	expectMessage := func(message string, channel string, payload string) {
+		expected := fmt.Sprintf("*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s", len(channel), channel, len(payload), payload)
+		if message != expected {
+			t.Errorf("expected message %q got %q", expected, message)
+		}
+	}
+	expectMessage(message, "subscribedSeries", fmt.Sprintf("%d 1558110305 10.5", found[0].Id()))
+
+	// typed values are formatted with the value type of their series
+	flag := c.Series("flagSeries", client.NewSeriesValueType(types.ValueTypeBool))
+	if _, err := flag.Create(); err != nil {
+		t.Fatal(err)
+	}
+	send("SUBSCRIBE flagSeries", "*3\r\n$9\r\nsubscribe\r\n$10\r\nflagSeries\r\n:3")
+	now := c.Now()
+	if res := flag.WriteValue(now, types.BoolValue(false)); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	select {
+	case line := <-w.output:
+		expectMessage(strings.TrimSpace(line), "flagSeries", fmt.Sprintf("%d %d false", flag.Id(), now))
+	case <-time.After(10 * time.Second):
+		t.Fatal("no typed message")
+	}
+
+	send("UNSUBSCRIBE subscribedSeries", "*3\r\n$11\r\nunsubscribe\r\n$16\r\nsubscribedSeries\r\n:2")
+	send("UNSUBSCRIBE",
+		"*3\r\n$11\r\nunsubscribe\r\n$10\r\nflagSeries\r\n:1",
+		"*3\r\n$11\r\nunsubscribe\r\n$17\r\ntag:subscribedTag\r\n:0",
+	)
+	send("UNSUBSCRIBE", "*3\r\n$11\r\nunsubscribe\r\n$-1\r\n:0")
+	send("SUBSCRIBE", "-ERR missing channel")
+
+	r.shutdown <- true
+	<-done
+}
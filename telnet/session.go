@@ -7,9 +7,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/reiver/go-oi"
 	tel "github.com/reiver/go-telnet"
-	"log"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const successMessage = "+OK"
@@ -18,6 +19,9 @@ const redisAddToSortedSetCommand = "ZADD"              // ZADD key [NX|XX] [CH]
 const redisRemoveFromSortedSetCommand = "ZREM"         // ZREM key member [member ...] https://redis.io/commands/zrem
 const redisRangeFromSortedSetCommand = "ZRANGEBYSCORE" // ZRANGEBYSCORE key min max [WITHSCORES] [LIMIT offset count] https://redis.io/commands/zrangebyscore
 const redisExistsCommand = "EXISTS"                    // EXISTS key [key ...] https://redis.io/commands/exists
+const redisSubscribeCommand = "SUBSCRIBE"              // SUBSCRIBE channel [channel ...] https://redis.io/commands/subscribe
+const redisUnsubscribeCommand = "UNSUBSCRIBE"          // UNSUBSCRIBE [channel [channel ...]] https://redis.io/commands/unsubscribe
+const subscribeTagPrefix = "tag:"                      // channel tag:x subscribes to all series with tag x, else the channel is a series name
 
 type Mode string
 
@@ -30,10 +34,14 @@ type Session struct {
 	authenticated bool
 	mode          Mode
 	client        *client.Instance
+	writeMux      sync.Mutex // subscriptions write from their own go-routine
+
+	subscriptions    map[string]*client.Subscription // channel => subscription
+	subscriptionsMux sync.Mutex
 }
 
 func (session *Session) SetMode(mode Mode) {
-	log.Printf("session in mode %s", mode)
+	session.instance.logger().Debugf("session in mode %s", mode)
 	session.mode = mode
 }
 
@@ -49,7 +57,12 @@ func (session *Session) Handle(typedLine InputLine) (err error) {
 		return nil
 	}
 	upperLine := strings.ToUpper(line)
-	log.Printf("telnet rcv %s", line)
+	if strings.HasPrefix(upperLine, "AUTH") {
+		// never log credentials
+		session.instance.logger().Debug("telnet rcv AUTH")
+	} else {
+		session.instance.logger().Debugf("telnet rcv %s", line)
+	}
 
 	// tokens
 	tokens := strings.Split(line, " ")
@@ -61,17 +74,25 @@ func (session *Session) Handle(typedLine InputLine) (err error) {
 			return session.WriteErrMessage(errors.New("missing auth token"))
 		}
 
-		// first check local
+		// AUTH token (shared auth token) or AUTH user token (named user)
+		var user string
 		token := tokens[1]
-		if token != session.instance.opts.AuthToken {
+		if len(tokens) > 2 {
+			user = tokens[1]
+			token = tokens[2]
+		} else if token != session.instance.opts.AuthToken {
+			// first check local
 			return session.WriteErrMessage(errors.New("invalid auth token"))
 		}
 
-		// real remote auth
+		// real remote auth (named users are only verified by the server)
 		clientOpts := client.NewOpts()
+		clientOpts.User = user
 		clientOpts.AuthToken = token
 		clientOpts.ListenHost = session.instance.opts.ServerHost
 		clientOpts.ListenPort = session.instance.opts.ServerPort
+		clientOpts.Tls = session.instance.opts.ServerTls
+		clientOpts.Logger = session.instance.opts.Logger
 		session.client = client.New(clientOpts)
 
 		// this verifies auth with the server
@@ -195,6 +216,10 @@ func (session *Session) Handle(typedLine InputLine) (err error) {
 			// val first (score in redis terms)
 			{
 				valStr := fmt.Sprintf("%v", val)
+				if res.ValueType.Typed() {
+					_, typed := resultIterator.TypedValue()
+					valStr = typed.Format(res.ValueType)
+				}
 				valStrLen := len(valStr)
 				resultBuffer.Write([]byte(fmt.Sprintf("$%d\r\n", valStrLen)))
 				resultBuffer.Write([]byte(valStr + "\r\n"))
@@ -209,12 +234,117 @@ func (session *Session) Handle(typedLine InputLine) (err error) {
 			}
 		}
 		return session.Write(resultBuffer.String())
+	} else if command == redisSubscribeCommand {
+		// SUBSCRIBE mySeries tag:myTag
+		if len(tokens) < 2 {
+			return session.WriteErrMessage(errors.New("missing channel"))
+		}
+		for _, channel := range tokens[1:] {
+			if err := session.subscribe(channel); err != nil {
+				return err
+			}
+		}
+		return nil
+	} else if command == redisUnsubscribeCommand {
+		// without channels all are unsubscribed
+		return session.unsubscribe(tokens[1:])
 	} else {
 		// command not found
 		return session.WriteErrMessage(errors.New(fmt.Sprintf("command %s not found", command)))
 	}
 }
 
+func (session *Session) subscribe(channel string) error {
+	session.subscriptionsMux.Lock()
+	defer session.subscriptionsMux.Unlock()
+	if _, found := session.subscriptions[channel]; !found {
+		filter := client.SubscriptionFilter{Name: channel}
+		if strings.HasPrefix(channel, subscribeTagPrefix) {
+			filter = client.SubscriptionFilter{Tag: strings.TrimPrefix(channel, subscribeTagPrefix)}
+		}
+		sub, err := session.client.Subscribe(0, filter)
+		if err != nil {
+			return err
+		}
+		session.subscriptions[channel] = sub
+		go session.forward(channel, sub)
+	}
+	// array format https://redis.io/topics/pubsub#format-of-pushed-messages
+	return session.Write(redisArray(3, "subscribe", channel) + fmt.Sprintf(":%d", len(session.subscriptions)))
+}
+
+func (session *Session) unsubscribe(channels []string) error {
+	session.subscriptionsMux.Lock()
+	defer session.subscriptionsMux.Unlock()
+	if len(channels) < 1 {
+		for channel := range session.subscriptions {
+			channels = append(channels, channel)
+		}
+		sort.Strings(channels)
+	}
+	if len(channels) < 1 {
+		return session.Write("*3\r\n$11\r\nunsubscribe\r\n$-1\r\n:0")
+	}
+	for _, channel := range channels {
+		if sub, found := session.subscriptions[channel]; found {
+			delete(session.subscriptions, channel)
+			if err := sub.Close(); err != nil {
+				return err
+			}
+		}
+		if err := session.Write(redisArray(3, "unsubscribe", channel) + fmt.Sprintf(":%d", len(session.subscriptions))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// push points as messages until the subscription ends
+func (session *Session) forward(channel string, sub *client.Subscription) {
+	for point := range sub.Points() {
+		// message payload: series id, timestamp and value
+		payload := fmt.Sprintf("%d %d %v", point.Id, point.Time, point.Value)
+		if point.Typed != nil {
+			payload = fmt.Sprintf("%d %d %s", point.Id, point.Time, point.Typed.Format(point.ValueType))
+		}
+		if err := session.Write(redisArray(3, "message", channel, payload)); err != nil {
+			session.instance.logger().Warnf("telnet subscription %s write failed: %s", channel, err)
+			_ = sub.Close()
+			return
+		}
+	}
+	if err := sub.Err(); err != nil {
+		session.subscriptionsMux.Lock()
+		if session.subscriptions[channel] == sub {
+			delete(session.subscriptions, channel)
+		}
+		session.subscriptionsMux.Unlock()
+		_ = session.WriteErrMessage(errors.Wrapf(err, "subscription %s", channel))
+	}
+}
+
+// end all subscriptions, called once the connection is gone
+func (session *Session) Close() {
+	session.subscriptionsMux.Lock()
+	defer session.subscriptionsMux.Unlock()
+	for channel, sub := range session.subscriptions {
+		if err := sub.Close(); err != nil {
+			session.instance.logger().Warnf("telnet subscription %s close failed: %s", channel, err)
+		}
+		delete(session.subscriptions, channel)
+	}
+}
+
+// array of bulk strings https://redis.io/topics/protocol#array-reply, size may exceed the values so an integer can follow
+func redisArray(size int, values ...string) string {
+	buffer := bytes.Buffer{}
+	buffer.WriteString(fmt.Sprintf("*%d\r\n", size))
+	for _, value := range values {
+		buffer.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(value), value))
+	}
+	return buffer.String()
+}
+
 func (session *Session) SetWriter(writer tel.Writer) {
 	session.writer = writer
 }
@@ -228,8 +358,10 @@ func (session *Session) Write(s string) error {
 	if !strings.HasSuffix(s, "\r\n") {
 		s = s + "\r\n"
 	}
-	log.Printf("telnet send %s", s)
+	session.instance.logger().Debugf("telnet send %s", strings.TrimRight(s, "\r\n"))
 	b := []byte(s)
+	session.writeMux.Lock()
+	defer session.writeMux.Unlock()
 	if nWritten, err := oi.LongWrite(session.writer, b); err != nil || int64(len(b)) != nWritten {
 		return err
 	}
@@ -238,8 +370,9 @@ func (session *Session) Write(s string) error {
 
 func NewSession(instance *Instance) *Session {
 	return &Session{
-		instance: instance,
-		mode:     ModePlain,
+		instance:      instance,
+		mode:          ModePlain,
+		subscriptions: make(map[string]*client.Subscription),
 	}
 }
 
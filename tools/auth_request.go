@@ -21,6 +21,8 @@ func BasicAuthRequest(opts rpc.OptsConnection) (request types.AuthRequest, err e
 
 	// request (single)
 	request = types.AuthRequest{
+		Version:   types.AuthVersion,
+		User:      opts.User,
 		Nonce:     base64.StdEncoding.EncodeToString(nonce),
 		Signature: base64.StdEncoding.EncodeToString(signature),
 	}
@@ -11,6 +11,7 @@ require (
 	github.com/RobinUS2/tsxdb/rpc v0.0.0-20200831110925-b62f451e618d
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b
+	github.com/sirupsen/logrus v1.9.0
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 	gopkg.in/yaml.v2 v2.3.0
 )
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+// structured fields shared by server, client and telnet logs
+const LogFieldSession = "session"
+const LogFieldUser = "user"
+const LogFieldNamespace = "namespace"
+const LogFieldSeries = "series"
+const LogFieldRequest = "request"
+const LogFieldBackend = "backend"
+const LogFieldComponent = "component"
+const LogFieldSuppressed = "suppressed" // similar messages dropped by a LogSampler since the previous one
+
+const DefaultLogLevel = logrus.InfoLevel
+
+// leveled structured logger writing to stderr, level is e.g. debug, info, warn, error (empty for info)
+func NewLogger(level string) (*logrus.Logger, error) {
+	logger := logrus.New()
+	logger.SetLevel(DefaultLogLevel)
+	if len(strings.TrimSpace(level)) > 0 {
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %s", level)
+		}
+		logger.SetLevel(parsed)
+	}
+	return logger, nil
+}
+
+// logs at most one message per key and interval, e.g. for errors that can occur on every request
+// keys should be a small fixed set, e.g. the operation that failed
+type LogSampler struct {
+	interval   time.Duration
+	mux        sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+// logger to log the message with, nil if the message should be dropped
+func (sampler *LogSampler) Sample(logger logrus.FieldLogger, key string) logrus.FieldLogger {
+	now := time.Now()
+	sampler.mux.Lock()
+	defer sampler.mux.Unlock()
+	if last, found := sampler.last[key]; found && now.Sub(last) < sampler.interval {
+		sampler.suppressed[key]++
+		return nil
+	}
+	sampler.last[key] = now
+	suppressed := sampler.suppressed[key]
+	delete(sampler.suppressed, key)
+	if suppressed > 0 {
+		return logger.WithField(LogFieldSuppressed, suppressed)
+	}
+	return logger
+}
+
+func NewLogSampler(interval time.Duration) *LogSampler {
+	return &LogSampler{
+		interval:   interval,
+		last:       make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
@@ -0,0 +1,46 @@
+package tools_test
+
+import (
+	"github.com/RobinUS2/tsxdb/tools"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"testing"
+	"time"
+)
+
+func TestNewLogger(t *testing.T) {
+	logger, err := tools.NewLogger("")
+	if err != nil || logger.GetLevel() != tools.DefaultLogLevel {
+		t.Error(err)
+	}
+	logger, err = tools.NewLogger("debug")
+	if err != nil || logger.GetLevel() != logrus.DebugLevel {
+		t.Error(err)
+	}
+	if _, err := tools.NewLogger("loud"); err == nil {
+		t.Error("expected invalid level")
+	}
+}
+
+func TestLogSampler(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	sampler := tools.NewLogSampler(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if sampled := sampler.Sample(logger, "write"); sampled != nil {
+			sampled.Error("failed")
+		}
+	}
+	// other keys are sampled independently
+	if sampler.Sample(logger, "read") == nil {
+		t.Error("expected first read error to be logged")
+	}
+	if len(hook.AllEntries()) != 1 {
+		t.Error(len(hook.AllEntries()))
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	sampler.Sample(logger, "write").Error("failed")
+	if hook.LastEntry().Data[tools.LogFieldSuppressed] != 2 {
+		t.Errorf("%+v", hook.LastEntry().Data)
+	}
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+)
+
+// secret of a session, derived by client and server from the auth token and the nonces of both sides
+func SessionSecret(authToken []byte, clientNonce []byte, serverNonce []byte) []byte {
+	mac := hmac.New(sha256.New, authToken)
+	mac.Write([]byte("tsxdb session secret"))
+	mac.Write(clientNonce)
+	mac.Write(serverNonce)
+	return mac.Sum(nil)
+}
+
+// full length signature of a request binding session, nonce, endpoint and payload
+func SessionSignature(secret []byte, ticket types.SessionTicket, endpoint types.Endpoint, payload []byte) []byte {
+	var b [8]byte
+	mac := hmac.New(sha256.New, secret)
+	binary.BigEndian.PutUint64(b[:], uint64(types.AuthVersion))
+	mac.Write(b[:])
+	binary.BigEndian.PutUint64(b[:], uint64(ticket.Id))
+	mac.Write(b[:])
+	binary.BigEndian.PutUint64(b[:], ticket.Nonce)
+	mac.Write(b[:])
+	binary.BigEndian.PutUint64(b[:], uint64(len(endpoint)))
+	mac.Write(b[:])
+	mac.Write([]byte(endpoint))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// sign the request with the next nonce of the session
+func SignSessionTicket(secret []byte, sessionId int, nonce uint64, endpoint types.Endpoint, request types.SignedRequest) types.SessionTicket {
+	ticket := types.SessionTicket{
+		Id:    sessionId,
+		Nonce: nonce,
+	}
+	ticket.Signature = SessionSignature(secret, ticket, endpoint, request.SignaturePayload())
+	return ticket
+}
+
+// constant time verification of a ticket signature
+func VerifySessionTicket(secret []byte, ticket types.SessionTicket, endpoint types.Endpoint, request types.SignedRequest) bool {
+	return hmac.Equal(ticket.Signature, SessionSignature(secret, ticket, endpoint, request.SignaturePayload()))
+}
@@ -0,0 +1,54 @@
+package tools_test
+
+import (
+	"bytes"
+	"github.com/RobinUS2/tsxdb/rpc/types"
+	"github.com/RobinUS2/tsxdb/tools"
+	"testing"
+)
+
+func TestSessionSecret(t *testing.T) {
+	secret := tools.SessionSecret([]byte("token"), []byte("client"), []byte("server"))
+	if len(secret) != 32 {
+		t.Error(len(secret))
+	}
+	if bytes.Equal(secret, tools.SessionSecret([]byte("other"), []byte("client"), []byte("server"))) {
+		t.Error("secret must depend on the auth token")
+	}
+}
+
+func TestSignSessionTicket(t *testing.T) {
+	secret := []byte("secret")
+	request := types.WriteRequest{
+		Series: []types.WriteSeriesRequest{{
+			SeriesIdentifier: types.SeriesIdentifier{Id: 1},
+			Times:            []uint64{1},
+			Values:           []float64{1.5},
+		}},
+	}
+	request.SessionTicket = tools.SignSessionTicket(secret, 10, 1, types.EndpointWriter, request)
+	if len(request.SessionTicket.Signature) != 32 {
+		t.Error(len(request.SessionTicket.Signature))
+	}
+	if !tools.VerifySessionTicket(secret, request.SessionTicket, types.EndpointWriter, request) {
+		t.Error("expected valid signature")
+	}
+
+	// other endpoint
+	if tools.VerifySessionTicket(secret, request.SessionTicket, types.EndpointReader, request) {
+		t.Error("signature bound to endpoint")
+	}
+
+	// other nonce
+	ticket := request.SessionTicket
+	ticket.Nonce++
+	if tools.VerifySessionTicket(secret, ticket, types.EndpointWriter, request) {
+		t.Error("signature bound to nonce")
+	}
+
+	// tampered payload
+	request.Series[0].Values[0] = 2.5
+	if tools.VerifySessionTicket(secret, request.SessionTicket, types.EndpointWriter, request) {
+		t.Error("signature bound to payload")
+	}
+}